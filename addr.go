@@ -0,0 +1,94 @@
+package datahash
+
+import "reflect"
+
+// marshalsViaPointer reports whether values of t are hashed by a marshaler or hashing interface
+// that only *t implements if they are addressable, like encoding/json does. FormatV2 and later,
+// or Options.AutoAddress, do so for types without a marshaler of their own, unless a struct tag
+// forces a marshaler or t is registered with Structural.
+func (h *Hasher) marshalsViaPointer(t reflect.Type, cfg config) bool {
+	if h.opts.Format < FormatV2 && !h.opts.AutoAddress || cfg.prefer != "" || cfg.raw || t.Kind() == reflect.Pointer || t.Kind() == reflect.Interface || h.isStructural(t) {
+		return false
+	}
+
+	pt := reflect.PointerTo(t)
+
+	// The interfaces in the order of precedence of compile; *t implements those of t.
+	for _, implements := range []func(t reflect.Type) bool{
+		func(t reflect.Type) bool { return t.Implements(hashEncoderType) },
+		func(t reflect.Type) bool { return t.Implements(hashWriterToType) },
+		func(t reflect.Type) bool { return t.Implements(hashWriterType) },
+		func(t reflect.Type) bool { _, ok := h.hashMethod(t); return ok },
+		func(t reflect.Type) bool { return h.opts.Errors && t.Implements(errorType) },
+		func(t reflect.Type) bool { return t.Implements(binaryMarshalerType) },
+		func(t reflect.Type) bool { return cfg.text && t.Implements(textMarshalerType) },
+		func(t reflect.Type) bool { return cfg.json && t.Implements(jsonMarshalerType) },
+		func(t reflect.Type) bool { return cfg.xml && t.Implements(xmlMarshalerType) },
+		func(t reflect.Type) bool { return cfg.str && t.Implements(stringerType) },
+		func(t reflect.Type) bool { return h.opts.Gob && t.Implements(gobEncoderType) },
+	} {
+		if implements(pt) {
+			return !implements(t)
+		}
+	}
+
+	return false
+}
+
+// viaPointer wraps hf, the hash function of a type whose pointer type is hashed by phf, so that
+// addressable values are hashed by phf with their address, and in AutoAddress mode all others
+// with the address of a copy.
+func (h *Hasher) viaPointer(phf, hf hashFunc) hashFunc {
+	return func(value reflect.Value, c *container) error {
+		if p, ok := h.address(value); ok {
+			return phf(p, c)
+		}
+
+		return hf(value, c)
+	}
+}
+
+// inlinesViaPointer reports whether values described by info contain, directly or in the fields
+// and elements stored inline, a value hashed via its pointer type if it is addressable, so that
+// hashes depend on whether the outermost value is addressable.
+func inlinesViaPointer(info *TypeInfo) bool {
+	switch {
+	case info == nil:
+		return false
+	case info.viaPointer:
+		return true
+	case info.Strategy != StrategyKind:
+		return false
+	}
+
+	switch info.Type.Kind() {
+	case reflect.Struct:
+		for _, f := range info.Fields {
+			if inlinesViaPointer(f.Info) {
+				return true
+			}
+		}
+	case reflect.Array:
+		return inlinesViaPointer(info.Elem)
+	}
+
+	return false
+}
+
+// address returns the address of value if it is addressable, or in AutoAddress mode the
+// address of a copy. Values of unexported fields have no usable address.
+func (h *Hasher) address(value reflect.Value) (reflect.Value, bool) {
+	switch {
+	case !value.IsValid() || !value.CanInterface():
+		return reflect.Value{}, false
+	case value.CanAddr():
+		return value.Addr(), true
+	case h.opts.AutoAddress:
+		p := reflect.New(value.Type())
+		p.Elem().Set(value)
+
+		return p, true
+	}
+
+	return reflect.Value{}, false
+}
@@ -0,0 +1,91 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type version struct {
+	Major, Minor int
+	cache        string
+}
+
+func (v *version) MarshalBinary() ([]byte, error) {
+	return []byte{byte(v.Major), byte(v.Minor)}, nil
+}
+
+type release struct {
+	Name    string
+	Version version
+}
+
+func TestHasher_PointerReceivers(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV2})
+
+	a := &release{Name: "x", Version: version{Major: 1, Minor: 2, cache: "a"}}
+	b := &release{Name: "x", Version: version{Major: 1, Minor: 2, cache: "b"}}
+
+	if hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected addressable fields to hash by the pointer-receiver marshaler")
+	}
+
+	type withBytes struct {
+		Name    string
+		Version []byte
+	}
+
+	if hasher.MustHash(a) != hasher.MustHash(&withBytes{Name: "x", Version: []byte{1, 2}}) {
+		t.Error("expected addressable fields to hash like the marshaled bytes")
+	}
+
+	if hasher.MustHash(*a) == hasher.MustHash(*b) {
+		t.Error("expected non-addressable values to keep hashing by their fields")
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	if plain.MustHash(a) == plain.MustHash(b) {
+		t.Error("expected FormatV1 to keep hashing by the fields")
+	}
+
+	if explain := hasher.ExplainType(reflect.TypeFor[release]()); !strings.Contains(explain, "addressable values via BinaryMarshaler") {
+		t.Errorf("expected the pointer-receiver marshaler to be explained, got %s", explain)
+	}
+}
+
+func TestHasher_AutoAddress(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{AutoAddress: true})
+
+	a := release{Name: "x", Version: version{Major: 1, Minor: 2, cache: "a"}}
+	b := release{Name: "x", Version: version{Major: 1, Minor: 2, cache: "b"}}
+
+	if hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected non-addressable values to hash by the pointer-receiver marshaler")
+	}
+
+	if hasher.MustHash(a) != hasher.MustHash(&a) {
+		t.Error("expected values and pointers to them to hash equally")
+	}
+
+	if hasher.MustHash(a.Version) != hasher.MustHash([]byte{1, 2}) {
+		t.Error("expected top-level values to hash by the pointer-receiver marshaler")
+	}
+
+	maps := hasher.MustHash(map[string]version{"v": a.Version})
+
+	if maps != hasher.MustHash(map[string]version{"v": b.Version}) {
+		t.Error("expected map values to hash by the pointer-receiver marshaler")
+	}
+
+	type hidden struct {
+		version version
+	}
+
+	if _, err := hasher.Hash(&hidden{version: a.Version}); err != nil {
+		t.Errorf("expected unexported fields to keep hashing by their fields, got %v", err)
+	}
+}
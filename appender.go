@@ -0,0 +1,34 @@
+package datahash
+
+import "encoding"
+
+// maxScratch is the capacity up to which a container keeps the buffer that appenders grew.
+const maxScratch = 64 << 10
+
+// marshalBinary returns the binary form of m, appended into the scratch buffer of c
+// if m implements encoding.BinaryAppender. The result is valid until the next call.
+func (c *container) marshalBinary(m encoding.BinaryMarshaler) ([]byte, error) {
+	if a, ok := m.(encoding.BinaryAppender); ok {
+		return c.keepScratch(a.AppendBinary(c.scratch[:0]))
+	}
+
+	return m.MarshalBinary()
+}
+
+// marshalText returns the text form of m, appended into the scratch buffer of c
+// if m implements encoding.TextAppender. The result is valid until the next call.
+func (c *container) marshalText(m encoding.TextMarshaler) ([]byte, error) {
+	if a, ok := m.(encoding.TextAppender); ok {
+		return c.keepScratch(a.AppendText(c.scratch[:0]))
+	}
+
+	return m.MarshalText()
+}
+
+func (c *container) keepScratch(b []byte, err error) ([]byte, error) {
+	if cap(b) <= maxScratch {
+		c.scratch = b[:0]
+	}
+
+	return b, err
+}
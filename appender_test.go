@@ -0,0 +1,60 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type textOnly int
+
+func (n textOnly) MarshalText() ([]byte, error) {
+	return []byte("n" + strconv.Itoa(int(n))), nil
+}
+
+type appender int
+
+func (n appender) MarshalText() ([]byte, error) {
+	return n.AppendText(nil)
+}
+
+func (n appender) AppendText(b []byte) ([]byte, error) {
+	return strconv.AppendInt(append(b, 'n'), int64(n), 10), nil
+}
+
+func TestHasher_Appender(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{Text: true})
+
+	if hasher.MustHash(appender(42)) != hasher.MustHash(textOnly(42)) {
+		t.Error("expected encoding.TextAppender to hash like encoding.TextMarshaler")
+	}
+
+	if hasher.MustHash([]appender{1, 2}) == hasher.MustHash([]appender{2, 1}) {
+		t.Error("expected appended texts not to overwrite each other")
+	}
+
+	f := big.NewFloat(1.5)
+
+	if hasher.MustHash(f) == hasher.MustHash(big.NewFloat(2.5)) {
+		t.Error("expected different big.Float values to hash differently")
+	}
+
+	canonical, err := hasher.Canonicalize(appender(7))
+	if err != nil || canonical != "n7" {
+		t.Errorf("expected appended text in canonical form, got %v, %v", canonical, err)
+	}
+
+	var tm any = time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	if allocs := testing.AllocsPerRun(100, func() { _, _ = hasher.Hash(tm) }); allocs != 0 {
+		t.Errorf("expected no allocations for time.Time, got %v", allocs)
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { _, _ = hasher.Hash(appender(42)) }); allocs != 0 {
+		t.Errorf("expected no allocations for encoding.TextAppender, got %v", allocs)
+	}
+}
@@ -0,0 +1,537 @@
+package datahash
+
+import (
+	"bytes"
+	"cmp"
+	"reflect"
+	"slices"
+)
+
+// Canonicalize returns a normalized deep copy of value built from plain Go values,
+// showing exactly the content that Hash consumes under the configured Options.
+//
+// Booleans, strings and complex numbers are kept, signed integers become int64, unsigned integers
+// uint64 and floats float64. In NormalizeNumbers mode, numbers become int64 or uint64 if they are
+// integral, float64 if they are real and complex128 otherwise, so numbers that hash equally are equal. Values hashed via TextMarshaler, JSONMarshaler, XMLMarshaler or
+// Stringer become the marshaled string and errors their Error string, unless Options.ErrorChain
+// is set; values hashed via HashWriter, HashWriterTo, BinaryMarshaler or built-in handling become
+// the written bytes. Structs become map[string]any without skipped fields, or []any of their
+// fields in Positional mode, maps with string keys map[string]any with normalized keys unless
+// keys collide after normalization, and all other maps []any of []any{key, value} pairs, or []any of their keys or values if only those are hashed. Slices,
+// arrays and iterators become []any; unordered sets and maps are sorted by the hash of their elements.
+// Nil pointers and interfaces, revisited pointers and skipped zero values become nil.
+func (h *Hasher) Canonicalize(value any) (any, error) {
+	if err := h.checkStream("Canonicalize"); err != nil {
+		return nil, err
+	}
+
+	v := reflect.ValueOf(value)
+
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	c := h.acquire()
+	defer h.containerPool.Put(c)
+
+	return h.canonical(v, h.cfg, c)
+}
+
+func (h *Hasher) canonical(v reflect.Value, cfg config, c *container) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	t := v.Type()
+
+	cfg = cfg.with(typeOptions(t))
+
+	hf, err := h.makeHashFunc(t, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.ignoreZero && isZero(v) {
+		return nil, nil
+	}
+
+	if h.opts.MaxDepth > 0 && isNesting(t.Kind()) {
+		if err := h.enter(c); err != nil {
+			return nil, err
+		}
+
+		defer func() { c.st.depth-- }()
+	}
+
+	if h.marshalsViaPointer(t, cfg) {
+		if p, ok := h.address(v); ok {
+			return h.canonical(p, cfg, c)
+		}
+	}
+
+	if info := h.typeInfo(t, cfg); info != nil && info.Strategy != StrategyKind {
+		var buf captureHash
+
+		tmp := h.sub(c)
+		defer h.containerPool.Put(tmp)
+
+		hash := tmp.hash
+		tmp.hash = &buf
+
+		err := hf(v, tmp)
+
+		tmp.hash = hash
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch info.Strategy {
+		case StrategyText, StrategyJSON, StrategyXML, StrategyStringer:
+			return buf.String(), nil
+		case StrategyError:
+			if !h.opts.ErrorChain {
+				return buf.String(), nil
+			}
+
+			return buf.Bytes(), nil
+		default:
+			return buf.Bytes(), nil
+		}
+	}
+
+	if h.opts.NormalizeNumbers {
+		if n, ok := canonicalNumber(v); ok {
+			return n, nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return h.canonical(v.Elem(), cfg, c)
+	case reflect.Pointer:
+		if v.IsNil() {
+			if cfg.zeroNil {
+				return h.canonical(reflect.Zero(t.Elem()), cfg, c)
+			}
+
+			return nil, nil
+		}
+
+		if h.tracks(t) {
+			if _, ok := h.revisited(v, c); ok {
+				return nil, nil
+			}
+		}
+
+		return h.canonical(v.Elem(), cfg, c)
+	case reflect.String:
+		return h.normalizeString(v.String(), cfg), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		if h.opts.NormalizeFloats {
+			return normalizeFloat(v.Float()), nil
+		}
+
+		return v.Float(), nil
+	case reflect.Complex64, reflect.Complex128:
+		if h.opts.NormalizeFloats {
+			return complex(normalizeFloat(real(v.Complex())), normalizeFloat(imag(v.Complex()))), nil
+		}
+
+		return v.Complex(), nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			if v.IsNil() {
+				return nil, nil
+			}
+
+			return bytes.Clone(v.Bytes()), nil
+		}
+
+		if h.rawArray(t, cfg) {
+			b := make([]byte, v.Len())
+
+			reflect.Copy(reflect.ValueOf(b), v)
+
+			return b, nil
+		}
+
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+
+		unordered := cfg.unorderedArray
+
+		if t.Kind() == reflect.Slice {
+			unordered = cfg.unorderedSlice
+		}
+
+		var list canonicalList
+
+		for i := range v.Len() {
+			if truncated, err := h.countElement(c); err != nil {
+				return nil, err
+			} else if truncated {
+				break
+			}
+
+			if err := list.add(h, v.Index(i), c, unordered); err != nil {
+				return nil, err
+			}
+		}
+
+		return list.result(unordered), nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		if cfg.mapKeys || cfg.mapValues {
+			var list canonicalList
+
+			iter := v.MapRange()
+
+			for iter.Next() {
+				if truncated, err := h.countElement(c); err != nil {
+					return nil, err
+				} else if truncated {
+					break
+				}
+
+				if cfg.ignoreZero && isZero(iter.Value()) {
+					continue
+				}
+
+				elem := iter.Value()
+
+				if cfg.mapKeys {
+					elem = iter.Key()
+				}
+
+				if err := list.add(h, elem, c, true); err != nil {
+					return nil, err
+				}
+			}
+
+			return list.result(true), nil
+		}
+
+		if key := h.typeInfo(t.Key(), h.cfg); t.Key().Kind() == reflect.String && key != nil && key.Strategy == StrategyKind && !h.keysCollide(v) {
+			result := make(map[string]any, v.Len())
+
+			iter := v.MapRange()
+
+			for iter.Next() {
+				if truncated, err := h.countElement(c); err != nil {
+					return nil, err
+				} else if truncated {
+					break
+				}
+
+				if cfg.ignoreZero && isZero(iter.Value()) {
+					continue
+				}
+
+				elem, err := h.canonical(iter.Value(), h.cfg, c)
+				if err != nil {
+					return nil, err
+				}
+
+				result[h.normalizeString(iter.Key().String(), h.cfg)] = elem
+			}
+
+			return result, nil
+		}
+
+		var list canonicalList
+
+		iter := v.MapRange()
+
+		for iter.Next() {
+			if truncated, err := h.countElement(c); err != nil {
+				return nil, err
+			} else if truncated {
+				break
+			}
+
+			if cfg.ignoreZero && isZero(iter.Value()) {
+				continue
+			}
+
+			if err := list.addPair(h, iter.Key(), iter.Value(), c, true); err != nil {
+				return nil, err
+			}
+		}
+
+		return list.result(true), nil
+	case reflect.Struct:
+		sfs := h.structFields(t, cfg)
+
+		if h.opts.Positional && !cfg.unorderedStruct {
+			return h.canonicalPositional(v, sfs, cfg, c)
+		}
+
+		result := make(map[string]any, len(sfs))
+
+		for i := range sfs {
+			sf := &sfs[i]
+			fv := sf.value(v)
+
+			if sf.skips(fv, cfg) {
+				continue
+			}
+
+			elem, err := h.canonical(fv, sf.cfg, c)
+			if err != nil {
+				return nil, err
+			}
+
+			result[string(sf.name)] = elem
+		}
+
+		return result, nil
+	}
+
+	if h.kindMode(t) == ModeIdentity {
+		return uint64(v.Pointer()), nil
+	}
+
+	switch {
+	case t.CanSeq2():
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		var list canonicalList
+
+		for k, e := range v.Seq2() {
+			if err := h.countSeqElement(c); err != nil {
+				return nil, err
+			}
+
+			if truncated, err := h.countElement(c); err != nil {
+				return nil, err
+			} else if truncated {
+				break
+			}
+
+			if !k.IsValid() || !e.IsValid() || cfg.ignoreZero && isZero(e) {
+				continue
+			}
+
+			if err := list.addPair(h, k, e, c, cfg.unorderedSeq2); err != nil {
+				return nil, err
+			}
+		}
+
+		return list.result(cfg.unorderedSeq2), nil
+	case t.CanSeq():
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		var list canonicalList
+
+		for e := range v.Seq() {
+			if err := h.countSeqElement(c); err != nil {
+				return nil, err
+			}
+
+			if truncated, err := h.countElement(c); err != nil {
+				return nil, err
+			} else if truncated {
+				break
+			}
+
+			if !e.IsValid() || cfg.ignoreZero && isZero(e) {
+				continue
+			}
+
+			if err := list.add(h, e, c, cfg.unorderedSeq); err != nil {
+				return nil, err
+			}
+		}
+
+		return list.result(cfg.unorderedSeq), nil
+	}
+
+	return nil, nil
+}
+
+// keysCollide reports whether distinct string keys of the map v are equal after the string
+// normalizations, e.g. "A" and "a" with FoldCase, so that they cannot be keys of a map[string]any.
+func (h *Hasher) keysCollide(v reflect.Value) bool {
+	if !h.opts.NormalizeUnicode && !h.cfg.foldCase && h.opts.StringTransform == nil {
+		return false
+	}
+
+	seen := make(map[string]struct{}, v.Len())
+
+	for _, key := range v.MapKeys() {
+		k := h.normalizeString(key.String(), h.cfg)
+
+		if _, ok := seen[k]; ok {
+			return true
+		}
+
+		seen[k] = struct{}{}
+	}
+
+	return false
+}
+
+// canonicalPositional returns the fields of the struct v in Positional mode as a []any,
+// in which skipped fields are nil and trailing skipped fields are dropped.
+func (h *Hasher) canonicalPositional(v reflect.Value, sfs []structField, cfg config, c *container) (any, error) {
+	result := make([]any, 0, len(sfs))
+	n := 0
+
+	for i := range sfs {
+		sf := &sfs[i]
+		fv := sf.value(v)
+
+		if sf.skips(fv, cfg) {
+			result = append(result, nil)
+
+			continue
+		}
+
+		elem, err := h.canonical(fv, sf.cfg, c)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, elem)
+		n = len(result)
+	}
+
+	return result[:n], nil
+}
+
+// canonicalList collects canonical elements, together with their hashes if they form an unordered set.
+type canonicalList struct {
+	elems []any
+	sums  []uint64
+}
+
+func (l *canonicalList) add(h *Hasher, v reflect.Value, c *container, unordered bool) error {
+	elem, err := h.canonical(v, h.cfg, c)
+	if err != nil {
+		return err
+	}
+
+	l.elems = append(l.elems, elem)
+
+	if unordered {
+		sum, err := h.subHash(c, func(tmp *container) error {
+			hf, err := h.makeHashFunc(v.Type(), h.cfg)
+			if err != nil {
+				return err
+			}
+
+			return hf(v, tmp)
+		})
+		if err != nil {
+			return err
+		}
+
+		l.sums = append(l.sums, sum)
+	}
+
+	return nil
+}
+
+func (l *canonicalList) addPair(h *Hasher, k, v reflect.Value, c *container, unordered bool) error {
+	key, err := h.canonical(k, h.cfg, c)
+	if err != nil {
+		return err
+	}
+
+	elem, err := h.canonical(v, h.cfg, c)
+	if err != nil {
+		return err
+	}
+
+	l.elems = append(l.elems, []any{key, elem})
+
+	if unordered {
+		sum, err := h.subHash(c, func(tmp *container) error {
+			khf, err := h.makeHashFunc(k.Type(), h.cfg)
+			if err != nil {
+				return err
+			}
+
+			vhf, err := h.makeHashFunc(v.Type(), h.cfg)
+			if err != nil {
+				return err
+			}
+
+			return threeErr(
+				khf(k, tmp),
+				tmp.write(colon[:]),
+				vhf(v, tmp),
+			)
+		})
+		if err != nil {
+			return err
+		}
+
+		l.sums = append(l.sums, sum)
+	}
+
+	return nil
+}
+
+func (l *canonicalList) result(unordered bool) []any {
+	if !unordered {
+		return l.elems
+	}
+
+	idx := make([]int, len(l.elems))
+
+	for i := range idx {
+		idx[i] = i
+	}
+
+	slices.SortStableFunc(idx, func(a, b int) int {
+		return cmp.Compare(l.sums[a], l.sums[b])
+	})
+
+	result := make([]any, len(idx))
+
+	for i, j := range idx {
+		result[i] = l.elems[j]
+	}
+
+	return result
+}
+
+// subHash returns the hash that write produces in a sub container of c.
+func (h *Hasher) subHash(c *container, write func(tmp *container) error) (uint64, error) {
+	tmp := h.sub(c)
+	defer h.containerPool.Put(tmp)
+
+	tmp.Reset()
+
+	if err := write(tmp); err != nil {
+		return 0, err
+	}
+
+	return tmp.hash.Sum64(), nil
+}
+
+// captureHash is a hash.Hash64 that records the written bytes instead of hashing them.
+type captureHash struct {
+	bytes.Buffer
+}
+
+func (b *captureHash) Sum(in []byte) []byte { return append(in, b.Bytes()...) }
+func (b *captureHash) Sum64() uint64        { return 0 }
+func (b *captureHash) Size() int            { return 8 }
+func (b *captureHash) BlockSize() int       { return 1 }
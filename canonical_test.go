@@ -0,0 +1,149 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"math/big"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_Canonicalize(t *testing.T) {
+	type item struct {
+		Name   string
+		Secret string `datahash:"-"`
+		Tags   []string
+		Price  *big.Float
+		Count  uint8
+		Extra  map[int]bool
+		Parent *item
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{Text: true, UnorderedSlice: true, IgnoreZero: true})
+
+	value := item{
+		Name:   "x",
+		Secret: "hidden",
+		Tags:   []string{"b", "a", "c"},
+		Price:  big.NewFloat(1.5),
+		Count:  3,
+		Extra:  map[int]bool{1: true},
+	}
+
+	got, err := hasher.Canonicalize(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m, ok := got.(map[string]any)
+	if !ok {
+		t.Fatalf("unexpected type %T", got)
+	}
+
+	if _, ok := m["Secret"]; ok {
+		t.Error("skipped field included")
+	}
+
+	if _, ok := m["Parent"]; ok {
+		t.Error("zero field included")
+	}
+
+	if m["Price"] != "1.5" || m["Count"] != uint64(3) || m["Name"] != "x" {
+		t.Errorf("unexpected fields: %v", m)
+	}
+
+	if !reflect.DeepEqual(m["Extra"], []any{[]any{int64(1), true}}) {
+		t.Errorf("unexpected map: %#v", m["Extra"])
+	}
+
+	other, _ := hasher.Canonicalize(item{Name: "x", Tags: []string{"c", "a", "b"}, Price: big.NewFloat(1.5), Count: 3, Extra: map[int]bool{1: true}})
+
+	if !reflect.DeepEqual(got, other) {
+		t.Errorf("canonical forms of equal sets differ:\n  got:  %v\n  want: %v", other, got)
+	}
+
+	tags, _ := m["Tags"].([]any)
+
+	if len(tags) != 3 || slices.Contains(tags, nil) {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+}
+
+func TestHasher_CanonicalizeCycle(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+
+	n := &node{Name: "a"}
+	n.Next = n
+
+	got, err := datahash.New(fnv.New64a, datahash.Options{}).Canonicalize(n)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]any{"Name": "a", "Next": nil}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("canonical mismatch:\n  got:  %v\n  want: %v", got, want)
+	}
+}
+
+func TestHasher_CanonicalizeNumbers(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{NormalizeNumbers: true})
+
+	for _, c := range []struct {
+		values []any
+		want   any
+	}{
+		{[]any{int8(5), uint(5), 5.0, float32(5), complex(5, 0)}, int64(5)},
+		{[]any{1.5, float32(1.5), complex64(1.5)}, 1.5},
+		{[]any{uint64(1) << 63, float64(1 << 63)}, uint64(1) << 63},
+		{[]any{complex(1, 2)}, complex(1, 2)},
+	} {
+		for _, value := range c.values {
+			got, err := hasher.Canonicalize(value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != c.want {
+				t.Errorf("canonical form of %T(%v): got %T(%v), want %T(%v)", value, value, got, got, c.want, c.want)
+			}
+
+			if hasher.MustHash(value) != hasher.MustHash(c.values[0]) {
+				t.Errorf("expected %T(%v) to hash like %T(%v)", value, value, c.values[0], c.values[0])
+			}
+		}
+	}
+}
+
+func TestHasher_CanonicalizeFoldedKeys(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{FoldCase: true})
+
+	got, err := hasher.Canonicalize(map[string]int{"A": 1, "b": 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := map[string]any{"a": int64(1), "b": int64(2)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("canonical mismatch:\n  got:  %v\n  want: %v", got, want)
+	}
+
+	if hasher.MustHash(map[string]int{"A": 1, "b": 2}) != hasher.MustHash(map[string]int{"a": 1, "B": 2}) {
+		t.Error("expected folded keys to hash equally")
+	}
+
+	// Keys that collide after folding are kept as pairs, since both entries are hashed.
+	got, err = hasher.Canonicalize(map[string]int{"A": 1, "a": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := []any{[]any{"a", int64(1)}, []any{"a", int64(1)}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("canonical mismatch:\n  got:  %v\n  want: %v", got, want)
+	}
+}
@@ -0,0 +1,319 @@
+package datahash
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CBOR major types.
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+)
+
+// appendCBOR appends the RFC 8949 core deterministic encoding of value.
+func appendCBOR(dst []byte, value any) ([]byte, error) {
+	e := cborEncoder{visiting: map[cborRef]struct{}{}}
+
+	return e.append(dst, reflect.ValueOf(value))
+}
+
+// cborEncoder encodes values in the deterministic CBOR of FormatCBOR, see there.
+type cborEncoder struct {
+	visiting map[cborRef]struct{} // Pointers, maps and slices being encoded, to reject cycles.
+}
+
+// cborRef identifies a pointer, map or slice by its type and address, and slices also by their length,
+// so that distinct subslices of the same array are not mistaken for cycles.
+type cborRef struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// enter marks the reference value v as being encoded until the returned function is called,
+// or fails if v is already being encoded.
+func (e *cborEncoder) enter(v reflect.Value) (func(), error) {
+	ref := cborRef{typ: v.Type(), ptr: v.Pointer()}
+
+	if v.Kind() == reflect.Slice {
+		ref.len = v.Len()
+	}
+
+	if _, ok := e.visiting[ref]; ok {
+		return nil, fmt.Errorf("datahash: cyclic value of type %s cannot be encoded as CBOR", ref.typ)
+	}
+
+	e.visiting[ref] = struct{}{}
+
+	return func() { delete(e.visiting, ref) }, nil
+}
+
+func (e *cborEncoder) append(dst []byte, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return append(dst, 0xf6), nil
+	}
+
+	t := v.Type()
+
+	if t == timeType {
+		tm := v.Interface().(time.Time)
+
+		dst = appendCBORHead(dst, cborTag, 0)
+
+		return appendCBORString(dst, cborText, tm.UTC().Format(time.RFC3339Nano)), nil
+	}
+
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && t.Implements(textMarshalerType) && v.CanInterface() {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, marshalerErr(t, StrategyText, err)
+		}
+
+		return appendCBORString(dst, cborText, string(b)), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return append(dst, 0xf5), nil
+		}
+
+		return append(dst, 0xf4), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if i := v.Int(); i < 0 {
+			return appendCBORHead(dst, cborNegint, uint64(^i)), nil //nolint:gosec
+		}
+
+		return appendCBORHead(dst, cborUint, uint64(v.Int())), nil //nolint:gosec
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return appendCBORHead(dst, cborUint, v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return appendCBORFloat(dst, v.Float()), nil
+	case reflect.String:
+		return appendCBORString(dst, cborText, v.String()), nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return append(dst, 0xf6), nil
+		}
+
+		return e.append(dst, v.Elem())
+	case reflect.Pointer:
+		if v.IsNil() {
+			return append(dst, 0xf6), nil
+		}
+
+		leave, err := e.enter(v)
+		if err != nil {
+			return nil, err
+		}
+
+		defer leave()
+
+		return e.append(dst, v.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			return append(dst, 0xf6), nil
+		}
+
+		if t.Elem().Kind() == reflect.Uint8 {
+			dst = appendCBORHead(dst, cborBytes, uint64(v.Len()))
+
+			for i := range v.Len() {
+				dst = append(dst, byte(v.Index(i).Uint()))
+			}
+
+			return dst, nil
+		}
+
+		if t.Kind() == reflect.Slice {
+			leave, err := e.enter(v)
+			if err != nil {
+				return nil, err
+			}
+
+			defer leave()
+		}
+
+		dst = appendCBORHead(dst, cborArray, uint64(v.Len()))
+
+		for i := range v.Len() {
+			var err error
+
+			if dst, err = e.append(dst, v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+
+		return dst, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return append(dst, 0xf6), nil
+		}
+
+		leave, err := e.enter(v)
+		if err != nil {
+			return nil, err
+		}
+
+		defer leave()
+
+		entries := make([][2][]byte, 0, v.Len())
+
+		for iter := v.MapRange(); iter.Next(); {
+			key, err := e.append(nil, iter.Key())
+			if err != nil {
+				return nil, err
+			}
+
+			elem, err := e.append(nil, iter.Value())
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, [2][]byte{key, elem})
+		}
+
+		return appendCBORMap(dst, entries), nil
+	case reflect.Struct:
+		entries := make([][2][]byte, 0, t.NumField())
+
+		for i := range t.NumField() {
+			name, ok := cborFieldName(t.Field(i))
+			if !ok {
+				continue
+			}
+
+			elem, err := e.append(nil, v.Field(i))
+			if err != nil {
+				return nil, fmt.Errorf("%w on field %s of %s", err, t.Field(i).Name, t)
+			}
+
+			entries = append(entries, [2][]byte{appendCBORString(nil, cborText, name), elem})
+		}
+
+		return appendCBORMap(dst, entries), nil
+	}
+
+	return nil, fmt.Errorf("datahash: type %s cannot be encoded as CBOR", t)
+}
+
+// cborFieldName returns the map key of a struct field from its cbor or json tag, or false if it is skipped.
+func cborFieldName(sf reflect.StructField) (string, bool) {
+	if !sf.IsExported() {
+		return "", false
+	}
+
+	for _, key := range []string{"cbor", "json"} {
+		if tag, ok := sf.Tag.Lookup(key); ok {
+			name, _, _ := strings.Cut(tag, ",")
+
+			switch name {
+			case "-":
+				return "", false
+			case "":
+				continue
+			}
+
+			return name, true
+		}
+	}
+
+	return sf.Name, true
+}
+
+// appendCBORMap appends a map of encoded entries, sorted by the bytewise order of their encoded keys.
+func appendCBORMap(dst []byte, entries [][2][]byte) []byte {
+	slices.SortFunc(entries, func(a, b [2][]byte) int { return bytes.Compare(a[0], b[0]) })
+
+	dst = appendCBORHead(dst, cborMap, uint64(len(entries)))
+
+	for _, entry := range entries {
+		dst = append(append(dst, entry[0]...), entry[1]...)
+	}
+
+	return dst
+}
+
+func appendCBORString(dst []byte, major byte, s string) []byte {
+	return append(appendCBORHead(dst, major, uint64(len(s))), s...)
+}
+
+// appendCBORHead appends the initial byte of a data item with the shortest encoding of its argument n.
+func appendCBORHead(dst []byte, major byte, n uint64) []byte {
+	major <<= 5
+
+	switch {
+	case n < 24:
+		return append(dst, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(dst, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return append(dst, major|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		return append(dst, major|26, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+
+	return append(dst, major|27, byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32),
+		byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+// appendCBORFloat appends f in the shortest of half, single and double precision that preserves
+// its value. All NaNs are encoded as the canonical half precision quiet NaN.
+func appendCBORFloat(dst []byte, f float64) []byte {
+	if math.IsNaN(f) {
+		return append(dst, 0xf9, 0x7e, 0x00)
+	}
+
+	if f32 := float32(f); float64(f32) == f {
+		if half, ok := halfFloat(f32); ok {
+			return append(dst, 0xf9, byte(half>>8), byte(half))
+		}
+
+		bits := math.Float32bits(f32)
+
+		return append(dst, 0xfa, byte(bits>>24), byte(bits>>16), byte(bits>>8), byte(bits))
+	}
+
+	bits := math.Float64bits(f)
+
+	return append(dst, 0xfb, byte(bits>>56), byte(bits>>48), byte(bits>>40), byte(bits>>32),
+		byte(bits>>24), byte(bits>>16), byte(bits>>8), byte(bits))
+}
+
+// halfFloat returns the IEEE 754 half precision bits of f, or false if f is not exactly representable.
+func halfFloat(f float32) (uint16, bool) {
+	var (
+		bits     = math.Float32bits(f)
+		sign     = uint16(bits>>16) & 0x8000
+		exp      = int(bits>>23&0xff) - 127
+		mantissa = bits & 0x7fffff
+	)
+
+	switch {
+	case bits&0x7fffffff == 0:
+		return sign, true
+	case exp == 128: // Infinity, NaNs are handled by the caller.
+		return sign | 0x7c00, mantissa == 0
+	case exp >= -14 && exp <= 15:
+		return sign | uint16(exp+15)<<10 | uint16(mantissa>>13), mantissa&0x1fff == 0
+	case exp >= -24 && exp < -14:
+		full := mantissa | 1<<23
+		shift := 13 + (-14 - exp)
+
+		return sign | uint16(full>>shift), full&(1<<shift-1) == 0
+	}
+
+	return 0, false
+}
@@ -0,0 +1,122 @@
+package datahash_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestFormat_CBOR(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatCBOR})
+
+	type point struct {
+		X      int    `cbor:"x"`
+		Y      int    `json:"y,omitempty"`
+		Label  string `json:"-"`
+		Weight float64
+		hidden bool
+	}
+
+	// Vectors from RFC 8949, Appendix A, and of its deterministic encoding rules.
+	for _, c := range []struct {
+		value any
+		want  string
+	}{
+		{0, "00"},
+		{23, "17"},
+		{24, "1818"},
+		{uint16(1000), "1903e8"},
+		{1000000, "1a000f4240"},
+		{int64(1000000000000), "1b000000e8d4a51000"},
+		{uint64(math.MaxUint64), "1bffffffffffffffff"},
+		{-1, "20"},
+		{-1000, "3903e7"},
+		{0.0, "f90000"},
+		{math.Copysign(0, -1), "f98000"},
+		{1.5, "f93e00"},
+		{65504.0, "f97bff"},
+		{100000.0, "fa47c35000"},
+		{3.4028234663852886e+38, "fa7f7fffff"},
+		{1.0e+300, "fb7e37e43c8800759c"},
+		{5.960464477539063e-8, "f90001"},
+		{0.00006103515625, "f90400"},
+		{-4.1, "fbc010666666666666"},
+		{float32(math.Inf(1)), "f97c00"},
+		{math.NaN(), "f97e00"},
+		{math.Inf(-1), "f9fc00"},
+		{false, "f4"},
+		{nil, "f6"},
+		{"", "60"},
+		{"IETF", "6449455446"},
+		{"ü", "62c3bc"},
+		{[]byte{1, 2, 3, 4}, "4401020304"},
+		{[]int{}, "80"},
+		{[3]int{1, 2, 3}, "83010203"},
+		{map[int]int{3: 4, 1: 2}, "a201020304"},
+		{map[string]any{"b": []int{2, 3}, "a": 1}, "a26161016162820203"},
+		{map[any]int{"aa": 1, 10: 2, -1: 3, "b": 4}, "a40a02200361620462616101"},
+		{point{X: 1, Y: -2, Label: "skipped", Weight: 0.5}, "a361780161792166576569676874f93800"},
+		{time.Date(2013, 3, 21, 21, 4, 0, 0, time.FixedZone("", 3600)), "c074323031332d30332d32315432303a30343a30305a"},
+		{(*int)(nil), "f6"},
+	} {
+		var buf bytes.Buffer
+
+		if err := hasher.Encode(c.value, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := hex.EncodeToString(buf.Bytes()); got != c.want {
+			t.Errorf("%v: expected %s, got %s", c.value, c.want, got)
+		}
+
+		b, _ := hex.DecodeString(c.want)
+
+		f := fnv.New64a()
+		_, _ = f.Write(b)
+
+		if hasher.MustHash(c.value) != f.Sum64() {
+			t.Errorf("%v: expected the hash of the encoding", c.value)
+		}
+	}
+
+	type node struct {
+		Next *node
+	}
+
+	cyclic := &node{}
+	cyclic.Next = cyclic
+
+	if _, err := hasher.Hash(cyclic); err == nil {
+		t.Error("expected an error for cyclic values")
+	}
+
+	cyclicMap := map[string]any{}
+	cyclicMap["self"] = cyclicMap
+
+	cyclicSlice := []any{nil}
+	cyclicSlice[0] = cyclicSlice
+
+	for _, value := range []any{cyclicMap, cyclicSlice} {
+		if _, err := hasher.Hash(value); err == nil || !strings.Contains(err.Error(), "cyclic value") {
+			t.Errorf("%T: expected a cycle error, got %v", value, err)
+		}
+	}
+
+	// Shared maps and subslices of the same array are not cycles.
+	shared := map[string]int{"a": 1}
+	array := []any{"a", "b"}
+
+	if _, err := hasher.Hash([]any{shared, shared, array[:1], array}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := hasher.Hash(complex(1, 2)); err == nil {
+		t.Error("expected an error for complex numbers")
+	}
+}
@@ -0,0 +1,149 @@
+package datahash
+
+import (
+	"errors"
+	"reflect"
+)
+
+// hashChunks writes data as a leaf if it is not longer than ChunkSize, and as a node of its
+// total length and the digests of its ChunkSize-byte blocks otherwise.
+func (h *Hasher) hashChunks(data []byte, c *container) error {
+	if len(data) <= h.opts.ChunkSize {
+		return twoErr(c.write(byteLeaf[:]), c.write(data))
+	}
+
+	if err := twoErr(c.write(byteNode[:]), c.writeUint64(uint64(len(data)))); err != nil {
+		return err
+	}
+
+	for chunk := range chunks(data, h.opts.ChunkSize) {
+		sum, err := h.chunkSum(chunk, c)
+		if err != nil {
+			return err
+		}
+
+		if err := c.writeUint64(sum); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chunkSum returns the digest of a block, tagged as a leaf.
+func (h *Hasher) chunkSum(chunk []byte, c *container) (uint64, error) {
+	return h.subHash(c, func(tmp *container) error { return twoErr(tmp.write(byteLeaf[:]), tmp.write(chunk)) })
+}
+
+// chunks yields the consecutive blocks of data of the given size; the last one may be shorter.
+func chunks(data []byte, size int) func(yield func([]byte) bool) {
+	return func(yield func([]byte) bool) {
+		for len(data) > 0 {
+			n := min(size, len(data))
+
+			if !yield(data[:n:n]) {
+				return
+			}
+
+			data = data[n:]
+		}
+	}
+}
+
+// Chunks holds the block digests of a byte slice hashed with Options.ChunkSize, so the hash of
+// large data can be kept up to date by re-hashing only the blocks of a changed region.
+type Chunks struct {
+	h       *Hasher
+	sums    []uint64
+	length  int
+	chunked bool
+	small   []byte // Copy of data not longer than ChunkSize, which is hashed as raw bytes.
+}
+
+// Chunks returns the block digests of data. Sum64 of the result equals the hash of data.
+// It fails unless Options.ChunkSize is positive.
+func (h *Hasher) Chunks(data []byte) (*Chunks, error) {
+	if h.opts.ChunkSize <= 0 || h.opts.Format >= formatCompat {
+		return nil, errors.New("datahash: Chunks requires a positive Options.ChunkSize")
+	}
+
+	ch := &Chunks{h: h}
+
+	return ch, ch.Update(data, 0, len(data))
+}
+
+// Update re-hashes the blocks of data overlapping the changed region [off, off+n), where data is
+// the complete new content. If the length of the data changed, the blocks from the shorter
+// length onwards are re-hashed as well, so data may grow or shrink.
+func (ch *Chunks) Update(data []byte, off, n int) error {
+	size := ch.h.opts.ChunkSize
+
+	if len(data) <= size {
+		ch.chunked, ch.sums, ch.length, ch.small = false, ch.sums[:0], len(data), nil
+
+		if data != nil {
+			ch.small = append(make([]byte, 0, len(data)), data...)
+		}
+
+		return nil
+	}
+
+	if !ch.chunked {
+		ch.chunked, ch.sums, ch.length, ch.small = true, ch.sums[:0], 0, nil
+	}
+
+	var (
+		count = (len(data) + size - 1) / size
+		from  = max(off, 0) / size
+		to    = min((max(off+n, 0)+size-1)/size, count)
+	)
+
+	if len(data) != ch.length {
+		from, to = min(from, min(ch.length, len(data))/size), count
+	}
+
+	ch.length = len(data)
+	ch.sums = append(ch.sums[:min(len(ch.sums), count)], make([]uint64, max(count-len(ch.sums), 0))...)
+
+	c := ch.h.acquire()
+	defer ch.h.containerPool.Put(c)
+
+	for i := from; i < to; i++ {
+		sum, err := ch.h.chunkSum(data[i*size:min((i+1)*size, len(data))], c)
+		if err != nil {
+			return err
+		}
+
+		ch.sums[i] = sum
+	}
+
+	return nil
+}
+
+// Sum64 returns the hash of the data, as Hasher.Hash returns it.
+func (ch *Chunks) Sum64() (uint64, error) {
+	if !ch.chunked {
+		return ch.h.Hash(ch.small)
+	}
+
+	c := ch.h.acquire()
+	defer ch.h.containerPool.Put(c)
+
+	if err := ch.h.writeType(bytesType, c); err != nil {
+		return 0, err
+	}
+
+	if err := twoErr(c.write(byteNode[:]), c.writeUint64(uint64(ch.length))); err != nil {
+		return 0, err
+	}
+
+	for _, sum := range ch.sums {
+		if err := c.writeUint64(sum); err != nil {
+			return 0, err
+		}
+	}
+
+	return c.hash.Sum64(), nil
+}
+
+var bytesType = reflect.TypeFor[[]byte]()
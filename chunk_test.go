@@ -0,0 +1,129 @@
+package datahash_test
+
+import (
+	"bytes"
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_ChunkSize(t *testing.T) {
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	chunked := datahash.New(fnv.New64a, datahash.Options{ChunkSize: 4})
+
+	small := []byte("abcd")
+
+	if plain.MustHash(small) == chunked.MustHash(small) || chunked.MustHash(small) == chunked.MustHash(small[:3]) {
+		t.Error("expected byte slices up to ChunkSize to hash as tagged leaves")
+	}
+
+	data := []byte("abcdefghij")
+
+	if plain.MustHash(data) == chunked.MustHash(data) {
+		t.Error("expected larger byte slices to hash by their blocks")
+	}
+
+	changed := bytes.Clone(data)
+	changed[9] = 'x'
+
+	if chunked.MustHash(data) == chunked.MustHash(changed) {
+		t.Error("expected a changed block to change the hash")
+	}
+
+	if _, err := plain.Chunks(data); err == nil {
+		t.Error("expected Chunks to fail without ChunkSize")
+	}
+}
+
+func TestHasher_ChunkSizeCollision(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{ChunkSize: 64})
+
+	large := bytes.Repeat([]byte("datahash"), 13)
+
+	var stream bytes.Buffer
+
+	if err := hasher.Encode(large, &stream); err != nil {
+		t.Fatal(err)
+	}
+
+	if stream.Len() > 64 {
+		t.Fatalf("expected a block list shorter than ChunkSize, got %d bytes", stream.Len())
+	}
+
+	if hasher.MustHash(stream.Bytes()) == hasher.MustHash(large) {
+		t.Error("expected a byte slice not to hash like the block list of another")
+	}
+}
+
+func TestChunks_Update(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{ChunkSize: 4, Header: true})
+
+	data := []byte("abcdefghij")
+
+	chunks, err := hasher.Chunks(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(data []byte) {
+		t.Helper()
+
+		got, err := chunks.Sum64()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if want := hasher.MustHash(data); got != want {
+			t.Errorf("%q: Sum64 %d != Hash %d", data, got, want)
+		}
+	}
+
+	check(data)
+
+	data[5] = 'X'
+	if err := chunks.Update(data, 5, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	check(data)
+
+	for _, next := range []struct {
+		data   []byte
+		off, n int
+	}{
+		{append(data, "klmno"...), 10, 5},
+		{data[:6], 6, 0},
+		{[]byte("ab"), 0, 2},
+		{[]byte("abcdefghijklmnopq"), 2, 15},
+		{[]byte{}, 0, 0},
+	} {
+		if err := chunks.Update(next.data, next.off, next.n); err != nil {
+			t.Fatal(err)
+		}
+
+		check(next.data)
+	}
+}
+
+func BenchmarkHasher_ChunkUpdate(b *testing.B) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{ChunkSize: 64 << 10})
+	data := make([]byte, 16<<20)
+
+	chunks, err := hasher.Chunks(data)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for b.Loop() {
+		data[1<<20]++
+
+		if err := chunks.Update(data, 1<<20, 1); err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := chunks.Sum64(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,489 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Generate returns the source of the WriteHash methods for the named types of the package in dir.
+// The file named skip, usually the previous output, is ignored when loading the package.
+func Generate(dir string, typeNames []string, skip string) ([]byte, error) {
+	pkg, err := load(dir, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	g := &generator{pkg: pkg, types: typeNames}
+
+	for _, name := range typeNames {
+		if err := g.generate(name); err != nil {
+			return nil, err
+		}
+	}
+
+	var src bytes.Buffer
+
+	src.WriteString("// Code generated by datahashgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\n", pkg.Name())
+	src.WriteString("import (\n")
+
+	for _, imp := range slices.Sorted(slices.Values(g.imports())) {
+		fmt.Fprintf(&src, "\t%q\n", imp)
+	}
+
+	src.WriteString(")\n")
+	src.Write(g.buf.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("datahashgen: formatting generated code: %w", err)
+	}
+
+	return formatted, nil
+}
+
+func load(dir, skip string) (*types.Package, error) {
+	fset := token.NewFileSet()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*ast.File
+
+	for _, e := range entries {
+		name := e.Name()
+
+		if e.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || name == skip {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, f)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("datahashgen: no Go files in %s", dir)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+
+	return conf.Check(files[0].Name.Name, fset, files, nil)
+}
+
+type generator struct {
+	pkg    *types.Package
+	types  []string
+	buf    bytes.Buffer
+	body   bytes.Buffer
+	chunks []string
+	vars   int
+	binary bool
+	io     bool
+	math   bool
+}
+
+func (g *generator) imports() []string {
+	imports := []string{"hash"}
+
+	if g.binary {
+		imports = append(imports, "encoding/binary")
+	}
+
+	if g.io {
+		imports = append(imports, "io")
+	}
+
+	if g.math {
+		imports = append(imports, "math")
+	}
+
+	return imports
+}
+
+func (g *generator) generate(name string) error {
+	obj := g.pkg.Scope().Lookup(name)
+	if obj == nil {
+		return fmt.Errorf("datahashgen: type %s not found", name)
+	}
+
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return fmt.Errorf("datahashgen: %s is not a named type", name)
+	}
+
+	if _, ok := named.Underlying().(*types.Struct); !ok {
+		return fmt.Errorf("datahashgen: %s is not a struct type", name)
+	}
+
+	g.body.Reset()
+	g.chunks = g.chunks[:0]
+	g.vars = 0
+
+	if err := g.structValue("v", named.Underlying().(*types.Struct), name); err != nil {
+		return err
+	}
+
+	chunks := lowerFirst(name) + "HashChunks"
+
+	fmt.Fprintf(&g.buf, "\nvar %s = [...][]byte{\n", chunks)
+
+	for _, c := range g.chunks {
+		fmt.Fprintf(&g.buf, "\t[]byte(%q),\n", c)
+	}
+
+	g.buf.WriteString("}\n\n")
+	fmt.Fprintf(&g.buf, "// WriteHash writes the canonical datahash stream of v, implementing datahash.HashWriter.\n")
+	fmt.Fprintf(&g.buf, "func (v %s) WriteHash(w hash.Hash64) error {\n", name)
+
+	if bytes.Contains(g.body.Bytes(), []byte("buf[:]")) {
+		g.binary = true
+
+		g.buf.WriteString("\tvar buf [8]byte\n\n")
+	}
+
+	g.buf.Write(bytes.ReplaceAll(g.body.Bytes(), []byte("CHUNKS"), []byte(chunks)))
+	g.buf.WriteString("\n\treturn nil\n}\n")
+
+	return nil
+}
+
+// chunk returns the index expression of the constant bytes s.
+func (g *generator) chunk(s string) string {
+	i := slices.Index(g.chunks, s)
+
+	if i < 0 {
+		i = len(g.chunks)
+		g.chunks = append(g.chunks, s)
+	}
+
+	return "CHUNKS[" + strconv.Itoa(i) + "]"
+}
+
+func (g *generator) write(s string) {
+	fmt.Fprintf(&g.body, "_, _ = w.Write(%s)\n", g.chunk(s))
+}
+
+func (g *generator) newVar(prefix string) string {
+	g.vars++
+
+	return prefix + strconv.Itoa(g.vars)
+}
+
+const (
+	colon     = "\x02"
+	comma     = "\x03"
+	startList = "\x06"
+	endList   = "\x07"
+)
+
+func (g *generator) value(expr string, t types.Type, path string) error {
+	if named, ok := t.(*types.Named); ok && named.Obj().Pkg() == g.pkg && slices.Contains(g.types, named.Obj().Name()) {
+		fmt.Fprintf(&g.body, "if err := %s.WriteHash(w); err != nil {\nreturn err\n}\n", expr)
+
+		return nil
+	}
+
+	if hasMethod(t, "WriteHashTo") {
+		fmt.Fprintf(&g.body, "if err := %s.WriteHashTo(w); err != nil {\nreturn err\n}\n", expr)
+
+		return nil
+	}
+
+	if hasMethod(t, "WriteHash") {
+		fmt.Fprintf(&g.body, "if err := %s.WriteHash(w); err != nil {\nreturn err\n}\n", expr)
+
+		return nil
+	}
+
+	if hasMethod(t, "MarshalBinary") {
+		b := g.newVar("b")
+
+		fmt.Fprintf(&g.body, "%s, err := %s.MarshalBinary()\nif err != nil {\nreturn err\n}\n\n_, _ = w.Write(%s)\n", b, expr, b)
+
+		return nil
+	}
+
+	if hasMethod(t, "EncodeHash") || hasMethod(t, "DatahashOptions") {
+		return fmt.Errorf("datahashgen: %s: type %s is not supported", path, t)
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		return g.basic(expr, u, path)
+	case *types.Pointer:
+		p := g.newVar("p")
+
+		fmt.Fprintf(&g.body, "if %s := %s; %s != nil {\n", p, expr, p)
+
+		if err := g.value("(*"+p+")", u.Elem(), path); err != nil {
+			return err
+		}
+
+		g.body.WriteString("}\n")
+
+		return nil
+	case *types.Slice:
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Uint8 {
+			if types.Identical(u.Elem(), types.Typ[types.Byte]) {
+				fmt.Fprintf(&g.body, "_, _ = w.Write(%s)\n", expr)
+			} else {
+				e := g.newVar("e")
+
+				fmt.Fprintf(&g.body, "for _, %s := range %s {\n_, _ = w.Write([]byte{byte(%s)})\n}\n", e, expr, e)
+			}
+
+			return nil
+		}
+
+		return g.list(expr, u.Elem(), path)
+	case *types.Array:
+		return g.list(expr, u.Elem(), path)
+	case *types.Struct:
+		if named, ok := t.(*types.Named); ok && named.Obj().Pkg() != g.pkg {
+			return fmt.Errorf("datahashgen: %s: struct type %s of another package is not supported", path, t)
+		}
+
+		return g.structValue(expr, u, path)
+	}
+
+	return fmt.Errorf("datahashgen: %s: type %s is not supported", path, t)
+}
+
+func (g *generator) basic(expr string, b *types.Basic, path string) error {
+	switch {
+	case b.Info()&types.IsString != 0:
+		g.io = true
+
+		fmt.Fprintf(&g.body, "_, _ = io.WriteString(w, string(%s))\n", expr)
+	case b.Kind() == types.Uintptr:
+		return fmt.Errorf("datahashgen: %s: uintptr is not supported", path)
+	case b.Info()&types.IsInteger != 0:
+		fmt.Fprintf(&g.body, "binary.LittleEndian.PutUint64(buf[:], uint64(%s)) //nolint:gosec\n_, _ = w.Write(buf[:])\n", expr)
+	case b.Info()&types.IsFloat != 0:
+		g.math = true
+
+		fmt.Fprintf(&g.body, "binary.LittleEndian.PutUint64(buf[:], math.Float64bits(float64(%s)))\n_, _ = w.Write(buf[:])\n", expr)
+	case b.Info()&types.IsComplex != 0:
+		g.math = true
+
+		fmt.Fprintf(&g.body, "binary.LittleEndian.PutUint64(buf[:], math.Float64bits(real(complex128(%s))))\n_, _ = w.Write(buf[:])\n", expr)
+		fmt.Fprintf(&g.body, "binary.LittleEndian.PutUint64(buf[:], math.Float64bits(imag(complex128(%s))))\n_, _ = w.Write(buf[:])\n", expr)
+	case b.Info()&types.IsBoolean != 0:
+		fmt.Fprintf(&g.body, "if %s {\n", expr)
+		g.write("\x01")
+		g.body.WriteString("} else {\n")
+		g.write("\x00")
+		g.body.WriteString("}\n")
+	default:
+		return fmt.Errorf("datahashgen: %s: type %s is not supported", path, b)
+	}
+
+	return nil
+}
+
+func (g *generator) list(expr string, elem types.Type, path string) error {
+	i, e := g.newVar("i"), g.newVar("e")
+
+	g.write(startList)
+	fmt.Fprintf(&g.body, "for %s, %s := range %s {\nif %s > 0 {\n", i, e, expr, i)
+	g.write(comma)
+	g.body.WriteString("}\n\n")
+
+	if err := g.value(e, elem, path+"[]"); err != nil {
+		return err
+	}
+
+	g.body.WriteString("}\n\n")
+	g.write(endList)
+
+	return nil
+}
+
+type genField struct {
+	name      string
+	expr      string
+	typ       types.Type
+	omitZero  bool
+	omitEmpty bool
+}
+
+func (g *generator) structValue(expr string, st *types.Struct, path string) error {
+	var fields []genField
+
+	for i := range st.NumFields() {
+		f := st.Field(i)
+
+		if isSkipped(f.Type()) {
+			continue
+		}
+
+		gf := genField{name: f.Name(), expr: expr + "." + f.Name(), typ: f.Type()}
+
+		tag := reflect.StructTag(st.Tag(i)).Get("datahash")
+
+		if tag == "-" {
+			continue
+		}
+
+		for directive := range strings.SplitSeq(tag, ",") {
+			switch directive = strings.TrimSpace(directive); {
+			case directive == "":
+			case directive == "omitzero":
+				gf.omitZero = true
+			case directive == "omitempty":
+				gf.omitEmpty = true
+			case strings.HasPrefix(directive, "name=") && len(directive) > len("name="):
+				gf.name = strings.TrimPrefix(directive, "name=")
+			default:
+				return fmt.Errorf("datahashgen: %s.%s: tag directive %q is not supported", path, f.Name(), directive)
+			}
+		}
+
+		fields = append(fields, gf)
+	}
+
+	// A comma precedes a field if any field before it was written. This is only known
+	// at runtime while all fields before it are omittable.
+	var (
+		sep              string
+		written, omitted bool
+	)
+
+	g.write(startList)
+
+	for _, f := range fields {
+		cond, err := omitCondition(f)
+		if err != nil {
+			return fmt.Errorf("datahashgen: %s.%s: %w", path, f.name, err)
+		}
+
+		if cond != "" && !written && !omitted {
+			sep = g.newVar("sep")
+
+			fmt.Fprintf(&g.body, "\n%s := false\n\n", sep)
+		}
+
+		if cond != "" {
+			fmt.Fprintf(&g.body, "if !(%s) {\n", cond)
+		}
+
+		switch {
+		case written:
+			g.write(comma + f.name + colon)
+		case omitted:
+			fmt.Fprintf(&g.body, "if %s {\n", sep)
+			g.write(comma)
+			g.body.WriteString("}\n\n")
+			g.write(f.name + colon)
+		default:
+			g.write(f.name + colon)
+		}
+
+		if cond != "" && !written {
+			fmt.Fprintf(&g.body, "%s = true\n\n", sep)
+		}
+
+		if err := g.value(f.expr, f.typ, path+"."+f.name); err != nil {
+			return err
+		}
+
+		if cond != "" {
+			g.body.WriteString("}\n")
+
+			omitted = true
+		} else {
+			written = true
+		}
+
+		g.body.WriteString("\n")
+	}
+
+	g.write(endList)
+
+	return nil
+}
+
+// omitCondition returns the Go condition under which a field with omitzero or omitempty is skipped.
+func omitCondition(f genField) (string, error) {
+	if !f.omitZero && !f.omitEmpty {
+		return "", nil
+	}
+
+	switch u := f.typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsString != 0:
+			return f.expr + ` == ""`, nil
+		case u.Info()&types.IsBoolean != 0:
+			return "!" + f.expr, nil
+		case u.Info()&types.IsNumeric != 0:
+			return f.expr + " == 0", nil
+		}
+	case *types.Pointer:
+		return f.expr + " == nil", nil
+	case *types.Slice:
+		if f.omitEmpty {
+			return "len(" + f.expr + ") == 0", nil
+		}
+
+		return f.expr + " == nil", nil
+	}
+
+	return "", fmt.Errorf("omitzero and omitempty are not supported for type %s", f.typ)
+}
+
+// hasMethod reports whether the method set of t contains the exported method name.
+func hasMethod(t types.Type, name string) bool {
+	return types.NewMethodSet(t).Lookup(nil, name) != nil
+}
+
+// isSkipped reports whether the reflection-based Hasher skips struct fields of type t.
+func isSkipped(t types.Type) bool {
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	if named.Obj().Name() == "noCopy" {
+		return true
+	}
+
+	if pkg := named.Obj().Pkg(); pkg != nil && pkg.Path() == "sync" {
+		switch named.Obj().Name() {
+		case "Mutex", "RWMutex", "Once", "WaitGroup", "Cond":
+			return true
+		}
+	}
+
+	return false
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToLower(s[:1]) + s[1:]
+}
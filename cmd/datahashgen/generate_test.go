@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerate_UpToDate(t *testing.T) {
+	dir := filepath.Join("internal", "example")
+
+	got, err := Generate(dir, []string{"User", "Address"}, "datahash_gen.go")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, err := os.ReadFile(filepath.Join(dir, "datahash_gen.go"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("generated code is outdated, run go generate ./...")
+	}
+}
+
+func TestGenerate_Unsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{"map", "type T struct{ M map[string]int }", "T.M: type map[string]int is not supported"},
+		{"interface", "type T struct{ V any }", "T.V: type any is not supported"},
+		{"directive", "type T struct{ S []int `datahash:\"set\"` }", `T.S: tag directive "set" is not supported`},
+		{"omitzero", "type T struct{ P struct{ X int } `datahash:\"omitzero\"` }", "omitzero and omitempty are not supported"},
+		{"not struct", "type T int", "T is not a struct type"},
+		{"missing", "type U struct{}", "type T not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			if err := os.WriteFile(filepath.Join(dir, "t.go"), []byte("package p\n\n"+tt.src+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := Generate(dir, []string{"T"}, "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
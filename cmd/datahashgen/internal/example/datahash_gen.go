@@ -0,0 +1,198 @@
+// Code generated by datahashgen; DO NOT EDIT.
+
+package example
+
+import (
+	"encoding/binary"
+	"hash"
+	"io"
+	"math"
+)
+
+var userHashChunks = [...][]byte{
+	[]byte("\x06"),
+	[]byte("Nickname\x02"),
+	[]byte("\x03"),
+	[]byte("id\x02"),
+	[]byte("\x03Name\x02"),
+	[]byte("\x03Email\x02"),
+	[]byte("\x03Admin\x02"),
+	[]byte("\x01"),
+	[]byte("\x00"),
+	[]byte("\x03Score\x02"),
+	[]byte("\x03Ratio\x02"),
+	[]byte("\x03Tags\x02"),
+	[]byte("\a"),
+	[]byte("\x03Avatar\x02"),
+	[]byte("\x03Checksum\x02"),
+	[]byte("\x03Address\x02"),
+	[]byte("\x03Previous\x02"),
+	[]byte("\x03Created\x02"),
+	[]byte("\x03Aliases\x02"),
+	[]byte("\x03Level\x02"),
+	[]byte("\x03Points\x02"),
+	[]byte("X\x02"),
+	[]byte("\x03Y\x02"),
+}
+
+// WriteHash writes the canonical datahash stream of v, implementing datahash.HashWriter.
+func (v User) WriteHash(w hash.Hash64) error {
+	var buf [8]byte
+
+	_, _ = w.Write(userHashChunks[0])
+
+	sep1 := false
+
+	if !(v.Nickname == "") {
+		_, _ = w.Write(userHashChunks[1])
+		sep1 = true
+
+		_, _ = io.WriteString(w, string(v.Nickname))
+	}
+
+	if sep1 {
+		_, _ = w.Write(userHashChunks[2])
+	}
+
+	_, _ = w.Write(userHashChunks[3])
+	binary.LittleEndian.PutUint64(buf[:], uint64(v.ID)) //nolint:gosec
+	_, _ = w.Write(buf[:])
+
+	_, _ = w.Write(userHashChunks[4])
+	_, _ = io.WriteString(w, string(v.Name))
+
+	_, _ = w.Write(userHashChunks[5])
+	if p2 := v.Email; p2 != nil {
+		_, _ = io.WriteString(w, string((*p2)))
+	}
+
+	_, _ = w.Write(userHashChunks[6])
+	if v.Admin {
+		_, _ = w.Write(userHashChunks[7])
+	} else {
+		_, _ = w.Write(userHashChunks[8])
+	}
+
+	_, _ = w.Write(userHashChunks[9])
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(float64(v.Score)))
+	_, _ = w.Write(buf[:])
+
+	_, _ = w.Write(userHashChunks[10])
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(float64(v.Ratio)))
+	_, _ = w.Write(buf[:])
+
+	_, _ = w.Write(userHashChunks[11])
+	_, _ = w.Write(userHashChunks[0])
+	for i3, e4 := range v.Tags {
+		if i3 > 0 {
+			_, _ = w.Write(userHashChunks[2])
+		}
+
+		_, _ = io.WriteString(w, string(e4))
+	}
+
+	_, _ = w.Write(userHashChunks[12])
+
+	_, _ = w.Write(userHashChunks[13])
+	_, _ = w.Write(v.Avatar)
+
+	_, _ = w.Write(userHashChunks[14])
+	_, _ = w.Write(userHashChunks[0])
+	for i5, e6 := range v.Checksum {
+		if i5 > 0 {
+			_, _ = w.Write(userHashChunks[2])
+		}
+
+		binary.LittleEndian.PutUint64(buf[:], uint64(e6)) //nolint:gosec
+		_, _ = w.Write(buf[:])
+	}
+
+	_, _ = w.Write(userHashChunks[12])
+
+	_, _ = w.Write(userHashChunks[15])
+	if err := v.Address.WriteHash(w); err != nil {
+		return err
+	}
+
+	_, _ = w.Write(userHashChunks[16])
+	if p7 := v.Previous; p7 != nil {
+		if err := (*p7).WriteHash(w); err != nil {
+			return err
+		}
+	}
+
+	_, _ = w.Write(userHashChunks[17])
+	b8, err := v.Created.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	_, _ = w.Write(b8)
+
+	if !(len(v.Aliases) == 0) {
+		_, _ = w.Write(userHashChunks[18])
+		_, _ = w.Write(userHashChunks[0])
+		for i9, e10 := range v.Aliases {
+			if i9 > 0 {
+				_, _ = w.Write(userHashChunks[2])
+			}
+
+			_, _ = io.WriteString(w, string(e10))
+		}
+
+		_, _ = w.Write(userHashChunks[12])
+	}
+
+	_, _ = w.Write(userHashChunks[19])
+	binary.LittleEndian.PutUint64(buf[:], uint64(v.Level)) //nolint:gosec
+	_, _ = w.Write(buf[:])
+
+	_, _ = w.Write(userHashChunks[20])
+	_, _ = w.Write(userHashChunks[0])
+	for i11, e12 := range v.Points {
+		if i11 > 0 {
+			_, _ = w.Write(userHashChunks[2])
+		}
+
+		_, _ = w.Write(userHashChunks[0])
+		_, _ = w.Write(userHashChunks[21])
+		binary.LittleEndian.PutUint64(buf[:], uint64(e12.X)) //nolint:gosec
+		_, _ = w.Write(buf[:])
+
+		_, _ = w.Write(userHashChunks[22])
+		binary.LittleEndian.PutUint64(buf[:], uint64(e12.Y)) //nolint:gosec
+		_, _ = w.Write(buf[:])
+
+		_, _ = w.Write(userHashChunks[12])
+	}
+
+	_, _ = w.Write(userHashChunks[12])
+
+	_, _ = w.Write(userHashChunks[12])
+
+	return nil
+}
+
+var addressHashChunks = [...][]byte{
+	[]byte("\x06"),
+	[]byte("Street\x02"),
+	[]byte("\x03Zip\x02"),
+	[]byte("\a"),
+}
+
+// WriteHash writes the canonical datahash stream of v, implementing datahash.HashWriter.
+func (v Address) WriteHash(w hash.Hash64) error {
+	var buf [8]byte
+
+	_, _ = w.Write(addressHashChunks[0])
+	_, _ = w.Write(addressHashChunks[1])
+	_, _ = io.WriteString(w, string(v.Street))
+
+	_, _ = w.Write(addressHashChunks[2])
+	binary.LittleEndian.PutUint64(buf[:], uint64(v.Zip)) //nolint:gosec
+	_, _ = w.Write(buf[:])
+
+	_, _ = w.Write(addressHashChunks[3])
+
+	return nil
+}
@@ -0,0 +1,43 @@
+// Package example contains types with generated WriteHash methods for testing datahashgen.
+package example
+
+import (
+	"time"
+)
+
+//go:generate go run github.com/go-sqlt/datahash/cmd/datahashgen -type=User,Address
+
+// User is hashed by its generated WriteHash method.
+type User struct {
+	Nickname string `datahash:"omitzero"`
+	ID       int64  `datahash:"name=id"`
+	Name     string
+	Email    *string
+	Admin    bool
+	Score    float64
+	Ratio    float32
+	Tags     []string
+	Avatar   []byte
+	Checksum [4]uint8
+	Address  Address
+	Previous *Address
+	Created  time.Time
+	Aliases  []string `datahash:"omitempty"`
+	Secret   string   `datahash:"-"`
+	Level    Level
+	Points   []Point
+}
+
+// Address is a nested struct with a generated WriteHash method.
+type Address struct {
+	Street string
+	Zip    uint16
+}
+
+// Level is a named integer type.
+type Level uint8
+
+// Point is a nested struct without a generated method.
+type Point struct {
+	X, Y int
+}
@@ -0,0 +1,71 @@
+package example_test
+
+import (
+	"bytes"
+	"hash/fnv"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-sqlt/datahash"
+	"github.com/go-sqlt/datahash/cmd/datahashgen/internal/example"
+)
+
+// Mirror types without the generated methods are hashed by reflection.
+type (
+	plainUser    example.User
+	plainAddress example.Address
+)
+
+func TestWriteHash(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	email := "alice@example.com"
+
+	users := []example.User{
+		{},
+		{
+			ID:       1,
+			Name:     "Alice",
+			Email:    &email,
+			Admin:    true,
+			Score:    math.Pi,
+			Ratio:    0.5,
+			Tags:     []string{"a", "b"},
+			Avatar:   []byte{1, 2, 3},
+			Checksum: [4]uint8{1, 2, 3, 4},
+			Address:  example.Address{Street: "Main", Zip: 12345},
+			Previous: &example.Address{Street: "Old", Zip: 1},
+			Created:  time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Nickname: "ali",
+			Aliases:  []string{"al"},
+			Secret:   "ignored",
+			Level:    3,
+			Points:   []example.Point{{X: 1, Y: -2}, {X: 3}},
+		},
+		{Nickname: "bob", Aliases: []string{}, Tags: []string{}},
+	}
+
+	for _, user := range users {
+		assertStream(t, hasher, user, plainUser(user))
+		assertStream(t, hasher, user.Address, plainAddress(user.Address))
+	}
+}
+
+func assertStream(t *testing.T, hasher *datahash.Hasher, generated, reflected any) {
+	t.Helper()
+
+	want, err := hasher.Explain(reflected)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := hasher.Explain(generated)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Errorf("stream mismatch for %T:\n  got:  %q\n  want: %q", generated, got.Bytes(), want.Bytes())
+	}
+}
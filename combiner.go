@@ -0,0 +1,161 @@
+package datahash
+
+import (
+	"bytes"
+	"hash"
+	"slices"
+)
+
+// Combiner folds the sub-hashes of the elements of an unordered collection into one hash.
+// Add must be commutative, so that the order of the elements does not matter.
+// A Combiner is used for a single collection and need not be safe for concurrent use.
+type Combiner interface {
+	Add(sum uint64)
+	Sum() uint64
+}
+
+// NewXORCombiner returns a Combiner that XORs sub-hashes, the default. It is the fastest,
+// but equal elements cancel out.
+func NewXORCombiner() Combiner {
+	return &xorCombiner{}
+}
+
+// NewAddCombiner returns a Combiner that adds mixed sub-hashes, keeping multiplicity.
+func NewAddCombiner() Combiner {
+	return &addCombiner{}
+}
+
+// NewMulCombiner returns a Combiner that multiplies mixed sub-hashes forced to be odd,
+// keeping multiplicity. Unlike addition, it is not linear, so elements cannot be chosen
+// to offset each other by simple arithmetic.
+func NewMulCombiner() Combiner {
+	return &mulCombiner{result: 1}
+}
+
+type xorCombiner struct {
+	result uint64
+}
+
+func (c *xorCombiner) Add(sum uint64) { c.result ^= sum }
+func (c *xorCombiner) Sum() uint64    { return c.result }
+
+type addCombiner struct {
+	result uint64
+}
+
+func (c *addCombiner) Add(sum uint64) { c.result += mix(sum) }
+func (c *addCombiner) Sum() uint64    { return c.result }
+
+type mulCombiner struct {
+	result uint64
+	n      int
+}
+
+func (c *mulCombiner) Add(sum uint64) {
+	c.result *= mix(sum) | 1
+	c.n++
+}
+
+// Sum returns zero for empty collections, like the other combiners.
+func (c *mulCombiner) Sum() uint64 {
+	if c.n == 0 {
+		return 0
+	}
+
+	return c.result
+}
+
+// folder folds sub-hashes with the built-in combiners without allocating,
+// or with the Combiner of Options.Combiner, or collects them in SortedUnordered mode.
+// Wide Hashers collect the full sub-digests instead, see NewDigest.
+type folder struct {
+	combiner Combiner
+	strong   bool
+	result   uint64
+	sorted   bool
+	sums     []uint64
+	wide     bool
+	digests  [][]byte
+}
+
+func (h *Hasher) folder() folder {
+	if h.wide && (h.opts.SortedUnordered || h.opts.Combiner == nil) {
+		return folder{wide: true}
+	}
+
+	if h.opts.SortedUnordered {
+		return folder{sorted: true}
+	}
+
+	if h.opts.Combiner != nil {
+		return folder{combiner: h.opts.Combiner()}
+	}
+
+	return folder{strong: h.opts.StrongUnordered}
+}
+
+func (f *folder) add(sum uint64) {
+	switch {
+	case f.sorted:
+		f.sums = append(f.sums, sum)
+	case f.combiner != nil:
+		f.combiner.Add(sum)
+	case f.strong:
+		f.result += mix(sum)
+	default:
+		f.result ^= sum
+	}
+}
+
+// addHash adds the sub-hash of sub, or its full digest if f is wide.
+func (f *folder) addHash(sub hash.Hash64) {
+	if f.wide {
+		f.digests = append(f.digests, sub.Sum(nil))
+
+		return
+	}
+
+	f.add(sub.Sum64())
+}
+
+func (f *folder) sum() uint64 {
+	if f.combiner != nil {
+		return f.combiner.Sum()
+	}
+
+	return f.result
+}
+
+// write writes the folded sub-hashes if they are not zero, or the sorted sub-hashes in SortedUnordered mode
+// or of wide Hashers.
+func (f *folder) write(c *container) error {
+	if f.wide {
+		slices.SortFunc(f.digests, bytes.Compare)
+
+		for _, digest := range f.digests {
+			if err := c.write(digest); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if f.sorted {
+		slices.Sort(f.sums)
+
+		for _, sum := range f.sums {
+			if err := c.writeUint64(sum); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if result := f.sum(); result != 0 {
+		return c.writeUint64(result)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type countingCombiner struct {
+	datahash.Combiner
+	adds *int
+}
+
+func (c countingCombiner) Add(sum uint64) {
+	*c.adds++
+
+	c.Combiner.Add(sum)
+}
+
+func TestCombiner(t *testing.T) {
+	values := []any{
+		[]string{"a", "b", "c"},
+		map[string]int{"a": 1, "b": 2},
+		struct{ A, B int }{1, 2},
+	}
+
+	opts := datahash.Options{UnorderedSlice: true, UnorderedStruct: true}
+
+	hash := func(opts datahash.Options, value any) uint64 {
+		return datahash.New(fnv.New64a, opts).MustHash(value)
+	}
+
+	for _, value := range values {
+		xor, add, strong := opts, opts, opts
+		xor.Combiner = datahash.NewXORCombiner
+		add.Combiner = datahash.NewAddCombiner
+		strong.StrongUnordered = true
+
+		if hash(xor, value) != hash(opts, value) {
+			t.Errorf("expected the XOR combiner to match the default for %v", value)
+		}
+
+		if hash(add, value) != hash(strong, value) {
+			t.Errorf("expected the add combiner to match StrongUnordered for %v", value)
+		}
+	}
+
+	mul := opts
+	mul.Combiner = datahash.NewMulCombiner
+
+	if hash(mul, []string{"a", "b", "c"}) != hash(mul, []string{"c", "a", "b"}) {
+		t.Error("expected order independence")
+	}
+
+	if hash(mul, []string{"a", "a", "b"}) == hash(mul, []string{"b"}) {
+		t.Error("expected multiplicity to be kept")
+	}
+
+	if hash(mul, []string{}) != hash(opts, []string{}) {
+		t.Error("expected empty sets to hash like the default")
+	}
+
+	var adds int
+
+	custom := opts
+	custom.Combiner = func() datahash.Combiner {
+		return countingCombiner{Combiner: datahash.NewXORCombiner(), adds: &adds}
+	}
+
+	if hash(custom, []int{1, 2, 3}) != hash(opts, []int{1, 2, 3}) || adds != 3 {
+		t.Errorf("expected the custom combiner to be used, got %d adds", adds)
+	}
+}
+
+func TestHasher_SortedUnordered(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{SortedUnordered: true, UnorderedSlice: true})
+
+	if hasher.MustHash([]string{"a", "b", "c"}) != hasher.MustHash([]string{"c", "a", "b"}) {
+		t.Error("expected sorted digests to be order-independent")
+	}
+
+	if hasher.MustHash([]string{"a", "a", "b"}) == hasher.MustHash([]string{"b"}) {
+		t.Error("expected equal elements not to cancel out")
+	}
+
+	if hasher.MustHash([]string{"a", "a"}) == hasher.MustHash([]string{"a"}) {
+		t.Error("expected multiplicity to be kept")
+	}
+
+	if hasher.MustHash(map[string]int{"x": 1, "y": 2}) != hasher.MustHash(map[string]int{"y": 2, "x": 1}) {
+		t.Error("expected maps to hash independently of their order")
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{UnorderedSlice: true})
+
+	if hasher.MustHash([]string{"a", "b"}) == plain.MustHash([]string{"a", "b"}) {
+		t.Error("expected sorted digests to differ from folded digests")
+	}
+}
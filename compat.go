@@ -0,0 +1,404 @@
+package datahash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"math"
+	"reflect"
+	"time"
+)
+
+// Formats reproducing the hashes of other libraries, so that persisted hashes stay valid when
+// migrating to datahash. They are not canonical streams: only Hash, MustHash, HashBytes and
+// FuncFor produce compatible hashes, while stream based methods such as Encode, Explain or
+// Equal fail.
+//
+// Compatibility formats read the "hash" struct tag ("ignore" or "-", "set" and "string") and
+// honor the Hash, HashInclude and HashIncludeMap methods of hashstructure. Options map to the
+// hashstructure options as follows: ZeroNil to ZeroNil, IgnoreZero to IgnoreZeroValue,
+// UnorderedSlice to SlicesAsSets and String to UseStringer. All other Options are ignored.
+const (
+	// FormatHashstructureV2 reproduces github.com/mitchellh/hashstructure/v2 with its FormatV2.
+	// Use fnv.New64 to match its default hash function.
+	FormatHashstructureV2 Format = formatCompat + iota
+
+	// FormatGohugoioHashstructure reproduces github.com/gohugoio/hashstructure, which differs
+	// from FormatHashstructureV2 in supporting complex128 and HashIncludeMap on map types.
+	// Use fnv.New64 to match its default hash function.
+	FormatGohugoioHashstructure
+)
+
+// formatCompat is the first compatibility format.
+const formatCompat Format = 0x80
+
+// hashstructure interfaces, matched structurally.
+type (
+	compatHashable interface {
+		Hash() (uint64, error)
+	}
+	compatIncludable interface {
+		HashInclude(field string, v any) (bool, error)
+	}
+	compatIncludableMap interface {
+		HashIncludeMap(field string, k, v any) (bool, error)
+	}
+)
+
+// compatWalker computes hashes of a compatibility format. It mirrors the traversal of
+// hashstructure exactly, including its quirks, because any deviation changes hashes.
+type compatWalker struct {
+	format     Format
+	h          hash.Hash64
+	zeroNil    bool
+	ignoreZero bool
+	sets       bool
+	stringer   bool
+}
+
+// compatVisit holds the struct containing the visited field.
+type compatVisit struct {
+	set    bool
+	parent any
+	field  string
+}
+
+// hashCompat computes the hash of value in the compatibility format of h.
+func (h *Hasher) hashCompat(value any) (uint64, error) {
+	c := h.containerPool.Get().(*container)
+	defer h.containerPool.Put(c)
+
+	c.hash.Reset()
+
+	w := &compatWalker{
+		format:     h.opts.Format,
+		h:          c.hash,
+		zeroNil:    h.opts.ZeroNil,
+		ignoreZero: h.opts.IgnoreZero,
+		sets:       h.opts.UnorderedSlice,
+		stringer:   h.opts.String,
+	}
+
+	return w.visit(reflect.ValueOf(value), nil)
+}
+
+func (w *compatWalker) visit(v reflect.Value, opts *compatVisit) (uint64, error) {
+	t := reflect.TypeFor[int]()
+
+	for {
+		if v.Kind() == reflect.Interface {
+			v = v.Elem()
+
+			continue
+		}
+
+		if v.Kind() == reflect.Pointer {
+			if w.zeroNil {
+				t = v.Type().Elem()
+			}
+
+			v = reflect.Indirect(v)
+
+			continue
+		}
+
+		break
+	}
+
+	// Nil pointers and interfaces are hashed like zero values.
+	if !v.IsValid() {
+		v = reflect.Zero(t)
+	}
+
+	k := v.Kind()
+
+	if k >= reflect.Bool && k <= reflect.Complex64 || k == reflect.Complex128 && w.format == FormatGohugoioHashstructure {
+		return w.direct(v)
+	}
+
+	if v.Type() == timeType {
+		b, err := v.Interface().(time.Time).MarshalBinary()
+		if err != nil {
+			return 0, err
+		}
+
+		w.h.Reset()
+
+		_, err = w.h.Write(b)
+
+		return w.h.Sum64(), err
+	}
+
+	switch k {
+	case reflect.Array:
+		var h uint64
+
+		for i := range v.Len() {
+			current, err := w.visit(v.Index(i), nil)
+			if err != nil {
+				return 0, err
+			}
+
+			h = w.ordered(h, current)
+		}
+
+		return h, nil
+	case reflect.Map:
+		return w.visitMap(v, opts)
+	case reflect.Struct:
+		return w.visitStruct(v)
+	case reflect.Slice:
+		var (
+			h   uint64
+			set = opts != nil && opts.set
+		)
+
+		for i := range v.Len() {
+			current, err := w.visit(v.Index(i), nil)
+			if err != nil {
+				return 0, err
+			}
+
+			if set || w.sets {
+				h ^= current
+			} else {
+				h = w.ordered(h, current)
+			}
+		}
+
+		if set {
+			h = w.finish(h)
+		}
+
+		return h, nil
+	case reflect.String:
+		w.h.Reset()
+
+		_, err := w.h.Write(stringToBytes(v.String()))
+
+		return w.h.Sum64(), err
+	}
+
+	return 0, fmt.Errorf("datahash: unknown kind to hash in format %s: %s", w.format, k)
+}
+
+func (w *compatWalker) visitMap(v reflect.Value, opts *compatVisit) (uint64, error) {
+	var (
+		include compatIncludableMap
+		field   string
+	)
+
+	if impl, ok := v.Interface().(compatIncludableMap); ok && w.format == FormatGohugoioHashstructure {
+		include = impl
+	} else if opts != nil && opts.parent != nil {
+		if impl, ok := opts.parent.(compatIncludableMap); ok {
+			include, field = impl, opts.field
+		}
+	}
+
+	var h uint64
+
+	iter := v.MapRange()
+
+	for iter.Next() {
+		if include != nil {
+			incl, err := include.HashIncludeMap(field, iter.Key().Interface(), iter.Value().Interface())
+			if err != nil {
+				return 0, err
+			}
+
+			if !incl {
+				continue
+			}
+		}
+
+		kh, err := w.visit(iter.Key(), nil)
+		if err != nil {
+			return 0, err
+		}
+
+		vh, err := w.visit(iter.Value(), nil)
+		if err != nil {
+			return 0, err
+		}
+
+		h ^= w.ordered(kh, vh)
+	}
+
+	return w.finish(h), nil
+}
+
+func (w *compatWalker) visitStruct(v reflect.Value) (uint64, error) {
+	parent := v.Interface()
+
+	include, _ := parent.(compatIncludable)
+
+	if impl, ok := parent.(compatHashable); ok {
+		return impl.Hash()
+	}
+
+	if v.CanAddr() {
+		ptr := v.Addr().Interface()
+
+		if impl, ok := ptr.(compatIncludable); ok {
+			include = impl
+		}
+
+		if impl, ok := ptr.(compatHashable); ok {
+			return impl.Hash()
+		}
+	}
+
+	t := v.Type()
+
+	h, err := w.visit(reflect.ValueOf(t.Name()), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range v.NumField() {
+		sf := t.Field(i)
+
+		// hashstructure finishes the running hash after every field that is not skipped,
+		// and for fields named "_".
+		if v.CanSet() || sf.Name != "_" {
+			fv := v.Field(i)
+
+			if !sf.IsExported() {
+				continue
+			}
+
+			tag := sf.Tag.Get("hash")
+
+			if tag == "ignore" || tag == "-" {
+				continue
+			}
+
+			if w.ignoreZero && fv.IsZero() {
+				continue
+			}
+
+			if tag == "string" || w.stringer {
+				if impl, ok := fv.Interface().(fmt.Stringer); ok {
+					fv = reflect.ValueOf(impl.String())
+				} else if tag == "string" {
+					return 0, fmt.Errorf("datahash: field %s of %s does not implement fmt.Stringer", sf.Name, t)
+				}
+			}
+
+			if include != nil {
+				incl, err := include.HashInclude(sf.Name, fv)
+				if err != nil {
+					return 0, err
+				}
+
+				if !incl {
+					continue
+				}
+			}
+
+			kh, err := w.visit(reflect.ValueOf(sf.Name), nil)
+			if err != nil {
+				return 0, err
+			}
+
+			vh, err := w.visit(fv, &compatVisit{set: tag == "set", parent: parent, field: sf.Name})
+			if err != nil {
+				return 0, err
+			}
+
+			h ^= w.ordered(kh, vh)
+		}
+
+		h = w.finish(h)
+	}
+
+	return h, nil
+}
+
+// direct hashes booleans and numbers by their fixed-size little-endian encoding.
+func (w *compatWalker) direct(v reflect.Value) (uint64, error) {
+	var (
+		b [16]byte
+		n int
+	)
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			b[0] = 1
+		}
+
+		n = 1
+	case reflect.Int8:
+		b[0], n = byte(v.Int()), 1 //nolint:gosec
+	case reflect.Int16:
+		binary.LittleEndian.PutUint16(b[:], uint16(v.Int())) //nolint:gosec
+		n = 2
+	case reflect.Int32:
+		binary.LittleEndian.PutUint32(b[:], uint32(v.Int())) //nolint:gosec
+		n = 4
+	case reflect.Int, reflect.Int64:
+		binary.LittleEndian.PutUint64(b[:], uint64(v.Int())) //nolint:gosec
+		n = 8
+	case reflect.Uint8:
+		b[0], n = byte(v.Uint()), 1 //nolint:gosec
+	case reflect.Uint16:
+		binary.LittleEndian.PutUint16(b[:], uint16(v.Uint())) //nolint:gosec
+		n = 2
+	case reflect.Uint32:
+		binary.LittleEndian.PutUint32(b[:], uint32(v.Uint())) //nolint:gosec
+		n = 4
+	case reflect.Uint, reflect.Uint64:
+		binary.LittleEndian.PutUint64(b[:], v.Uint())
+		n = 8
+	case reflect.Float32:
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(v.Float())))
+		n = 4
+	case reflect.Float64:
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(v.Float()))
+		n = 8
+	case reflect.Complex64:
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(real(v.Complex()))))
+		binary.LittleEndian.PutUint32(b[4:], math.Float32bits(float32(imag(v.Complex()))))
+		n = 8
+	case reflect.Complex128:
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(real(v.Complex())))
+		binary.LittleEndian.PutUint64(b[8:], math.Float64bits(imag(v.Complex())))
+		n = 16
+	default:
+		// encoding/binary rejects uintptr as not fixed-size.
+		return 0, fmt.Errorf("datahash: type %s is not fixed-size in format %s", v.Type(), w.format)
+	}
+
+	w.h.Reset()
+
+	_, err := w.h.Write(b[:n])
+
+	return w.h.Sum64(), err
+}
+
+// ordered combines two hashes order-dependently.
+func (w *compatWalker) ordered(a, b uint64) uint64 {
+	var buf [16]byte
+
+	binary.LittleEndian.PutUint64(buf[:], a)
+	binary.LittleEndian.PutUint64(buf[8:], b)
+
+	w.h.Reset()
+	_, _ = w.h.Write(buf[:])
+
+	return w.h.Sum64()
+}
+
+// finish hardens an unordered hash, so that equal XOR-ed elements in other contexts cannot cancel it out.
+func (w *compatWalker) finish(a uint64) uint64 {
+	var buf [8]byte
+
+	binary.LittleEndian.PutUint64(buf[:], a)
+
+	w.h.Reset()
+	_, _ = w.h.Write(buf[:])
+
+	return w.h.Sum64()
+}
@@ -0,0 +1,204 @@
+package datahash_test
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-sqlt/datahash"
+	gohugoio "github.com/gohugoio/hashstructure"
+	mitchellh "github.com/mitchellh/hashstructure/v2"
+)
+
+type compatLevel int8
+
+func (l compatLevel) String() string { return fmt.Sprintf("level-%d", l) }
+
+type compatInner struct {
+	Name  string
+	Score float32
+}
+
+type compatFiltered struct {
+	Keep   string
+	Drop   string
+	Labels map[string]string
+}
+
+func (compatFiltered) HashInclude(field string, _ any) (bool, error) {
+	return field != "Drop", nil
+}
+
+func (compatFiltered) HashIncludeMap(_ string, k, _ any) (bool, error) {
+	return k != "internal", nil
+}
+
+type compatLabels map[string]string
+
+func (compatLabels) HashIncludeMap(_ string, k, _ any) (bool, error) {
+	return k != "secret", nil
+}
+
+type compatCustom struct {
+	ID uint64
+}
+
+func (c compatCustom) Hash() (uint64, error) { return c.ID * 31, nil }
+
+type compatValue struct {
+	Int      int
+	Int8     int8
+	Int16    int16
+	Uint32   uint32
+	Uint     uint
+	Float    float64
+	Complex  complex64
+	Bool     bool
+	String   string
+	Bytes    []byte
+	Array    [3]int16
+	Slice    []string
+	Set      []string    `hash:"set"`
+	Ignored  string      `hash:"ignore"`
+	Dash     string      `hash:"-"`
+	Level    compatLevel `hash:"string"`
+	Map      map[string]int
+	Ptr      *compatInner
+	Nil      *compatInner
+	Iface    any
+	NilIface any
+	Created  time.Time
+	Filtered compatFiltered
+	Custom   compatCustom
+	Nested   []map[int]*compatInner
+	Labels   compatLabels
+	hidden   int
+	_        int
+}
+
+func compatValues() []any {
+	return []any{
+		nil,
+		42,
+		"fast path",
+		true,
+		[]int{1, 2, 3},
+		map[string]any{"a": 1, "b": []string{"x"}},
+		compatValue{},
+		compatValue{
+			Int: -1, Int8: 2, Int16: 3, Uint32: 4, Uint: 5, Float: 6.5, Complex: complex(1, 2), Bool: true,
+			String: "s", Bytes: []byte("b"), Array: [3]int16{1, 2, 3}, Slice: []string{"a", "b"},
+			Set: []string{"y", "x"}, Ignored: "i", Dash: "d", Level: 3, Map: map[string]int{"k": 1, "l": 2},
+			Ptr: &compatInner{Name: "p", Score: 1.5}, Iface: compatInner{Name: "i"},
+			Created:  time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+			Filtered: compatFiltered{Keep: "k", Drop: "d", Labels: map[string]string{"internal": "x", "public": "y"}},
+			Custom:   compatCustom{ID: 7}, Nested: []map[int]*compatInner{{1: {Name: "n"}}, nil},
+			Labels: compatLabels{"secret": "s", "name": "n"}, hidden: 9,
+		},
+	}
+}
+
+func TestFormatHashstructureV2(t *testing.T) {
+	for _, opts := range []datahash.Options{
+		{},
+		{ZeroNil: true},
+		{IgnoreZero: true},
+		{UnorderedSlice: true},
+		{String: true},
+	} {
+		opts.Format = datahash.FormatHashstructureV2
+
+		hasher := datahash.New(fnv.New64, opts)
+
+		for _, value := range compatValues() {
+			got, err := hasher.Hash(value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want, err := mitchellh.Hash(value, mitchellh.FormatV2, &mitchellh.HashOptions{
+				ZeroNil:         opts.ZeroNil,
+				IgnoreZeroValue: opts.IgnoreZero,
+				SlicesAsSets:    opts.UnorderedSlice,
+				UseStringer:     opts.String,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != want {
+				t.Errorf("hash mismatch for %#v with %+v: got %d, want %d", value, opts, got, want)
+			}
+		}
+	}
+
+	hasher := datahash.New(xxhash.New, datahash.Options{Format: datahash.FormatHashstructureV2})
+
+	want, _ := mitchellh.Hash(compatValues()[7], mitchellh.FormatV2, &mitchellh.HashOptions{Hasher: xxhash.New()})
+
+	if got, _ := hasher.Hash(compatValues()[7]); got != want {
+		t.Errorf("hash mismatch with xxhash: got %d, want %d", got, want)
+	}
+
+	if _, err := hasher.Hash(complex128(1)); err == nil {
+		t.Error("expected error for complex128")
+	}
+
+	value := struct{ A int }{1}
+
+	if err := hasher.Encode(value, io.Discard); err == nil {
+		t.Error("expected Encode to fail in compatibility formats")
+	}
+
+	if _, err := hasher.Equal(value, value); err == nil {
+		t.Error("expected Equal to fail in compatibility formats")
+	}
+
+	if _, err := hasher.Diff(value, value); err == nil {
+		t.Error("expected Diff to fail in compatibility formats")
+	}
+
+	if _, err := hasher.Explain(value); err == nil {
+		t.Error("expected Explain to fail in compatibility formats")
+	}
+}
+
+func TestFormatGohugoioHashstructure(t *testing.T) {
+	values := append(compatValues(), complex(1, 2), struct{ C complex128 }{complex(3, 4)})
+
+	for _, opts := range []datahash.Options{
+		{},
+		{ZeroNil: true},
+		{IgnoreZero: true},
+		{UnorderedSlice: true},
+		{String: true},
+	} {
+		opts.Format = datahash.FormatGohugoioHashstructure
+
+		hasher := datahash.New(fnv.New64, opts)
+
+		for _, value := range values {
+			got, err := hasher.Hash(value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want, err := gohugoio.Hash(value, &gohugoio.HashOptions{
+				ZeroNil:         opts.ZeroNil,
+				IgnoreZeroValue: opts.IgnoreZero,
+				SlicesAsSets:    opts.UnorderedSlice,
+				UseStringer:     opts.String,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != want {
+				t.Errorf("hash mismatch for %#v with %+v: got %d, want %d", value, opts, got, want)
+			}
+		}
+	}
+}
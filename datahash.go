@@ -55,23 +55,36 @@ func main() {
 //   - For custom hashing behavior, implement the HashWriter or encoing.BinaryMarshaler interface.
 //   - Text/JSON/String Option: use marshaling interfaces if available.
 //   - Unordered Option: treat structs, slices, iter.Seq and iter.Seq2 as unordered sets.
+//   - Implement Optioner to declare per-type options next to the type.
 //   - Use `datahash:"-"` to exclude a field from hashing.
+//   - Fields of type sync.Mutex, sync.RWMutex, sync.Once, sync.WaitGroup, sync.Cond and noCopy are skipped.
 //   - Struct fields are hashed in declared order unless Unordered is enabled, in which case order is ignored.
 //   - Maps are always hashed as a unordered set.
 package datahash
 
 import (
+	"bytes"
+	"crypto/hmac"
 	"encoding"
 	"encoding/binary"
 	"encoding/json"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"hash"
+	"hash/maphash"
+	"io"
 	"math"
 	"reflect"
 	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 	"unsafe"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // HashWriter can be implemented by types that want to define
@@ -83,12 +96,281 @@ type HashWriter interface {
 	WriteHash(hash hash.Hash64) error
 }
 
+// HashWriterTo is like HashWriter, but the WriteHashTo method receives the canonical stream
+// as an io.Writer instead of the hash.Hash64, so implementations do not depend on the digest.
+// It takes precedence over HashWriter.
+type HashWriterTo interface {
+	WriteHashTo(w io.Writer) error
+}
+
 // Options configures how values are hashed, including support for unordered collections, interface marshaling, and zero value handling.
 type Options struct {
 	UnorderedStruct, UnorderedArray, UnorderedSlice, UnorderedSeq, UnorderedSeq2 bool
-	Text, JSON, String                                                           bool
+	Text, JSON, String, XML                                                      bool
 	ZeroNil                                                                      bool
 	IgnoreZero                                                                   bool
+
+	// WarnFunc, if set, is called whenever content is skipped without an error,
+	// for example nil interface values or revisited pointers. The path locates the
+	// skipped value relative to the hashed value (e.g. "Items[3].Meta") and is empty
+	// for the top-level value.
+	WarnFunc func(path string, reason string)
+
+	// SkipField, if set, is called once per struct field when the parent type is compiled.
+	// Fields for which it returns true are excluded from hashing, like fields tagged `datahash:"-"`.
+	SkipField func(parent reflect.Type, field reflect.StructField) bool
+
+	// Identity, if set, identifies non-nil pointers for revisit detection instead of their address,
+	// e.g. by an entity's ID field. Pointers with an identity that was already hashed are skipped,
+	// so graphs rebuilt with fresh pointers hash like the original graph. Returning false falls back
+	// to the address. Identities must be comparable.
+	Identity func(ptr reflect.Value) (id any, ok bool)
+
+	// StringTransform, if set, is applied to every string before it is written, after NormalizeUnicode
+	// and FoldCase, e.g. to trim whitespace or scrub personal data without wrapping every type.
+	// It applies to string kinds, including map keys, but not to the output of marshalers.
+	StringTransform func(string) string
+
+	// AutoAddress hashes values of types whose marshaler or hashing interface has a pointer receiver
+	// by it, even if they are not addressable, by calling it on a copy. Hashes then do not depend
+	// on how values were obtained, e.g. Hash(v) and Hash(&v) agree. It applies to all formats.
+	AutoAddress bool
+
+	// MarshalFallback hashes values whose BinaryMarshaler fails by their TextMarshaler instead of
+	// failing the hash, values whose TextMarshaler fails by their json.Marshaler, and values whose
+	// json.Marshaler fails, or that lack the next marshaler, by their kind, e.g. the fields of a struct.
+	// Some types only fail to marshal in edge states; their hashes then depend on which marshaler succeeded.
+	MarshalFallback bool
+
+	// Positional hashes the fields of ordered structs by their position instead of their name, so
+	// renaming fields does not change the hash and streams are smaller. Reordering fields does, and
+	// structs of the same shape hash alike. Skipped fields leave an empty position unless no hashed
+	// field follows them. Unordered structs keep hashing fields by name.
+	Positional bool
+
+	// FlattenEmbedded hashes the fields of embedded structs and pointers to structs as fields of
+	// the parent struct, like encoding/json, so that moving fields into an embedded base type does
+	// not change the hash. Fields of the parent shadow promoted fields of the same name, and fields
+	// promoted from nil pointers are skipped. Embedded fields with a name=<name> directive, or a
+	// json name in JSONTags mode, stay nested. The struct tag directive flatten sets it for a single field.
+	FlattenEmbedded bool
+
+	// JSONTags hashes struct fields under the names of their `json` tags, skips fields tagged
+	// `json:"-"` and honors the omitempty and omitzero options of the tags, so hashes follow
+	// the JSON representation of structs. The directives of `datahash` tags take precedence.
+	JSONTags bool
+
+	// ExplicitOnly skips all struct fields that are not tagged `datahash:"include"`, so that new
+	// fields of structs whose identity is defined by a few fields do not change their hash.
+	// It applies to the structs of all packages, which hash as empty without such tags.
+	ExplicitOnly bool
+
+	// Exclude lists struct fields to skip by type name, for types whose tags cannot be changed.
+	// Keys are either fully qualified ("github.com/org/pkg.Type") or as formatted by
+	// reflect.Type.String ("pkg.Type"); values are Go field names. It decodes directly
+	// from configuration such as {"pkg.Type": ["CreatedAt", "Revision"]}.
+	Exclude map[string][]string
+
+	// MaxSeqElements limits the total number of elements consumed from iter.Seq and iter.Seq2
+	// values, including nested ones, during a single Hash call. Exceeding it returns ErrSeqLimit,
+	// so infinite or self-nesting iterators cannot hang the Hasher. Zero means no limit.
+	MaxSeqElements int
+
+	// MaxDepth limits the nesting depth of pointers, interfaces, structs, arrays, slices, maps and
+	// iterators during a single Hash call, so deeply nested data decoded from untrusted input fails
+	// fast with ErrDepthLimit instead of being traversed. The top-level value has depth 1.
+	// Zero means no limit.
+	MaxDepth int
+
+	// NoCycleDetection disables the tracking of visited pointers for data known to be acyclic, such
+	// as decoded JSON or database rows, so pointers are hashed without bookkeeping. Shared pointers
+	// are then hashed by their content each time. Cyclic data recurses without end, unless MaxDepth
+	// limits it.
+	NoCycleDetection bool
+
+	// Memo, if set, caches the digests of structs behind pointers across Hash calls. Such
+	// pointers are hashed by the digest of their struct instead of its content, so hashes differ
+	// from those of Hashers without Memo. See Memo for the invalidation of stale digests.
+	Memo *Memo
+
+	// MaxElements limits the total number of elements of slices, arrays, maps and iterators,
+	// including nested ones, consumed during a single Hash call, so user-supplied collections of
+	// excessive size cannot exhaust the Hasher. Exceeding it returns ErrElementLimit, unless
+	// TruncateElements is set. Zero means no limit.
+	MaxElements int
+
+	// TruncateElements hashes the elements within MaxElements followed by a truncation marker
+	// instead of returning ErrElementLimit. Values that only differ beyond the limit hash equally.
+	// Maps are iterated in random order, so the hashes of truncated maps are not deterministic.
+	TruncateElements bool
+
+	// ChunkSize, if positive, hashes byte slices longer than ChunkSize as the list of the digests
+	// of their ChunkSize-byte blocks, Merkle-style, instead of as one run of bytes. Hasher.Chunks
+	// keeps the block digests of large data, so its hash can be updated by re-hashing only the
+	// blocks of a changed region. As in RFC 6962, blocks and shorter byte slices are tagged as
+	// leaves and the block lists as nodes with the total length, so no byte slice hashes like
+	// the block list of another.
+	ChunkSize int
+
+	// StrictKinds returns a *KindError when a type of a kind without explicit handling is compiled,
+	// e.g. channels, uintptr, unsafe pointers or kinds added by future Go versions, instead of
+	// detecting iter.Seq support. Functions are still accepted as iter.Seq and iter.Seq2.
+	StrictKinds bool
+
+	// Funcs selects how func values are hashed that are neither iter.Seq nor iter.Seq2, e.g.
+	// callbacks in structs: ModeDefault fails with an unsupported type error,
+	// ModeIdentity hashes them by the address of their code, so all closures of a function literal
+	// hash alike, and ModeSkip ignores them. Func fields are dropped entirely in ModeSkip.
+	Funcs Mode
+
+	// Chans selects how channel values are hashed, like Funcs: ModeDefault consumes them like
+	// iter.Seq, draining them, ModeIdentity hashes them by the address of the channel without
+	// receiving from it, and ModeSkip ignores them.
+	Chans Mode
+
+	// NilMarker writes a marker byte for nil pointers and nil interfaces instead of nothing, so that
+	// nil values are distinguishable from absent ones, e.g. in unordered structs. ZeroNil takes
+	// precedence for pointers.
+	NilMarker bool
+
+	// DistinguishNil writes a marker byte for nil slices and maps, so that they hash differently
+	// from empty ones, e.g. when the hash reflects JSON semantics (null vs []). IgnoreZero still
+	// skips them.
+	DistinguishNil bool
+
+	// NormalizeFloats hashes all NaN bit patterns of floats and complex numbers as a single NaN
+	// and -0.0 like 0.0, so values that compare as equal by their meaning hash equally.
+	NormalizeFloats bool
+
+	// StrongUnordered folds the sub-hashes of unordered collections by adding their mixed values
+	// instead of XOR, which keeps multiplicity: with XOR, equal elements cancel out, so {a, a, b}
+	// hashes like {b} and {a, a} like {}. It is equivalent to Combiner: NewAddCombiner.
+	StrongUnordered bool
+
+	// SortedUnordered hashes unordered collections, including maps, by writing the sorted digests
+	// of their elements to the stream instead of folding them with the Combiner. Like folding, it is
+	// order-independent, but elements cannot cancel out or offset each other algebraically, and
+	// the stream is easy to reproduce. It overrides Combiner and StrongUnordered.
+	SortedUnordered bool
+
+	// SortedMaps hashes maps as lists of their entries sorted by the digests of their keys, instead
+	// of folding the digests of their entries with the Combiner. It is slower, but the entries are
+	// part of the stream, so the hash is as collision resistant as the hash of an ordered list.
+	SortedMaps bool
+
+	// Combiner, if set, creates the Combiner that folds the sub-hashes of each unordered
+	// collection, overriding StrongUnordered.
+	Combiner func() Combiner
+
+	// NormalizeTime hashes time.Time values as instants in UTC instead of by MarshalBinary, so that
+	// equal instants hash equally regardless of their location and monotonic clock reading.
+	NormalizeTime bool
+
+	// TimeLocation additionally hashes the location name of time.Time values in NormalizeTime mode.
+	TimeLocation bool
+
+	// TruncateTime truncates time.Time values to a multiple of the duration before hashing, e.g.
+	// time.Second, so timestamps that only differ in sub-second noise hash equally. The struct tag
+	// directive trunc=<duration> sets it for a single field. Truncation also strips monotonic readings.
+	TruncateTime time.Duration
+
+	// NormalizeNumbers hashes numbers of all kinds by their value, so that int8(5), int64(5), uint(5)
+	// and 5.0 hash equally, e.g. in any fields of configuration or JSON-like data. Integral values
+	// in the range of int64 hash as integers, larger unsigned integers as unsigned, other floats
+	// as floats and complex numbers with an imaginary part as complex. It implies NormalizeFloats.
+	NormalizeNumbers bool
+
+	// FoldCase hashes strings case-insensitively, so that strings equal under strings.EqualFold
+	// hash equally, e.g. HTTP header names, hostnames and identifiers. The struct tag directive
+	// fold sets it for a single field. It applies to string kinds, including map keys, but not to
+	// the output of marshalers.
+	FoldCase bool
+
+	// NormalizeUnicode normalizes strings to the Unicode normalization form UnicodeForm before
+	// hashing, so that visually identical strings with different compositions, e.g. "é" as one
+	// code point or as "e" with a combining accent, hash equally. It applies to string kinds,
+	// including map keys, but not to the output of marshalers.
+	NormalizeUnicode bool
+
+	// UnicodeForm is the normalization form of NormalizeUnicode: norm.NFC (the zero value), norm.NFD,
+	// or norm.NFKC and norm.NFKD, which also fold compatibility characters such as ligatures and full
+	// width forms. New panics for other forms.
+	UnicodeForm norm.Form
+
+	// NormalizeJSON hashes json.RawMessage values by their RFC 8785 canonical serialization instead
+	// of their bytes, so that semantically equal JSON texts, e.g. {"a":1,"b":2} and { "b": 2.0, "a": 1 },
+	// hash equally. Invalid JSON fails to hash.
+	NormalizeJSON bool
+
+	// Gob hashes types implementing gob.GobEncoder by GobEncode if no other marshaler applies, and
+	// types that are unsupported otherwise, e.g. structs with func fields, by their encoding/gob
+	// encoding instead of failing. Gob encodes maps in iteration order, so the hashes of such types
+	// containing maps with more than one entry are not deterministic.
+	Gob bool
+
+	// Errors hashes types implementing error by their Error string, including error interface
+	// fields, so that errors hash by their message instead of the internals of their concrete
+	// types. Only HashEncoder, HashWriterTo,
+	// HashWriter and HashMethod take precedence.
+	Errors bool
+
+	// ErrorChain additionally hashes the Error strings of the errors wrapped by errors in Errors
+	// mode, following Unwrap() error and Unwrap() []error, so that errors with equal messages
+	// but different causes hash differently.
+	ErrorChain bool
+
+	// HashMethod, if set, names a method with the signature func() uint64, e.g. "Hash64" or "Hash",
+	// whose result is hashed instead of the content of the types that have it, so precomputed
+	// hashes are incorporated directly. Only HashEncoder, HashWriterTo and HashWriter
+	// take precedence.
+	HashMethod string
+
+	// TypeAware mixes the identity of the concrete type into the stream of the top-level value and of
+	// every interface value, so that values of different types, such as int(42) and uint64(42) or
+	// structs of the same shape, never hash equally. Named types are identified by their package
+	// path and name, all other types by their description, e.g. "[]int".
+	TypeAware bool
+
+	// Format selects the version of the canonical stream format; the zero value selects FormatV1.
+	// FormatV2 hashes revisited pointers by back-references instead of skipping them.
+	// Set it explicitly when hashes are persisted. New panics for unknown formats.
+	Format Format
+
+	// Header prefixes every stream with a header holding the format version and a fingerprint
+	// of the Options (see Hasher.Header), so persisted digests and encodings are self-describing.
+	Header bool
+
+	// StrictSchema rejects values of interface fields, elements and keys whose dynamic
+	// types were not registered with Hasher.Allow, returning ErrNotAllowed. Cache keys then
+	// only ever derive from a reviewed, closed set of types.
+	StrictSchema bool
+
+	// GoSyntax enables structural hashing of go/ast nodes and go/types values:
+	// position information and legacy object resolution fields are skipped, and
+	// go/types objects, types, packages and scopes are hashed by their descriptions.
+	GoSyntax bool
+}
+
+// FieldOptions declares hashing preferences for a single type.
+// Enabled options are added to the Options of the Hasher; they cannot disable them.
+type FieldOptions struct {
+	Unordered  bool // Hash structs, arrays, slices, iter.Seq and iter.Seq2 as unordered sets.
+	Text       bool // Use encoding.TextMarshaler if available.
+	JSON       bool // Use json.Marshaler if available.
+	String     bool // Use fmt.Stringer if available.
+	XML        bool // Use xml.Marshaler if available.
+	IgnoreZero bool // Skip zero values.
+	Keys       bool // Hash maps as the set of their keys, e.g. for membership. It takes precedence over Values.
+	Values     bool // Hash maps as the multiset of their values.
+}
+
+// Optioner can be implemented by types that want to keep their hashing
+// preferences next to the type instead of in the Options of the Hasher.
+//
+// DatahashOptions is called once on the zero value when the type is first hashed.
+// The returned FieldOptions apply to the type itself, not to the types it contains.
+type Optioner interface {
+	DatahashOptions() FieldOptions
 }
 
 // New creates a new Hasher that uses the given hash.Hash64 constructor and Options.
@@ -100,18 +382,183 @@ type Options struct {
 //	fnvHasher := datahash.New(fnv.New64a, datahash.Options{})
 //	xxhHasher := datahash.New(xxhash.New, datahash.Options{})
 func New[H hash.Hash64](init func() H, opts Options) *Hasher {
-	return &Hasher{
+	return newHasher(func() hash.Hash64 { return init() }, opts)
+}
+
+// NewMaphash creates a new Hasher that uses hash/maphash with the given seed. Hashes are fast and
+// randomized per seed, which suits in-memory hash tables; they must not be persisted or shared
+// between processes unless the seed is.
+//
+// Example:
+//
+//	hasher := datahash.NewMaphash(maphash.MakeSeed(), datahash.Options{})
+func NewMaphash(seed maphash.Seed, opts Options) *Hasher {
+	return newHasher(func() hash.Hash64 {
+		var h maphash.Hash
+
+		h.SetSeed(seed)
+
+		return &h
+	}, opts)
+}
+
+// NewDigest creates a new Hasher that feeds the canonical stream into a hash.Hash with
+// an arbitrary output size, e.g. sha256.New. Use HashBytes to obtain the full digest;
+// Hash returns its first 8 bytes.
+//
+// In FormatV2 and later, maps, sets and unordered collections write the full digests of their
+// entries in sorted order, unless Options.Combiner is set without SortedUnordered. FormatV1
+// folds them from 64-bit sub-hashes, so collisions of digests of such values take at most
+// about 2^32 attempts, and far fewer for XOR-folded sets whose elements an attacker chooses.
+// Memo and ChunkSize substitute 64-bit sub-hashes in every format.
+//
+// Example:
+//
+//	shaHasher := datahash.NewDigest(sha256.New, datahash.Options{Format: datahash.FormatV2})
+func NewDigest[H hash.Hash](init func() H, opts Options) *Hasher {
+	h := newHasher(func() hash.Hash64 { return &digest64{Hash: init()} }, opts)
+	h.wide = h.opts.Format >= FormatV2
+
+	return h
+}
+
+// NewSum creates a SumHasher that feeds the canonical stream into a hash.Hash, e.g. sha256.New
+// or a BLAKE3 constructor, and returns the full digest.
+func NewSum[H hash.Hash](init func() H, opts Options) *SumHasher {
+	return &SumHasher{hasher: NewDigest(init, opts)}
+}
+
+// SumHasher hashes values into full digests of a hash.Hash.
+type SumHasher struct {
+	hasher *Hasher
+}
+
+// Hash computes the digest of the given value, as returned by Sum(nil) of the hash function.
+func (s *SumHasher) Hash(value any) ([]byte, error) {
+	return s.hasher.HashBytes(value)
+}
+
+// Hasher returns the underlying Hasher, e.g. for ExplainType or FuncFor.
+func (s *SumHasher) Hasher() *Hasher {
+	return s.hasher
+}
+
+// New32 creates a Hasher32 that feeds the canonical stream into a hash.Hash32 such as crc32.NewIEEE
+// or fnv.New32a. Unordered collections fold 32-bit sub-hashes.
+func New32[H hash.Hash32](init func() H, opts Options) *Hasher32 {
+	return &Hasher32{hasher: newHasher(func() hash.Hash64 { return hash32{Hash32: init()} }, opts)}
+}
+
+// Hasher32 hashes values into 32-bit hashes.
+type Hasher32 struct {
+	hasher *Hasher
+}
+
+// Hash computes the 32-bit hash of the given value.
+func (h *Hasher32) Hash(value any) (uint32, error) {
+	result, err := h.hasher.Hash(value)
+
+	return uint32(result), err //nolint:gosec
+}
+
+// Hasher returns the underlying Hasher, e.g. to precompile types or explain streams.
+func (h *Hasher32) Hasher() *Hasher {
+	return h.hasher
+}
+
+// hash32 adapts a hash.Hash32 to hash.Hash64.
+type hash32 struct {
+	hash.Hash32
+}
+
+func (h hash32) Sum64() uint64 {
+	return uint64(h.Sum32())
+}
+
+// NewHMAC creates a keyed Hasher that wraps the canonical stream in an HMAC with the given key,
+// e.g. NewHMAC(sha256.New, key, opts), so hashes exposed to untrusted clients cannot be forged
+// without the key. Use HashBytes for the full MAC and compare MACs with hmac.Equal. Sub-hashes
+// are combined as described for NewDigest, so use FormatV2 or later for the full MAC strength
+// on values with maps or unordered collections.
+func NewHMAC[H hash.Hash](init func() H, key []byte, opts Options) *Hasher {
+	key = bytes.Clone(key)
+
+	return NewDigest(func() hash.Hash {
+		return hmac.New(func() hash.Hash { return init() }, key)
+	}, opts)
+}
+
+// digest64 adapts a hash.Hash to hash.Hash64 by its first 8 digest bytes.
+type digest64 struct {
+	hash.Hash
+	buf []byte
+}
+
+func (d *digest64) Sum64() uint64 {
+	d.buf = d.Sum(d.buf[:0])
+
+	if len(d.buf) < 8 {
+		var b [8]byte
+
+		copy(b[8-len(d.buf):], d.buf)
+
+		return binary.BigEndian.Uint64(b[:])
+	}
+
+	return binary.BigEndian.Uint64(d.buf)
+}
+
+func newHasher(init func() hash.Hash64, opts Options) *Hasher {
+	opts.Format = resolveFormat(opts.Format)
+
+	if opts.NormalizeUnicode {
+		unicodeFormName(opts.UnicodeForm)
+	}
+
+	h := &Hasher{
 		opts: opts,
+		cfg: config{
+			unorderedStruct: opts.UnorderedStruct,
+			unorderedArray:  opts.UnorderedArray,
+			unorderedSlice:  opts.UnorderedSlice,
+			unorderedSeq:    opts.UnorderedSeq,
+			unorderedSeq2:   opts.UnorderedSeq2,
+			text:            opts.Text,
+			json:            opts.JSON,
+			str:             opts.String,
+			xml:             opts.XML,
+			zeroNil:         opts.ZeroNil,
+			ignoreZero:      opts.IgnoreZero,
+			truncate:        opts.TruncateTime,
+			foldCase:        opts.FoldCase,
+		},
 		containerPool: &sync.Pool{
 			New: func() any {
-				return &container{
-					hash:    init(),
-					visited: []uintptr{},
+				c := &container{
+					hash: init(),
 				}
+
+				c.st = &c.own
+
+				return c
 			},
 		},
-		hashFuncMap: &sync.Map{},
+		hashFuncMap:     &sync.Map{},
+		typeInfoMap:     &sync.Map{},
+		structFieldsMap: &sync.Map{},
+		compileMu:       &sync.Mutex{},
+		pending:         map[cacheKey]*atomic.Pointer[hashFunc]{},
+		allowed:         &sync.Map{},
+		custom:          &sync.Map{},
+		scalars: !opts.TypeAware && !opts.NormalizeNumbers && !opts.FoldCase && !opts.NormalizeUnicode &&
+			opts.StringTransform == nil,
+	}
+
+	if opts.Header {
+		h.header, _ = h.Header().AppendBinary(nil)
 	}
+
+	return h
 }
 
 // Hasher hashes arbitrary Go values consistently according to configurable Options.
@@ -119,9 +566,21 @@ func New[H hash.Hash64](init func() H, opts Options) *Hasher {
 // It caches reflection logic internally for performance, is safe for concurrent use,
 // and supports integration with marshaling interfaces (BinaryMarshaler, TextMarshaler, etc.).
 type Hasher struct {
-	opts          Options
-	containerPool *sync.Pool // Pool of *container.
-	hashFuncMap   *sync.Map  // Map with key reflect.Type and value hashFunc
+	opts            Options
+	cfg             config
+	containerPool   *sync.Pool // Pool of *container.
+	hashFuncMap     *sync.Map  // Map with key cacheKey and value hashFunc
+	typeInfoMap     *sync.Map  // Map with key cacheKey and value *TypeInfo
+	structFieldsMap *sync.Map  // Map with key cacheKey and value []structField of structs
+	compileMu       *sync.Mutex
+	scalars         bool                                   // Whether hashScalar applies: no options affect scalars and no hash functions are registered.
+	pending         map[cacheKey]*atomic.Pointer[hashFunc] // Types being compiled, guarded by compileMu.
+	allowed         *sync.Map                              // Set of reflect.Type allowed as dynamic types in StrictSchema mode
+	header          []byte                                 // Encoded stream header if Options.Header is set.
+	custom          *sync.Map                              // Map with key reflect.Type and value hashFunc of registered hash functions
+	implementers    []implementer                          // Hash functions registered for interfaces, guarded by compileMu.
+	structural      map[reflect.Type]struct{}              // Types registered with Structural, guarded by compileMu.
+	wide            bool                                   // Whether unordered collections fold full sub-digests, see NewDigest.
 }
 
 // Hash computes a 64-bit hash of the given value.
@@ -132,46 +591,562 @@ type Hasher struct {
 //
 // Returns the computed hash or an error if hashing fails.
 func (h *Hasher) Hash(value any) (uint64, error) {
-	c := h.containerPool.Get().(*container)
-	c.Reset()
-
-	v := reflect.ValueOf(value)
+	if h.opts.Format >= formatInterop {
+		c, err := h.hashInterop(value)
 
-	if !v.IsValid() {
 		result := c.hash.Sum64()
 
 		h.containerPool.Put(c)
 
-		return result, nil
+		return result, err
 	}
 
-	hf, err := h.makeHashFunc(v.Type())
+	if h.opts.Format >= formatCompat {
+		return h.hashCompat(value)
+	}
+
+	c := h.acquire()
+
+	err := h.hash(value, c)
+
+	result := c.hash.Sum64()
+
+	h.containerPool.Put(c)
+
+	return result, err
+}
+
+// MustHash is like Hash but panics if hashing fails. It simplifies initialization code
+// and tests where an error is impossible.
+func (h *Hasher) MustHash(value any) uint64 {
+	result, err := h.Hash(value)
 	if err != nil {
-		result := c.hash.Sum64()
+		panic(err)
+	}
+
+	return result
+}
+
+// HashBytes computes the full digest of the given value, e.g. 32 bytes for a Hasher
+// created with NewDigest(sha256.New, ...). Use it instead of Hash for wide digests.
+func (h *Hasher) HashBytes(value any) ([]byte, error) {
+	if h.opts.Format >= formatInterop {
+		c, err := h.hashInterop(value)
+
+		result := c.hash.Sum(nil)
 
 		h.containerPool.Put(c)
 
 		return result, err
 	}
 
-	err = hf(v, c)
-	if err != nil {
-		result := c.hash.Sum64()
+	if h.opts.Format >= formatCompat {
+		result, err := h.hashCompat(value)
 
-		h.containerPool.Put(c)
+		return binary.BigEndian.AppendUint64(nil, result), err
+	}
 
-		return result, err
+	c := h.acquire()
+
+	err := h.hash(value, c)
+
+	result := c.hash.Sum(nil)
+
+	h.containerPool.Put(c)
+
+	return result, err
+}
+
+// Encode writes the canonical byte stream of value to w instead of hashing it, including the
+// header if Options.Header is set. Hashing the stream with the init function of the Hasher
+// yields the result of Hash. Unordered collections contribute their folded sub-hashes.
+// In interoperable formats such as FormatJCS, it writes the serialization of value, and it fails
+// in compatibility formats.
+func (h *Hasher) Encode(value any, w io.Writer) error {
+	if h.opts.Format >= formatInterop {
+		b, err := h.encodeInterop(value)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(b)
+
+		return err
 	}
 
-	result := c.hash.Sum64()
+	if err := h.checkStream("Encode"); err != nil {
+		return err
+	}
+
+	if h.header != nil {
+		if _, err := w.Write(h.header); err != nil {
+			return err
+		}
+	}
+
+	c := h.acquire()
+
+	out := &writerHash{w: w}
+
+	hash := c.hash
+	c.hash = out
+
+	err := h.hash(value, c)
+
+	c.hash = hash
 
 	h.containerPool.Put(c)
 
-	return result, nil
+	return err
+}
+
+// writerHash is a hash.Hash64 that forwards the written bytes to an io.Writer.
+type writerHash struct {
+	w io.Writer
+}
+
+func (o *writerHash) Write(p []byte) (int, error) { return o.w.Write(p) }
+func (o *writerHash) Sum(in []byte) []byte        { return in }
+func (o *writerHash) Sum64() uint64               { return 0 }
+func (o *writerHash) Reset()                      {}
+func (o *writerHash) Size() int                   { return 8 }
+func (o *writerHash) BlockSize() int              { return 1 }
+
+// hash writes value into the top-level container c.
+func (h *Hasher) hash(value any, c *container) error {
+	if h.scalars {
+		if ok, err := h.hashScalar(value, c); ok {
+			return err
+		}
+	}
+
+	v := reflect.ValueOf(value)
+
+	if !v.IsValid() {
+		if h.opts.NilMarker {
+			return c.write(byteNil[:])
+		}
+
+		return nil
+	}
+
+	hf, err := h.makeHashFunc(v.Type(), h.cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := h.writeType(v.Type(), c); err != nil {
+		return err
+	}
+
+	return hf(v, c)
+}
+
+// hashScalar writes values of the predeclared scalar types without reflection and the type cache,
+// exactly like their compiled hash functions. It reports false for all other values.
+func (h *Hasher) hashScalar(value any, c *container) (bool, error) {
+	switch v := value.(type) {
+	case string:
+		return true, c.write(stringToBytes(v))
+	case int:
+		return true, c.writeUint64(uint64(v)) //nolint:gosec
+	case int64:
+		return true, c.writeUint64(uint64(v)) //nolint:gosec
+	case int32:
+		return true, c.writeUint64(uint64(v)) //nolint:gosec
+	case uint:
+		return true, c.writeUint64(uint64(v))
+	case uint64:
+		return true, c.writeUint64(v)
+	case uint32:
+		return true, c.writeUint64(uint64(v))
+	case float64:
+		if h.opts.NormalizeFloats {
+			v = normalizeFloat(v)
+		}
+
+		return true, c.writeFloat64(v)
+	case bool:
+		if v {
+			return true, c.write(byteTrue[:])
+		}
+
+		return true, c.write(byteFalse[:])
+	}
+
+	return false, nil
+}
+
+// typeIDs caches the identities written in TypeAware mode.
+var typeIDs sync.Map // map[reflect.Type][]byte
+
+// writeType writes the identity of t, followed by a colon, in TypeAware mode.
+func (h *Hasher) writeType(t reflect.Type, c *container) error {
+	if !h.opts.TypeAware {
+		return nil
+	}
+
+	id, ok := typeIDs.Load(t)
+	if !ok {
+		name := t.String()
+
+		if t.Name() != "" && t.PkgPath() != "" {
+			name = t.PkgPath() + "." + t.Name()
+		}
+
+		id, _ = typeIDs.LoadOrStore(t, append([]byte(name), colon[:]...))
+	}
+
+	return c.write(id.([]byte))
+}
+
+// ErrNotAllowed is returned in StrictSchema mode for dynamic types that were not registered with Hasher.Allow.
+var ErrNotAllowed = errors.New("datahash: dynamic type not allowed in strict schema mode")
+
+// Allow registers the types of the given values as allowed dynamic types in StrictSchema mode.
+// A reflect.Type is registered as the type itself. The types are compiled immediately,
+// so unsupported types are reported here instead of on first use.
+func (h *Hasher) Allow(values ...any) error {
+	for _, v := range values {
+		t, ok := v.(reflect.Type)
+		if !ok {
+			t = reflect.TypeOf(v)
+		}
+
+		if t == nil {
+			continue
+		}
+
+		if _, err := h.makeHashFunc(t, h.cfg); err != nil {
+			return err
+		}
+
+		h.allowed.Store(t, struct{}{})
+	}
+
+	return nil
+}
+
+// Precompile compiles the hash functions of the types of the given values, so that services can
+// build them at startup and fail fast on unsupported types instead of on the first request.
+// A reflect.Type is compiled as the type itself. The errors of all unsupported types are joined.
+func (h *Hasher) Precompile(values ...any) error {
+	var errs []error
+
+	for _, v := range values {
+		t, ok := v.(reflect.Type)
+		if !ok {
+			t = reflect.TypeOf(v)
+		}
+
+		if t == nil {
+			continue
+		}
+
+		if _, err := h.makeHashFunc(t, h.cfg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *Hasher) isAllowed(t reflect.Type) bool {
+	_, ok := h.allowed.Load(t)
+
+	return ok
 }
 
 type hashFunc func(value reflect.Value, c *container) error
 
+// config holds the options that affect how a single type is compiled.
+type config struct {
+	unorderedStruct, unorderedArray, unorderedSlice, unorderedSeq, unorderedSeq2 bool
+	text, json, str, xml                                                         bool
+	zeroNil                                                                      bool
+	ignoreZero                                                                   bool
+	prefer                                                                       Strategy      // Marshaler forced by a struct tag or Options.MarshalFallback.
+	truncate                                                                     time.Duration // Truncation of time.Time values.
+	foldCase                                                                     bool          // Case folding of strings.
+	mapKeys, mapValues                                                           bool          // Whether maps hash only their keys or values.
+	raw                                                                          bool          // Whether strings and bytes are written as is.
+}
+
+func (cfg config) with(fo FieldOptions) config {
+	if fo.Unordered {
+		cfg.unorderedStruct = true
+		cfg.unorderedArray = true
+		cfg.unorderedSlice = true
+		cfg.unorderedSeq = true
+		cfg.unorderedSeq2 = true
+	}
+
+	cfg.text = cfg.text || fo.Text
+	cfg.json = cfg.json || fo.JSON
+	cfg.str = cfg.str || fo.String
+	cfg.xml = cfg.xml || fo.XML
+	cfg.ignoreZero = cfg.ignoreZero || fo.IgnoreZero
+	cfg.mapKeys = cfg.mapKeys || fo.Keys
+	cfg.mapValues = cfg.mapValues || fo.Values
+
+	return cfg
+}
+
+type cacheKey struct {
+	typ reflect.Type
+	cfg config
+}
+
+// typeOptions returns the FieldOptions declared by t, or by *t, via Optioner.
+func typeOptions(t reflect.Type) FieldOptions {
+	switch {
+	case t.Kind() == reflect.Interface || t.Kind() == reflect.Pointer:
+		return FieldOptions{}
+	case t.Implements(optionerType):
+		return reflect.Zero(t).Interface().(Optioner).DatahashOptions()
+	case reflect.PointerTo(t).Implements(optionerType):
+		return reflect.New(t).Interface().(Optioner).DatahashOptions()
+	}
+
+	return FieldOptions{}
+}
+
+// acquire returns a pooled top-level container for a new Hash call.
+func (h *Hasher) acquire() *container {
+	c := h.containerPool.Get().(*container)
+	c.Reset()
+	c.st = &c.own
+	c.own.reset(h.opts.WarnFunc != nil)
+
+	if h.header != nil {
+		_, _ = c.hash.Write(h.header)
+	}
+
+	return c
+}
+
+// sub returns a pooled container for hashing a set element of c.
+// It shares the state of c, so paths and warnings stay consistent.
+func (h *Hasher) sub(c *container) *container {
+	tmp := h.containerPool.Get().(*container)
+	tmp.st = c.st
+
+	return tmp
+}
+
+// ErrSeqLimit is returned when hashing consumes more iterator elements than Options.MaxSeqElements.
+var ErrSeqLimit = errors.New("datahash: iterator element limit exceeded")
+
+// ErrElementLimit is returned when hashing consumes more collection elements than Options.MaxElements.
+var ErrElementLimit = errors.New("datahash: element limit exceeded")
+
+// ErrDepthLimit is returned when hashing nests deeper than Options.MaxDepth.
+var ErrDepthLimit = errors.New("datahash: depth limit exceeded")
+
+// ErrUnsupportedType is wrapped by the errors of types that have neither a supported kind nor a
+// hashing or marshaling interface, e.g. structs with func fields. Options.Gob only applies to them.
+var ErrUnsupportedType = errors.New("datahash: unsupported type")
+
+// KindError is returned in StrictKinds mode for types of a kind without explicit handling.
+type KindError struct {
+	Kind reflect.Kind
+	Type reflect.Type
+	Path string // Location of the type within the compiled type, e.g. "Config.Events[]".
+}
+
+func (e *KindError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("datahash: unsupported kind %s of type %s", e.Kind, e.Type)
+	}
+
+	return fmt.Sprintf("datahash: unsupported kind %s of type %s at %s", e.Kind, e.Type, e.Path)
+}
+
+// MarshalerError is returned when a custom hashing method of a type fails,
+// e.g. MarshalBinary, MarshalText, WriteHash or a registered hash function.
+type MarshalerError struct {
+	Type     reflect.Type
+	Strategy Strategy
+	Err      error
+}
+
+func (e *MarshalerError) Error() string {
+	return fmt.Sprintf("datahash: %s of type %s failed: %v", e.Strategy, e.Type, e.Err)
+}
+
+func (e *MarshalerError) Unwrap() error {
+	return e.Err
+}
+
+func marshalerErr(t reflect.Type, s Strategy, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &MarshalerError{Type: t, Strategy: s, Err: err}
+}
+
+// PathError records the location of the value that failed to hash, e.g. "Order.Items[3].Meta".
+//
+// Paths are built while the error unwinds, so tracking them costs nothing for successful calls.
+// Errors of types that could not be compiled carry the path within the type instead,
+// with "[]" for elements and "[key]" for map keys.
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	return fmt.Sprintf("%s (at %s)", e.Err, e.Path)
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// prefixPath prepends elem to the path of err while the error unwinds through the containing
+// values or types. A *KindError keeps its own path, all other errors are wrapped in a *PathError.
+func prefixPath(err error, elem string) error {
+	var (
+		ke *KindError
+		pe *PathError
+	)
+
+	switch {
+	case errors.As(err, &ke):
+		ke.Path = joinPath(elem, ke.Path)
+
+		return err
+	case errors.As(err, &pe):
+		pe.Path = joinPath(elem, pe.Path)
+
+		return err
+	}
+
+	return &PathError{Path: elem, Err: err}
+}
+
+func joinPath(elem, path string) string {
+	switch {
+	case path == "":
+		return elem
+	case path[0] == '[':
+		return elem + path
+	default:
+		return elem + "." + path
+	}
+}
+
+func fieldErr(err error, name []byte) error {
+	return prefixPath(err, string(name))
+}
+
+func indexErr(err error, i int) error {
+	return prefixPath(err, "["+strconv.Itoa(i)+"]")
+}
+
+func keyErr(err error, key reflect.Value) error {
+	return prefixPath(err, fmt.Sprintf("[%v]", key))
+}
+
+// countSeqElement counts an element consumed from an iter.Seq or iter.Seq2 against Options.MaxSeqElements.
+func (h *Hasher) countSeqElement(c *container) error {
+	if h.opts.MaxSeqElements <= 0 {
+		return nil
+	}
+
+	c.st.seqElements++
+
+	if c.st.seqElements > h.opts.MaxSeqElements {
+		return ErrSeqLimit
+	}
+
+	return nil
+}
+
+// countElement counts an element of a slice, array, map or iterator against Options.MaxElements.
+// Beyond the limit it reports that the collection is truncated in TruncateElements mode and
+// returns ErrElementLimit otherwise.
+func (h *Hasher) countElement(c *container) (truncated bool, err error) {
+	if h.opts.MaxElements <= 0 {
+		return false, nil
+	}
+
+	if c.st.elements >= h.opts.MaxElements {
+		if h.opts.TruncateElements {
+			return true, nil
+		}
+
+		return false, ErrElementLimit
+	}
+
+	c.st.elements++
+
+	return false, nil
+}
+
+// closeSet writes the folded sum of an unordered set, followed by a marker if it was truncated.
+func (h *Hasher) closeSet(fold *folder, truncated bool, c *container) error {
+	err := fold.write(c)
+
+	if truncated {
+		err = twoErr(err, c.write(byteTruncated[:]))
+	}
+
+	return twoErr(err, c.write(endSet[:]))
+}
+
+// limitDepth wraps the hash function of a nesting kind to count its depth against Options.MaxDepth.
+func (h *Hasher) limitDepth(t reflect.Type, hf hashFunc) hashFunc {
+	if h.opts.MaxDepth <= 0 || !isNesting(t.Kind()) {
+		return hf
+	}
+
+	return func(value reflect.Value, c *container) error {
+		if err := h.enter(c); err != nil {
+			return err
+		}
+
+		err := hf(value, c)
+
+		c.st.depth--
+
+		return err
+	}
+}
+
+// enter increments the depth of c, failing with ErrDepthLimit beyond Options.MaxDepth.
+// Callers decrement c.st.depth when they leave the value.
+func (h *Hasher) enter(c *container) error {
+	if c.st.depth >= h.opts.MaxDepth {
+		return ErrDepthLimit
+	}
+
+	c.st.depth++
+
+	return nil
+}
+
+// isNesting reports whether values of kind k can contain other values.
+func isNesting(k reflect.Kind) bool {
+	switch k {
+	case reflect.Interface, reflect.Pointer, reflect.Struct, reflect.Array, reflect.Slice, reflect.Map,
+		reflect.Func, reflect.Chan:
+		return true
+	}
+
+	return false
+}
+
+// warn records that a value of the type described by info was skipped.
+func (h *Hasher) warn(c *container, info *TypeInfo, reason string) {
+	info.skips.Add(1)
+
+	if h.opts.WarnFunc != nil {
+		h.opts.WarnFunc(c.st.String(), reason)
+	}
+}
+
 var (
 	byteFalse = [1]byte{0x00}
 	byteTrue  = [1]byte{0x01}
@@ -181,13 +1156,35 @@ var (
 	endSet    = [1]byte{0x05}
 	startList = [1]byte{0x06}
 	endList   = [1]byte{0x07}
+	byteNil   = [1]byte{0x08}
+
+	byteTruncated = [1]byte{0x09}
+	byteRef       = [1]byte{0x0a}
+	byteLeaf      = [1]byte{0x0b} // Byte slice or block in ChunkSize mode.
+	byteNode      = [1]byte{0x0c} // Block list in ChunkSize mode.
 )
 
-func (h *Hasher) hashUnorderedSliceArray(vhf hashFunc) hashFunc {
+// distinguishNil wraps the hash function of a slice or map type to write a marker for nil values
+// in DistinguishNil mode, unless they are skipped as zero values.
+func (h *Hasher) distinguishNil(hf hashFunc, cfg config) hashFunc {
+	if !h.opts.DistinguishNil || cfg.ignoreZero {
+		return hf
+	}
+
+	return func(value reflect.Value, c *container) error {
+		if value.IsValid() && value.IsNil() {
+			return c.write(byteNil[:])
+		}
+
+		return hf(value, c)
+	}
+}
+
+func (h *Hasher) hashUnorderedSliceArray(vhf hashFunc, cfg config) hashFunc {
 	return func(value reflect.Value, c *container) error {
 		var err error
 
-		if !value.IsValid() || (h.opts.IgnoreZero && isZero(value)) {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
 			return nil
 		}
 
@@ -196,46 +1193,52 @@ func (h *Hasher) hashUnorderedSliceArray(vhf hashFunc) hashFunc {
 		}
 
 		var (
-			result uint64
-			tmp    = h.containerPool.Get().(*container)
+			fold      = h.folder()
+			tmp       = h.sub(c)
+			truncated bool
 		)
 
 		for i := range value.Len() {
+			if truncated, err = h.countElement(c); truncated || err != nil {
+				break
+			}
+
 			tmp.Reset()
 
 			v := value.Index(i)
 
-			if !v.IsValid() || (h.opts.IgnoreZero && isZero(v)) {
+			if !v.IsValid() || (cfg.ignoreZero && isZero(v)) {
 				continue
 			}
 
+			c.enterIndex(i)
+
 			if err = vhf(v, tmp); err != nil {
 				h.containerPool.Put(tmp)
 
-				return err
+				return indexErr(err, i)
 			}
 
-			result ^= tmp.hash.Sum64()
+			c.leave()
+
+			fold.addHash(tmp.hash)
 		}
 
 		h.containerPool.Put(tmp)
 
-		if result == 0 {
-			return c.write(endSet[:])
+		if err != nil {
+			return err
 		}
 
-		return twoErr(
-			c.writeUint64(result),
-			c.write(endSet[:]),
-		)
+		return h.closeSet(&fold, truncated, c)
 	}
 }
 
-func (h *Hasher) hashSliceArray(vhf hashFunc) hashFunc {
+func (h *Hasher) hashSliceArray(vhf hashFunc, cfg config) hashFunc {
 	return func(value reflect.Value, c *container) error {
 		var err error
 
-		if !value.IsValid() || (h.opts.IgnoreZero && isZero(value)) {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
 			return nil
 		}
 
@@ -246,9 +1249,15 @@ func (h *Hasher) hashSliceArray(vhf hashFunc) hashFunc {
 		first := true
 
 		for i := range value.Len() {
+			if truncated, err := h.countElement(c); err != nil {
+				return err
+			} else if truncated {
+				return twoErr(c.write(byteTruncated[:]), c.write(endList[:]))
+			}
+
 			v := value.Index(i)
 
-			if !v.IsValid() || (h.opts.IgnoreZero && isZero(v)) {
+			if !v.IsValid() || (cfg.ignoreZero && isZero(v)) {
 				continue
 			}
 
@@ -260,26 +1269,31 @@ func (h *Hasher) hashSliceArray(vhf hashFunc) hashFunc {
 				first = false
 			}
 
+			c.enterIndex(i)
+
 			if err = vhf(v, c); err != nil {
-				return err
+				return indexErr(err, i)
 			}
+
+			c.leave()
 		}
 
 		return c.write(endList[:])
 	}
 }
 
-func (h *Hasher) hashMap(khf, vhf hashFunc) hashFunc {
+func (h *Hasher) hashMap(khf, vhf hashFunc, cfg config) hashFunc {
 	return func(value reflect.Value, c *container) error {
 		if !value.IsValid() {
 			return nil
 		}
 
 		var (
-			result uint64
-			err    error
-			tmp    = h.containerPool.Get().(*container)
-			iter   = value.MapRange()
+			fold      = h.folder()
+			err       error
+			tmp       = h.sub(c)
+			iter      = value.MapRange()
+			truncated bool
 		)
 
 		if err = c.write(startSet[:]); err != nil {
@@ -287,91 +1301,161 @@ func (h *Hasher) hashMap(khf, vhf hashFunc) hashFunc {
 		}
 
 		for iter.Next() {
+			if truncated, err = h.countElement(c); truncated || err != nil {
+				break
+			}
+
 			tmp.Reset()
 
 			value := iter.Value()
-			if !value.IsValid() || (h.opts.IgnoreZero && isZero(value)) {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
 				continue
 			}
 
-			if err = threeErr(
-				khf(iter.Key(), tmp),
-				tmp.write(colon[:]),
-				vhf(value, tmp),
-			); err != nil {
+			c.enterKey(iter.Key())
+
+			if err = writeEntry(iter.Key(), value, khf, vhf, cfg, tmp); err != nil {
 				h.containerPool.Put(tmp)
 
-				return err
+				return keyErr(err, iter.Key())
 			}
 
-			result ^= tmp.hash.Sum64()
+			c.leave()
+
+			fold.addHash(tmp.hash)
 		}
 
 		h.containerPool.Put(tmp)
 
-		if result == 0 {
-			return c.write(endSet[:])
+		if err != nil {
+			return err
 		}
 
-		return twoErr(
-			c.writeUint64(result),
-			c.write(endSet[:]),
-		)
+		return h.closeSet(&fold, truncated, c)
+	}
+}
+
+// writeEntry writes the key:value pair of a map entry, or only its key or value if cfg selects them.
+func writeEntry(k, v reflect.Value, khf, vhf hashFunc, cfg config, c *container) error {
+	switch {
+	case cfg.mapKeys:
+		return khf(k, c)
+	case cfg.mapValues:
+		return vhf(v, c)
 	}
+
+	return threeErr(khf(k, c), c.write(colon[:]), vhf(v, c))
 }
 
 type structField struct {
-	name []byte
-	hf   hashFunc
-	idx  int
+	name   []byte
+	hf     hashFunc
+	idx    int
+	path   []int  // Index path of fields promoted from embedded structs that are flattened, or nil.
+	cfg    config // Config of the field type.
+	tag    fieldTag
+	offset uintptr
+	read   fieldReader // Reads scalar fields of addressable structs without reflection, if set.
 }
 
-func (h *Hasher) hashStruct(sfs []structField) hashFunc {
-	if h.opts.UnorderedStruct {
-		return func(value reflect.Value, c *container) error {
-			var err error
+// hash hashes the field of the struct value at base, or fv if base is nil or the field has no reader.
+func (sf *structField) hash(fv reflect.Value, base unsafe.Pointer, c *container) error {
+	if base != nil && sf.read != nil {
+		return sf.read(unsafe.Add(base, sf.offset), c)
+	}
 
-			if err = c.write(startSet[:]); err != nil {
+	return sf.hf(fv, c)
+}
+
+// field returns the field of the struct value, or the invalid Value if it is read from base.
+func (sf *structField) field(value reflect.Value, base unsafe.Pointer) reflect.Value {
+	if base != nil && sf.read != nil {
+		return reflect.Value{}
+	}
+
+	return sf.value(value)
+}
+
+// value returns the field of the struct value, or the invalid Value if it is promoted
+// from a nil embedded pointer.
+func (sf *structField) value(value reflect.Value) reflect.Value {
+	if sf.path == nil {
+		return value.Field(sf.idx)
+	}
+
+	fv, err := value.FieldByIndexErr(sf.path)
+	if err != nil {
+		return reflect.Value{}
+	}
+
+	return fv
+}
+
+// skips reports whether the field value fv is skipped: if it is zero in IgnoreZero mode,
+// omitted by its tag, or promoted from a nil embedded pointer.
+func (sf *structField) skips(fv reflect.Value, cfg config) bool {
+	if !fv.IsValid() {
+		return sf.path != nil
+	}
+
+	return cfg.ignoreZero && isZero(fv) || sf.tag.omits(fv)
+}
+
+// fieldSum hashes the name:value pair of a field of an unordered struct into tmp, a sub container
+// of c, whose hash then holds the sub-hash of the field. It reports false if the field is skipped.
+func (h *Hasher) fieldSum(sf *structField, value reflect.Value, base unsafe.Pointer, cfg config, c, tmp *container) (bool, error) {
+	fv := sf.field(value, base)
+
+	if sf.skips(fv, cfg) {
+		return false, nil
+	}
+
+	tmp.Reset()
+
+	c.enterField(sf.name)
+
+	if err := threeErr(
+		tmp.write(sf.name),
+		tmp.write(colon[:]),
+		sf.hash(fv, base, tmp),
+	); err != nil {
+		return false, fieldErr(err, sf.name)
+	}
+
+	c.leave()
+
+	return true, nil
+}
+
+func (h *Hasher) hashStruct(sfs []structField, cfg config) hashFunc {
+	if cfg.unorderedStruct {
+		return func(value reflect.Value, c *container) error {
+			if err := c.write(startSet[:]); err != nil {
 				return err
 			}
 
 			var (
-				tmp    = h.containerPool.Get().(*container)
-				result uint64
+				tmp  = h.sub(c)
+				fold = h.folder()
+				base = structBase(value)
 			)
 
-			for _, sf := range sfs {
-				fv := value.Field(sf.idx)
-
-				if !fv.IsValid() || h.opts.IgnoreZero && isZero(fv) {
-					continue
-				}
-
-				tmp.Reset()
-
-				if err = threeErr(
-					tmp.write(sf.name),
-					tmp.write(colon[:]),
-					sf.hf(fv, tmp),
-				); err != nil {
+			for i := range sfs {
+				ok, err := h.fieldSum(&sfs[i], value, base, cfg, c, tmp)
+				if err != nil {
 					h.containerPool.Put(tmp)
 
 					return err
 				}
 
-				result ^= tmp.hash.Sum64()
+				if ok {
+					fold.addHash(tmp.hash)
+				}
 			}
 
 			h.containerPool.Put(tmp)
 
-			if result == 0 {
-				return c.write(endSet[:])
-			}
-
-			return twoErr(
-				c.writeUint64(result),
-				c.write(endSet[:]),
-			)
+			return twoErr(fold.write(c), c.write(endSet[:]))
 		}
 	}
 
@@ -386,12 +1470,18 @@ func (h *Hasher) hashStruct(sfs []structField) hashFunc {
 			return err
 		}
 
-		first := true
+		var (
+			first   = true
+			base    = structBase(value)
+			skipped int
+		)
 
 		for _, sf := range sfs {
-			fv := value.Field(sf.idx)
+			fv := sf.field(value, base)
+
+			if sf.skips(fv, cfg) {
+				skipped++
 
-			if !fv.IsValid() || h.opts.IgnoreZero && isZero(fv) {
 				continue
 			}
 
@@ -403,23 +1493,40 @@ func (h *Hasher) hashStruct(sfs []structField) hashFunc {
 				first = false
 			}
 
-			if err = threeErr(
-				c.write(sf.name),
-				c.write(colon[:]),
-				sf.hf(fv, c),
-			); err != nil {
-				return err
+			c.enterField(sf.name)
+
+			if h.opts.Positional {
+				// Skipped fields leave empty positions, unless they are trailing.
+				for ; skipped > 0; skipped-- {
+					if err := c.write(comma[:]); err != nil {
+						return err
+					}
+				}
+
+				err = sf.hash(fv, base, c)
+			} else {
+				err = threeErr(
+					c.write(sf.name),
+					c.write(colon[:]),
+					sf.hash(fv, base, c),
+				)
+			}
+
+			if err != nil {
+				return fieldErr(err, sf.name)
 			}
+
+			c.leave()
 		}
 
 		return c.write(endList[:])
 	}
 }
 
-func (h *Hasher) hashSeq2() hashFunc {
-	if h.opts.UnorderedSeq2 {
+func (h *Hasher) hashSeq2(cfg config) hashFunc {
+	if cfg.unorderedSeq2 {
 		return func(value reflect.Value, c *container) error {
-			if !value.IsValid() || value.IsNil() || (h.opts.IgnoreZero && isZero(value)) {
+			if !value.IsValid() || value.IsNil() || (cfg.ignoreZero && isZero(value)) {
 				return nil
 			}
 
@@ -433,33 +1540,48 @@ func (h *Hasher) hashSeq2() hashFunc {
 			}
 
 			var (
-				result uint64
-				tmp    = h.containerPool.Get().(*container)
+				fold      = h.folder()
+				tmp       = h.sub(c)
+				truncated bool
 			)
 
 			for k, v := range value.Seq2() {
-				if !k.IsValid() || !v.IsValid() || h.opts.IgnoreZero && isZero(v) {
+				if err = h.countSeqElement(c); err != nil {
+					h.containerPool.Put(tmp)
+
+					return err
+				}
+
+				if truncated, err = h.countElement(c); truncated || err != nil {
+					break
+				}
+
+				if !k.IsValid() || !v.IsValid() || cfg.ignoreZero && isZero(v) {
 					continue
 				}
 
 				tmp.Reset()
+				tmp.visited.copyFrom(&c.visited) // Keep cycle detection along the path.
+				tmp.ids.copyFrom(&c.ids)
 
 				if khf == nil || vhf == nil {
-					khf, err = h.makeHashFunc(k.Type())
+					khf, err = h.makeHashFunc(k.Type(), h.cfg)
 					if err != nil {
 						h.containerPool.Put(tmp)
 
-						return err
+						return keyErr(err, k)
 					}
 
-					vhf, err = h.makeHashFunc(v.Type())
+					vhf, err = h.makeHashFunc(v.Type(), h.cfg)
 					if err != nil {
 						h.containerPool.Put(tmp)
 
-						return err
+						return keyErr(err, k)
 					}
 				}
 
+				c.enterKey(k)
+
 				if err = threeErr(
 					khf(k, tmp),
 					tmp.write(colon[:]),
@@ -467,27 +1589,26 @@ func (h *Hasher) hashSeq2() hashFunc {
 				); err != nil {
 					h.containerPool.Put(tmp)
 
-					return err
+					return keyErr(err, k)
 				}
 
-				result ^= tmp.hash.Sum64()
+				c.leave()
+
+				fold.addHash(tmp.hash)
 			}
 
 			h.containerPool.Put(tmp)
 
-			if result == 0 {
-				return c.write(endSet[:])
+			if err != nil {
+				return err
 			}
 
-			return twoErr(
-				c.writeUint64(result),
-				c.write(endSet[:]),
-			)
+			return h.closeSet(&fold, truncated, c)
 		}
 	}
 
 	return func(value reflect.Value, c *container) error {
-		if !value.IsValid() || value.IsNil() || (h.opts.IgnoreZero && isZero(value)) {
+		if !value.IsValid() || value.IsNil() || (cfg.ignoreZero && isZero(value)) {
 			return nil
 		}
 
@@ -501,17 +1622,27 @@ func (h *Hasher) hashSeq2() hashFunc {
 		}
 
 		for k, v := range value.Seq2() {
-			if !k.IsValid() || !v.IsValid() || h.opts.IgnoreZero && isZero(v) {
+			if err = h.countSeqElement(c); err != nil {
+				return err
+			}
+
+			if truncated, err := h.countElement(c); err != nil {
+				return err
+			} else if truncated {
+				return twoErr(c.write(byteTruncated[:]), c.write(endList[:]))
+			}
+
+			if !k.IsValid() || !v.IsValid() || cfg.ignoreZero && isZero(v) {
 				continue
 			}
 
 			if khf == nil || vhf == nil {
-				if khf, err = h.makeHashFunc(k.Type()); err != nil {
-					return err
+				if khf, err = h.makeHashFunc(k.Type(), h.cfg); err != nil {
+					return keyErr(err, k)
 				}
 
-				if vhf, err = h.makeHashFunc(v.Type()); err != nil {
-					return err
+				if vhf, err = h.makeHashFunc(v.Type(), h.cfg); err != nil {
+					return keyErr(err, k)
 				}
 			} else {
 				if err = c.write(comma[:]); err != nil {
@@ -519,29 +1650,34 @@ func (h *Hasher) hashSeq2() hashFunc {
 				}
 			}
 
+			c.enterKey(k)
+
 			if err = threeErr(
 				khf(k, c),
 				c.write(colon[:]),
 				vhf(v, c),
 			); err != nil {
-				return err
+				return keyErr(err, k)
 			}
+
+			c.leave()
 		}
 
 		return c.write(endList[:])
 	}
 }
 
-func (h *Hasher) hashSeq() hashFunc {
-	if h.opts.UnorderedSeq {
+func (h *Hasher) hashSeq(cfg config) hashFunc {
+	if cfg.unorderedSeq {
 		return func(value reflect.Value, c *container) error {
-			if !value.IsValid() || value.IsNil() || (h.opts.IgnoreZero && isZero(value)) {
+			if !value.IsValid() || value.IsNil() || (cfg.ignoreZero && isZero(value)) {
 				return nil
 			}
 
 			var (
 				err error
 				vhf hashFunc
+				i   int
 			)
 
 			if err = c.write(startSet[:]); err != nil {
@@ -549,114 +1685,368 @@ func (h *Hasher) hashSeq() hashFunc {
 			}
 
 			var (
-				result uint64
-				tmp    = h.containerPool.Get().(*container)
+				fold      = h.folder()
+				tmp       = h.sub(c)
+				truncated bool
 			)
 
 			for v := range value.Seq() {
-				if !v.IsValid() || h.opts.IgnoreZero && isZero(v) {
+				i++
+
+				if err = h.countSeqElement(c); err != nil {
+					h.containerPool.Put(tmp)
+
+					return err
+				}
+
+				if truncated, err = h.countElement(c); truncated || err != nil {
+					break
+				}
+
+				if !v.IsValid() || cfg.ignoreZero && isZero(v) {
 					continue
 				}
 
 				if vhf == nil {
-					vhf, err = h.makeHashFunc(v.Type())
+					vhf, err = h.makeHashFunc(v.Type(), h.cfg)
 					if err != nil {
 						h.containerPool.Put(tmp)
 
-						return err
+						return indexErr(err, i-1)
 					}
 				}
 
 				tmp.Reset()
+				tmp.visited.copyFrom(&c.visited) // Keep cycle detection along the path.
+				tmp.ids.copyFrom(&c.ids)
+
+				c.enterIndex(i - 1)
 
 				if err = vhf(v, tmp); err != nil {
 					h.containerPool.Put(tmp)
 
-					return err
+					return indexErr(err, i-1)
 				}
 
-				result ^= tmp.hash.Sum64()
+				c.leave()
+
+				fold.addHash(tmp.hash)
 			}
 
 			h.containerPool.Put(tmp)
 
-			if result == 0 {
-				return c.write(endSet[:])
+			if err != nil {
+				return err
 			}
 
-			return twoErr(
-				c.writeUint64(result),
-				c.write(endSet[:]),
-			)
+			return h.closeSet(&fold, truncated, c)
 		}
 	}
 
 	return func(value reflect.Value, c *container) error {
-		if !value.IsValid() || value.IsNil() || (h.opts.IgnoreZero && isZero(value)) {
+		if !value.IsValid() || value.IsNil() || (cfg.ignoreZero && isZero(value)) {
 			return nil
 		}
 
 		var (
 			err error
 			vhf hashFunc
+			i   int
 		)
 
 		if err = c.write(startList[:]); err != nil {
 			return err
 		}
 
-		for v := range value.Seq() {
-			if !v.IsValid() || h.opts.IgnoreZero && isZero(v) {
-				continue
+		for v := range value.Seq() {
+			i++
+
+			if err = h.countSeqElement(c); err != nil {
+				return err
+			}
+
+			if truncated, err := h.countElement(c); err != nil {
+				return err
+			} else if truncated {
+				return twoErr(c.write(byteTruncated[:]), c.write(endList[:]))
+			}
+
+			if !v.IsValid() || cfg.ignoreZero && isZero(v) {
+				continue
+			}
+
+			if vhf == nil {
+				if vhf, err = h.makeHashFunc(v.Type(), h.cfg); err != nil {
+					return indexErr(err, i-1)
+				}
+			} else {
+				if err = c.write(comma[:]); err != nil {
+					return err
+				}
+			}
+
+			c.enterIndex(i - 1)
+
+			if err = vhf(v, c); err != nil {
+				return indexErr(err, i-1)
+			}
+
+			c.leave()
+		}
+
+		return c.write(endList[:])
+	}
+}
+
+var (
+	hashWriterType      = reflect.TypeFor[HashWriter]()
+	hashWriterToType    = reflect.TypeFor[HashWriterTo]()
+	hashEncoderType     = reflect.TypeFor[HashEncoder]()
+	binaryMarshalerType = reflect.TypeFor[encoding.BinaryMarshaler]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	jsonMarshalerType   = reflect.TypeFor[json.Marshaler]()
+	xmlMarshalerType    = reflect.TypeFor[xml.Marshaler]()
+	stringerType        = reflect.TypeFor[fmt.Stringer]()
+	optionerType        = reflect.TypeFor[Optioner]()
+
+	// syncTypes hold runtime state only and are never hashed as struct fields.
+	syncTypes = []reflect.Type{
+		reflect.TypeFor[sync.Mutex](),
+		reflect.TypeFor[sync.RWMutex](),
+		reflect.TypeFor[sync.Once](),
+		reflect.TypeFor[sync.WaitGroup](),
+		reflect.TypeFor[sync.Cond](),
+	}
+
+	noop hashFunc = func(reflect.Value, *container) error {
+		return nil
+	}
+)
+
+// wellKnown returns the built-in hashFunc for standard library types
+// that cannot be hashed by their fields, or nil.
+func (h *Hasher) wellKnown(t reflect.Type, cfg config) hashFunc {
+	if hf := h.hashTemplate(t, cfg); hf != nil {
+		return hf
+	}
+
+	if hf := h.hashTime(t, cfg); hf != nil {
+		return hf
+	}
+
+	if hf := h.hashRawJSON(t, cfg); hf != nil {
+		return hf
+	}
+
+	if hf := h.hashSQLNull(t, cfg); hf != nil {
+		return hf
+	}
+
+	if hf := h.hashNetIP(t, cfg); hf != nil {
+		return hf
+	}
+
+	if hf := h.hashUUID(t, cfg); hf != nil {
+		return hf
+	}
+
+	if h.opts.GoSyntax {
+		return h.hashGoTypes(t, cfg)
+	}
+
+	return nil
+}
+
+// forward returns a hash function for a reference to the recursive type t, which calls the
+// final hash function of t once its compilation completed. If the compilation failed, it
+// compiles t again to report the error.
+func (h *Hasher) forward(t reflect.Type, cfg config, final *atomic.Pointer[hashFunc]) hashFunc {
+	return func(value reflect.Value, c *container) error {
+		if hf := final.Load(); hf != nil {
+			return (*hf)(value, c)
+		}
+
+		hf, err := h.makeHashFunc(t, cfg)
+		if err != nil {
+			return err
+		}
+
+		return hf(value, c)
+	}
+}
+
+// makeHashFunc returns the cached hash function of t, compiling it on first use.
+// Compilation is serialized by compileMu, so concurrent first uses of a type are safe
+// and never observe partially compiled hash functions.
+func (h *Hasher) makeHashFunc(t reflect.Type, cfg config) (hashFunc, error) {
+	if v, ok := h.hashFuncMap.Load(cacheKey{typ: t, cfg: cfg}); ok {
+		return v.(hashFunc), nil
+	}
+
+	h.compileMu.Lock()
+	defer h.compileMu.Unlock()
+
+	return h.compile(t, cfg)
+}
+
+// compile compiles the hash function of t. It must be called with compileMu held, and is
+// called recursively for the types contained in t. Types that are still being compiled
+// further up the call chain, i.e. recursive types, resolve to a forwarder to their final
+// hash function; in FormatV1 they resolve to noop, so recursive references hash nothing.
+func (h *Hasher) compile(t reflect.Type, cfg config) (hf hashFunc, err error) {
+	key := cacheKey{typ: t, cfg: cfg}
+
+	if v, ok := h.hashFuncMap.Load(key); ok {
+		return v.(hashFunc), nil
+	}
+
+	if final, ok := h.pending[key]; ok {
+		if h.opts.Format < FormatV2 {
+			return noop, nil
+		}
+
+		return h.forward(t, cfg, final), nil
+	}
+
+	final := &atomic.Pointer[hashFunc]{}
+	h.pending[key] = final
+
+	info := &TypeInfo{Type: t}
+	h.typeInfoMap.Store(key, info)
+
+	var phf hashFunc // Hash function of *t for addressable values, if set.
+
+	defer func() {
+		delete(h.pending, key)
+
+		if errors.Is(err, ErrUnsupportedType) && h.opts.Gob {
+			if gf := h.gobFallback(t, cfg); gf != nil {
+				hf, err = gf, nil
+
+				*info = TypeInfo{Type: t, Strategy: StrategyGob, Reason: "encoding/gob fallback"}
+			}
+		}
+
+		if err != nil {
+			info.Strategy, info.Reason = StrategyUnsupported, err.Error()
+
+			return
+		}
+
+		if info.Strategy == StrategyKind {
+			hf = h.limitDepth(t, hf)
+		}
+
+		if phf != nil {
+			hf = h.viaPointer(phf, hf)
+			info.viaPointer = true
+
+			info.Reason += "; addressable values via " + string(h.typeInfo(reflect.PointerTo(t), cfg).Strategy) + " of *" + t.String()
+		}
+
+		final.Store(&hf)
+		h.hashFuncMap.Store(key, hf)
+	}()
+
+	if cfg.raw {
+		if hf, err = h.hashRaw(t, cfg); err == nil {
+			info.Strategy, info.Reason = StrategyBuiltin, `raw bytes (tagged datahash:"raw")`
+		}
+
+		return hf, err
+	}
+
+	if hf := h.customHashFunc(t, cfg); hf != nil {
+		info.Strategy, info.Reason = StrategyCustom, "registered hash function"
+
+		return hf, nil
+	}
+
+	if own := cfg.with(typeOptions(t)); own != cfg {
+		hf, err = h.compile(t, own)
+
+		h.typeInfoMap.Store(key, h.typeInfo(t, own))
+
+		return hf, err
+	}
+
+	structural := cfg.prefer == "" && h.isStructural(t)
+
+	if hf := h.wellKnown(t, cfg); hf != nil && !structural {
+		info.Strategy, info.Reason = StrategyBuiltin, "built-in handling for "+t.String()
+
+		return hf, nil
+	}
+
+	if h.marshalsViaPointer(t, cfg) {
+		if hf, err := h.compile(reflect.PointerTo(t), cfg); err == nil {
+			phf = hf
+		}
+	}
+
+	if pt := preferredTypes[cfg.prefer]; pt != nil && t.Kind() != reflect.Interface && !t.Implements(pt) {
+		return nil, fmt.Errorf("datahash: type %s does not implement %s required by its struct tag", t, pt)
+	}
+
+	method, hasMethod := h.hashMethod(t)
+
+	switch {
+	case structural:
+	case cfg.allows(StrategyHashEncoder) && t.Implements(hashEncoderType):
+		info.Strategy, info.Reason = StrategyHashEncoder, "implements datahash.HashEncoder"
+
+		return func(value reflect.Value, c *container) error {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+				return nil
+			}
+
+			if !value.CanInterface() {
+				return errors.New("datahash: cannot use datahash.HashEncoder on unexported fields that are not accessible via reflection")
+			}
+
+			if value.Kind() == reflect.Pointer && value.IsNil() {
+				return nil
 			}
 
-			if vhf == nil {
-				if vhf, err = h.makeHashFunc(v.Type()); err != nil {
-					return err
-				}
-			} else {
-				if err = c.write(comma[:]); err != nil {
-					return err
-				}
+			i, ok := value.Interface().(HashEncoder)
+			if !ok || i == nil {
+				h.warn(c, info, "nil HashEncoder skipped")
+
+				return nil
 			}
 
-			if err = vhf(v, c); err != nil {
-				return err
+			return marshalerErr(t, StrategyHashEncoder, h.encode(i, c))
+		}, nil
+	case cfg.allows(StrategyHashWriterTo) && t.Implements(hashWriterToType):
+		info.Strategy, info.Reason = StrategyHashWriterTo, "implements datahash.HashWriterTo"
+
+		return func(value reflect.Value, c *container) error {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+				return nil
 			}
-		}
 
-		return c.write(endList[:])
-	}
-}
+			if !value.CanInterface() {
+				return errors.New("cannot use datahash.HashWriterTo on unexported fields that are not accessible via reflection")
+			}
 
-var (
-	hashWriterType      = reflect.TypeFor[HashWriter]()
-	binaryMarshalerType = reflect.TypeFor[encoding.BinaryMarshaler]()
-	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
-	jsonMarshalerType   = reflect.TypeFor[json.Marshaler]()
-	stringerType        = reflect.TypeFor[fmt.Stringer]()
+			if value.Kind() == reflect.Pointer && value.IsNil() {
+				return nil
+			}
 
-	noop hashFunc = func(reflect.Value, *container) error {
-		return nil
-	}
-)
+			i, ok := value.Interface().(HashWriterTo)
+			if !ok || i == nil {
+				h.warn(c, info, "nil HashWriterTo skipped")
 
-func (h *Hasher) makeHashFunc(t reflect.Type) (hf hashFunc, err error) {
-	v, ok := h.hashFuncMap.LoadOrStore(t, noop)
-	if ok {
-		return v.(hashFunc), nil
-	}
+				return nil
+			}
 
-	defer func() {
-		if err == nil {
-			h.hashFuncMap.Store(t, hf)
-		}
-	}()
+			return marshalerErr(t, StrategyHashWriterTo, i.WriteHashTo(c.hash))
+		}, nil
+	case cfg.allows(StrategyHashWriter) && t.Implements(hashWriterType):
+		info.Strategy, info.Reason = StrategyHashWriter, "implements datahash.HashWriter"
 
-	switch {
-	case t.Implements(hashWriterType):
 		return func(value reflect.Value, c *container) error {
-			if !value.IsValid() || (h.opts.IgnoreZero && isZero(value)) {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
 				return nil
 			}
 
@@ -670,14 +2060,28 @@ func (h *Hasher) makeHashFunc(t reflect.Type) (hf hashFunc, err error) {
 
 			i, ok := value.Interface().(HashWriter)
 			if !ok || i == nil {
+				h.warn(c, info, "nil HashWriter skipped")
+
 				return nil
 			}
 
-			return i.WriteHash(c.hash)
+			return marshalerErr(t, StrategyHashWriter, i.WriteHash(c.hash))
 		}, nil
-	case t.Implements(binaryMarshalerType):
+	case cfg.prefer == "" && hasMethod:
+		info.Strategy, info.Reason = StrategyHashMethod, "has method "+h.opts.HashMethod+"() uint64"
+
+		return h.hashByMethod(method, cfg), nil
+	case h.opts.Errors && cfg.prefer == "" && t.Implements(errorType):
+		info.Strategy, info.Reason = StrategyError, "implements error"
+
+		return h.hashError(t, cfg, info), nil
+	case cfg.allows(StrategyBinary) && t.Implements(binaryMarshalerType):
+		info.Strategy, info.Reason = StrategyBinary, "implements encoding.BinaryMarshaler"
+
+		fallback := h.marshalFallback(t, cfg, StrategyBinary, info)
+
 		return func(value reflect.Value, c *container) error {
-			if !value.IsValid() || (h.opts.IgnoreZero && isZero(value)) {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
 				return nil
 			}
 
@@ -691,19 +2095,29 @@ func (h *Hasher) makeHashFunc(t reflect.Type) (hf hashFunc, err error) {
 
 			i, ok := value.Interface().(encoding.BinaryMarshaler)
 			if !ok || i == nil {
+				h.warn(c, info, "nil encoding.BinaryMarshaler skipped")
+
 				return nil
 			}
 
-			v, err := i.MarshalBinary()
+			v, err := c.marshalBinary(i)
 			if err != nil {
-				return err
+				if fallback != nil {
+					return fallback(value, c)
+				}
+
+				return marshalerErr(t, StrategyBinary, err)
 			}
 
 			return c.write(v)
 		}, nil
-	case h.opts.Text && t.Implements(textMarshalerType):
+	case cfg.text && cfg.allows(StrategyText) && t.Implements(textMarshalerType):
+		info.Strategy, info.Reason = StrategyText, "implements encoding.TextMarshaler"
+
+		fallback := h.marshalFallback(t, cfg, StrategyText, info)
+
 		return func(value reflect.Value, c *container) error {
-			if !value.IsValid() || (h.opts.IgnoreZero && isZero(value)) {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
 				return nil
 			}
 
@@ -717,19 +2131,29 @@ func (h *Hasher) makeHashFunc(t reflect.Type) (hf hashFunc, err error) {
 
 			i, ok := value.Interface().(encoding.TextMarshaler)
 			if !ok || i == nil {
+				h.warn(c, info, "nil encoding.TextMarshaler skipped")
+
 				return nil
 			}
 
-			v, err := i.MarshalText()
+			v, err := c.marshalText(i)
 			if err != nil {
-				return err
+				if fallback != nil {
+					return fallback(value, c)
+				}
+
+				return marshalerErr(t, StrategyText, err)
 			}
 
 			return c.write(v)
 		}, nil
-	case h.opts.JSON && t.Implements(jsonMarshalerType):
+	case cfg.json && cfg.allows(StrategyJSON) && t.Implements(jsonMarshalerType):
+		info.Strategy, info.Reason = StrategyJSON, "implements json.Marshaler"
+
+		fallback := h.marshalFallback(t, cfg, StrategyJSON, info)
+
 		return func(value reflect.Value, c *container) error {
-			if !value.IsValid() || (h.opts.IgnoreZero && isZero(value)) {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
 				return nil
 			}
 
@@ -743,19 +2167,57 @@ func (h *Hasher) makeHashFunc(t reflect.Type) (hf hashFunc, err error) {
 
 			i, ok := value.Interface().(json.Marshaler)
 			if !ok || i == nil {
+				h.warn(c, info, "nil json.Marshaler skipped")
+
 				return nil
 			}
 
 			v, err := i.MarshalJSON()
 			if err != nil {
-				return err
+				if fallback != nil {
+					return fallback(value, c)
+				}
+
+				return marshalerErr(t, StrategyJSON, err)
+			}
+
+			return c.write(v)
+		}, nil
+	case cfg.xml && cfg.allows(StrategyXML) && t.Implements(xmlMarshalerType):
+		info.Strategy, info.Reason = StrategyXML, "implements xml.Marshaler"
+
+		return func(value reflect.Value, c *container) error {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+				return nil
+			}
+
+			if !value.CanInterface() {
+				return errors.New("cannot use xml.Marshaler on unexported fields that are not accessible via reflection")
+			}
+
+			if value.Kind() == reflect.Pointer && value.IsNil() {
+				return nil
+			}
+
+			i, ok := value.Interface().(xml.Marshaler)
+			if !ok || i == nil {
+				h.warn(c, info, "nil xml.Marshaler skipped")
+
+				return nil
+			}
+
+			v, err := xml.Marshal(i)
+			if err != nil {
+				return marshalerErr(t, StrategyXML, err)
 			}
 
 			return c.write(v)
 		}, nil
-	case h.opts.String && t.Implements(stringerType):
+	case cfg.str && cfg.allows(StrategyStringer) && t.Implements(stringerType):
+		info.Strategy, info.Reason = StrategyStringer, "implements fmt.Stringer"
+
 		return func(value reflect.Value, c *container) error {
-			if !value.IsValid() || (h.opts.IgnoreZero && isZero(value)) {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
 				return nil
 			}
 
@@ -769,71 +2231,124 @@ func (h *Hasher) makeHashFunc(t reflect.Type) (hf hashFunc, err error) {
 
 			i, ok := value.Interface().(fmt.Stringer)
 			if !ok || i == nil {
+				h.warn(c, info, "nil fmt.Stringer skipped")
+
 				return nil
 			}
 
 			return c.write(stringToBytes(i.String()))
 		}, nil
+	case h.opts.Gob && cfg.allows(StrategyGob) && t.Implements(gobEncoderType):
+		info.Strategy, info.Reason = StrategyGob, "implements gob.GobEncoder"
+
+		return h.hashGobEncoder(t, cfg, info), nil
+	}
+
+	info.Strategy = StrategyKind
+
+	if hf := h.hashNumber(t); hf != nil {
+		info.Reason = "normalized number"
+
+		return hf, nil
 	}
 
 	switch t.Kind() {
 	case reflect.Interface:
+		info.Reason = "dynamic type resolved per value"
+
 		return func(value reflect.Value, c *container) error {
-			if !value.IsValid() || (h.opts.IgnoreZero && isZero(value)) {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
 				return nil
 			}
 
-			if value.Kind() != reflect.Interface {
-				hasher, err := h.makeHashFunc(value.Type())
-				if err != nil {
-					return err
-				}
+			elem := value
 
-				return hasher(value, c)
+			if value.Kind() == reflect.Interface {
+				elem = value.Elem()
 			}
 
-			elem := value.Elem()
-
 			if elem.Kind() == reflect.Invalid {
+				if h.opts.NilMarker {
+					return c.write(byteNil[:])
+				}
+
+				h.warn(c, info, "nil interface skipped")
+
 				return nil
 			}
 
-			hasher, err := h.makeHashFunc(elem.Type())
+			if h.opts.StrictSchema && !h.isAllowed(elem.Type()) {
+				return fmt.Errorf("%w: %s", ErrNotAllowed, elem.Type())
+			}
+
+			hasher, err := h.makeHashFunc(elem.Type(), cfg)
 			if err != nil {
 				return err
 			}
 
+			if err := h.writeType(elem.Type(), c); err != nil {
+				return err
+			}
+
 			return hasher(elem, c)
 		}, nil
 	case reflect.Pointer:
-		ehf, err := h.makeHashFunc(t.Elem())
+		ehf, err := h.compile(t.Elem(), cfg)
+
+		info.Elem = h.typeInfo(t.Elem(), cfg)
+
 		if err != nil {
 			return nil, err
 		}
 
+		var (
+			track = h.tracks(t)
+			memo  = h.opts.Memo != nil && t.Elem().Kind() == reflect.Struct && !mayRevisit(t)
+		)
+
 		return func(value reflect.Value, c *container) error {
 			if !value.IsValid() {
 				return nil
 			}
 
 			if value.IsNil() {
-				if h.opts.ZeroNil {
+				if cfg.zeroNil {
 					return ehf(reflect.Zero(t.Elem()), c)
 				}
 
+				if h.opts.NilMarker {
+					return c.write(byteNil[:])
+				}
+
 				return nil
 			}
 
-			addr := value.Pointer()
-			if slices.Contains(c.visited, addr) {
-				return nil
+			if track {
+				if ref, ok := h.revisited(value, c); ok {
+					if h.opts.Format >= FormatV2 {
+						//nolint:gosec
+						return twoErr(c.write(byteRef[:]), c.writeUint64(uint64(ref)))
+					}
+
+					h.warn(c, info, "revisited pointer skipped")
+
+					return nil
+				}
 			}
 
-			c.visited = append(c.visited, addr)
+			if memo {
+				return h.hashMemo(value, ehf, cfg, c)
+			}
 
 			return ehf(value.Elem(), c)
 		}, nil
 	case reflect.String:
+		if h.opts.NormalizeUnicode || cfg.foldCase || h.opts.StringTransform != nil {
+			return func(value reflect.Value, c *container) error {
+				return c.write(stringToBytes(h.normalizeString(value.String(), cfg)))
+			}, nil
+		}
+
 		return func(value reflect.Value, c *container) error {
 			return c.write(stringToBytes(value.String()))
 		}, nil
@@ -847,10 +2362,27 @@ func (h *Hasher) makeHashFunc(t reflect.Type) (hf hashFunc, err error) {
 			return c.writeUint64(value.Uint())
 		}, nil
 	case reflect.Float32, reflect.Float64:
+		if h.opts.NormalizeFloats {
+			return func(value reflect.Value, c *container) error {
+				return c.writeFloat64(normalizeFloat(value.Float()))
+			}, nil
+		}
+
 		return func(value reflect.Value, c *container) error {
 			return c.writeFloat64(value.Float())
 		}, nil
 	case reflect.Complex64, reflect.Complex128:
+		if h.opts.NormalizeFloats {
+			return func(value reflect.Value, c *container) error {
+				v := value.Complex()
+
+				return twoErr(
+					c.writeFloat64(normalizeFloat(real(v))),
+					c.writeFloat64(normalizeFloat(imag(v))),
+				)
+			}, nil
+		}
+
 		return func(value reflect.Value, c *container) error {
 			v := value.Complex()
 
@@ -868,96 +2400,320 @@ func (h *Hasher) makeHashFunc(t reflect.Type) (hf hashFunc, err error) {
 			return c.write(byteFalse[:])
 		}, nil
 	case reflect.Array:
-		vhf, err := h.makeHashFunc(t.Elem())
+		vhf, err := h.compile(t.Elem(), h.cfg)
+
+		info.Elem = h.typeInfo(t.Elem(), h.cfg)
+
 		if err != nil {
-			return nil, err
+			return nil, prefixPath(err, "[]")
+		}
+
+		if cfg.unorderedArray {
+			info.Reason = "unordered set"
+
+			return h.hashUnorderedSliceArray(vhf, cfg), nil
 		}
 
-		if h.opts.UnorderedArray {
-			return h.hashUnorderedSliceArray(vhf), nil
+		if h.rawArray(t, cfg) {
+			info.Reason = "raw bytes"
+
+			return h.hashByteArray(cfg), nil
 		}
 
-		return h.hashSliceArray(vhf), nil
+		info.Reason = "ordered list"
+
+		return h.hashSliceArray(vhf, cfg), nil
 	case reflect.Slice:
 		elem := t.Elem()
 
 		if elem.Kind() == reflect.Uint8 {
-			return func(value reflect.Value, c *container) error {
-				if !value.IsValid() || (h.opts.IgnoreZero && isZero(value)) {
+			info.Reason = "raw bytes"
+
+			return h.distinguishNil(func(value reflect.Value, c *container) error {
+				if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
 					return nil
 				}
 
+				if h.opts.ChunkSize > 0 {
+					return h.hashChunks(value.Bytes(), c)
+				}
+
 				return c.write(value.Bytes())
-			}, nil
+			}, cfg), nil
 		}
 
-		vhf, err := h.makeHashFunc(elem)
+		vhf, err := h.compile(elem, h.cfg)
+
+		info.Elem = h.typeInfo(elem, h.cfg)
+
 		if err != nil {
-			return nil, err
+			return nil, prefixPath(err, "[]")
 		}
 
-		if h.opts.UnorderedSlice {
-			return h.hashUnorderedSliceArray(vhf), nil
+		if cfg.unorderedSlice {
+			info.Reason = "unordered set"
+
+			return h.distinguishNil(h.hashUnorderedSliceArray(vhf, cfg), cfg), nil
 		}
 
-		return h.hashSliceArray(vhf), nil
+		info.Reason = "ordered list"
+
+		return h.distinguishNil(h.hashSliceArray(vhf, cfg), cfg), nil
 	case reflect.Map:
-		khf, err := h.makeHashFunc(t.Key())
+		khf, err := h.compile(t.Key(), h.cfg)
+
+		info.Key = h.typeInfo(t.Key(), h.cfg)
+
 		if err != nil {
-			return nil, err
+			return nil, prefixPath(err, "[key]")
 		}
 
-		vhf, err := h.makeHashFunc(t.Elem())
+		vhf, err := h.compile(t.Elem(), h.cfg)
+
+		info.Elem = h.typeInfo(t.Elem(), h.cfg)
+
 		if err != nil {
-			return nil, err
+			return nil, prefixPath(err, "[]")
+		}
+
+		var entries string
+
+		switch {
+		case cfg.mapKeys:
+			entries = " of keys"
+		case cfg.mapValues:
+			entries = " of values"
+		}
+
+		if h.opts.SortedMaps {
+			info.Reason = "ordered list" + entries + " sorted by digest"
+
+			return h.distinguishNil(h.hashSortedMap(khf, vhf, cfg), cfg), nil
 		}
 
-		return h.hashMap(khf, vhf), nil
+		info.Reason = "unordered set" + entries
+
+		return h.distinguishNil(h.hashMap(khf, vhf, cfg), cfg), nil
 	case reflect.Struct:
 		sfs := make([]structField, 0, t.NumField())
 
 		for i := range t.NumField() {
 			sf := t.Field(i)
 
-			if sf.Tag.Get("datahash") == "-" {
+			if reason := h.skipReason(t, sf); reason != "" {
+				info.Fields = append(info.Fields, FieldInfo{Name: sf.Name, Skipped: true, Reason: reason})
+
 				continue
 			}
 
-			hf, err := h.makeHashFunc(sf.Type)
+			tag, err := h.fieldTag(sf)
+			if err != nil {
+				return nil, fmt.Errorf("%w on field %s of %s", err, sf.Name, t)
+			}
+
+			fcfg := h.cfg.withTag(tag)
+
+			hf, err := h.compile(sf.Type, fcfg)
+
+			info.Fields = append(info.Fields, FieldInfo{Name: sf.Name, Info: h.typeInfo(sf.Type, fcfg)})
+
 			if err != nil {
+				return nil, prefixPath(err, sf.Name)
+			}
+
+			if promoted, ok := h.promoted(sf, tag, fcfg); ok {
+				for _, p := range promoted {
+					p.path = append([]int{i}, p.indexPath()...)
+					p.read = nil
+
+					if sfs, err = addField(sfs, p, t); err != nil {
+						return nil, err
+					}
+				}
+
+				continue
+			}
+
+			name := sf.Name
+
+			if tag.name != "" {
+				name = tag.name
+			}
+
+			if sfs, err = addField(sfs, structField{
+				name:   stringToBytes(name),
+				idx:    i,
+				hf:     hf,
+				cfg:    fcfg,
+				tag:    tag,
+				offset: sf.Offset,
+				read:   h.scalarReader(sf, cfg, fcfg, tag),
+			}, t); err != nil {
 				return nil, err
 			}
+		}
 
-			sfs = append(sfs, structField{
-				name: stringToBytes(sf.Name),
-				idx:  i,
-				hf:   hf,
-			})
+		if cfg.unorderedStruct {
+			info.Reason = "unordered fields"
+		} else {
+			info.Reason = "ordered fields"
 		}
 
-		return h.hashStruct(sfs), nil
+		h.structFieldsMap.Store(key, sfs)
+
+		return h.hashStruct(sfs, cfg), nil
+	}
+
+	if mode := h.kindMode(t); mode != ModeDefault {
+		return h.hashByMode(mode, info), nil
+	}
+
+	if h.opts.StrictKinds && (t.Kind() != reflect.Func || (!t.CanSeq() && !t.CanSeq2())) {
+		return nil, &KindError{Kind: t.Kind(), Type: t}
 	}
 
 	if t.CanSeq2() {
-		return h.hashSeq2(), nil
+		if cfg.unorderedSeq2 {
+			info.Reason = "iter.Seq2, unordered set"
+		} else {
+			info.Reason = "iter.Seq2, ordered list"
+		}
+
+		return h.hashSeq2(cfg), nil
 	}
 
 	if t.CanSeq() {
-		return h.hashSeq(), nil
+		if cfg.unorderedSeq {
+			info.Reason = "iter.Seq, unordered set"
+		} else {
+			info.Reason = "iter.Seq, ordered list"
+		}
+
+		return h.hashSeq(cfg), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q (missing HashWriter or marshaling interface)", ErrUnsupportedType, t)
+}
+
+// tracks reports whether values of the pointer type t are recorded for revisit detection.
+// FormatV1 records all pointers; later formats only pointers of recursive types, so shared
+// pointers of other types hash by their content. NoCycleDetection disables recording.
+func (h *Hasher) tracks(t reflect.Type) bool {
+	return !h.opts.NoCycleDetection && (h.opts.Format < FormatV2 || mayRevisit(t))
+}
+
+// revisited reports whether the non-nil pointer value was already hashed by c, together with the
+// index of its first occurrence, and records it otherwise. Pointers are identified by Options.Identity
+// if it returns an identity, and by their address else.
+func (h *Hasher) revisited(value reflect.Value, c *container) (int, bool) {
+	if h.opts.Identity != nil {
+		if id, ok := h.opts.Identity(value); ok {
+			if ref, ok := c.ids.lookup(id); ok {
+				return ref, true
+			}
+
+			c.ids.add(id)
+
+			return 0, false
+		}
+	}
+
+	addr := value.Pointer()
+	if ref, ok := c.visited.lookup(addr); ok {
+		return ref, true
 	}
 
-	return nil, fmt.Errorf("datahash: unsupported type: %q (missing HashWriter or marshaling interface)", t)
+	c.visited.add(addr)
+
+	return 0, false
 }
 
 type container struct {
 	hash    hash.Hash64
-	visited []uintptr
+	visited visitSet[uintptr]
+	ids     visitSet[any] // Identities returned by Options.Identity.
+	st      *state        // Shared with the sub containers of a single Hash call.
+	own     state
 	buf     [8]byte
+	scratch []byte // Reused by encoding.BinaryAppender and encoding.TextAppender.
+}
+
+// state holds the per-call bookkeeping shared by a container and its sub containers.
+type state struct {
+	track       bool
+	path        []pathElem
+	seqElements int
+	depth       int
+	elements    int
+}
+
+// pathElem is a single step of a path: a struct field, an index or a map key.
+type pathElem struct {
+	field []byte
+	index int
+	key   reflect.Value
+}
+
+func (s *state) reset(track bool) {
+	s.track = track
+	s.path = s.path[:0]
+	s.seqElements = 0
+	s.depth = 0
+	s.elements = 0
+}
+
+// String formats the path like "Items[3].Meta".
+func (s *state) String() string {
+	var b strings.Builder
+
+	for _, e := range s.path {
+		switch {
+		case e.field != nil:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+
+			b.Write(e.field)
+		case e.key.IsValid():
+			fmt.Fprintf(&b, "[%v]", e.key)
+		default:
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(e.index))
+			b.WriteByte(']')
+		}
+	}
+
+	return b.String()
+}
+
+func (c *container) enterField(name []byte) {
+	if c.st.track {
+		c.st.path = append(c.st.path, pathElem{field: name})
+	}
+}
+
+func (c *container) enterIndex(i int) {
+	if c.st.track {
+		c.st.path = append(c.st.path, pathElem{index: i})
+	}
+}
+
+func (c *container) enterKey(key reflect.Value) {
+	if c.st.track {
+		c.st.path = append(c.st.path, pathElem{key: key})
+	}
+}
+
+func (c *container) leave() {
+	if c.st.track {
+		c.st.path = c.st.path[:len(c.st.path)-1]
+	}
 }
 
 func (c *container) Reset() {
 	c.hash.Reset()
-	c.visited = c.visited[:0]
+	c.visited.reset()
+	c.ids.reset()
 }
 
 func (c *container) write(b []byte) error {
@@ -978,6 +2734,18 @@ func (c *container) writeFloat64(v float64) error {
 	return c.write(c.buf[:])
 }
 
+// normalizeFloat maps all NaN bit patterns to a single NaN and negative zero to zero.
+func normalizeFloat(v float64) float64 {
+	switch {
+	case v != v:
+		return math.NaN()
+	case v == 0:
+		return 0
+	}
+
+	return v
+}
+
 func stringToBytes(s string) []byte {
 	//nolint:gosec
 	return unsafe.Slice(unsafe.StringData(s), len(s))
@@ -1011,6 +2779,50 @@ func threeErr(err1, err2, err3 error) error {
 	return errors.Join(err1, err2, err3)
 }
 
+// skipReason returns why the field sf of the struct type parent is excluded from hashing,
+// or an empty string if it is hashed.
+func (h *Hasher) skipReason(parent reflect.Type, sf reflect.StructField) string {
+	switch {
+	case sf.Tag.Get("datahash") == "-":
+		return `tagged datahash:"-"`
+	case h.opts.JSONTags && sf.Tag.Get("json") == "-":
+		return `tagged json:"-" in JSONTags mode`
+	case h.opts.ExplicitOnly && !fieldIncluded(sf):
+		return `not tagged datahash:"include" in ExplicitOnly mode`
+	case isSyncPrimitive(sf.Type):
+		return "sync primitive"
+	case h.opts.GoSyntax && isSyntaxNoise(sf.Type):
+		return "position or object resolution in GoSyntax mode"
+	case h.opts.SkipField != nil && h.opts.SkipField(parent, sf):
+		return "excluded by SkipField"
+	case h.excluded(parent, sf.Name):
+		return "excluded by Exclude"
+	case h.kindMode(sf.Type) == ModeSkip:
+		return sf.Type.Kind().String() + " in ModeSkip"
+	}
+
+	return ""
+}
+
+// excluded reports whether Options.Exclude lists the field name for the struct type parent.
+func (h *Hasher) excluded(parent reflect.Type, name string) bool {
+	if len(h.opts.Exclude) == 0 || parent.Name() == "" {
+		return false
+	}
+
+	return slices.Contains(h.opts.Exclude[parent.PkgPath()+"."+parent.Name()], name) ||
+		slices.Contains(h.opts.Exclude[parent.String()], name)
+}
+
+// isSyncPrimitive reports whether t is (a pointer to) a sync primitive or a noCopy marker.
+func isSyncPrimitive(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return slices.Contains(syncTypes, t) || t.Name() == "noCopy"
+}
+
 func isZero(value reflect.Value) bool {
 	var check = value
 
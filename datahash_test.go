@@ -1,11 +1,26 @@
 package datahash_test
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"hash"
+	"hash/crc32"
 	"hash/fnv"
+	"hash/maphash"
+	"io"
+	"iter"
 	"maps"
+	"math"
+	"reflect"
 	"slices"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/cespare/xxhash/v2"
@@ -30,6 +45,26 @@ func (c customHash) WriteHash(hash hash.Hash64) error {
 	return err
 }
 
+type customStream struct {
+	Value string
+}
+
+func (c customStream) WriteHashTo(w io.Writer) error {
+	_, err := io.WriteString(w, "custom:"+c.Value)
+
+	return err
+}
+
+type bothWriters struct {
+	customStream
+}
+
+func (bothWriters) WriteHash(hash hash.Hash64) error {
+	_, err := hash.Write([]byte("legacy"))
+
+	return err
+}
+
 type stringerType struct {
 	V int
 }
@@ -183,3 +218,1238 @@ func makeCyclic() *node {
 	a.Next = b
 	return a
 }
+
+func TestHasher_RecursiveTypes(t *testing.T) {
+	type tree struct {
+		Name     string
+		Children []tree
+		Index    map[string]*tree
+	}
+
+	v1 := datahash.New(fnv.New64a, datahash.Options{})
+	v2 := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV2})
+
+	if v1.MustHash(&node{Value: 1, Next: &node{Value: 2}}) != v1.MustHash(&node{Value: 1, Next: &node{Value: 3}}) {
+		t.Error("expected FormatV1 to hash nothing for recursive references")
+	}
+
+	if v2.MustHash(&node{Value: 1, Next: &node{Value: 2}}) == v2.MustHash(&node{Value: 1, Next: &node{Value: 3}}) {
+		t.Error("expected nested nodes to contribute")
+	}
+
+	if v2.MustHash(node{Value: 1, Next: &node{Value: 2}}) == v2.MustHash(node{Value: 1, Next: &node{Value: 3}}) {
+		t.Error("expected nested nodes of values to contribute")
+	}
+
+	a := tree{Name: "root", Children: []tree{{Name: "a"}}, Index: map[string]*tree{"b": {Name: "b"}}}
+	b := tree{Name: "root", Children: []tree{{Name: "x"}}, Index: map[string]*tree{"b": {Name: "b"}}}
+	c := tree{Name: "root", Children: []tree{{Name: "a"}}, Index: map[string]*tree{"b": {Name: "x"}}}
+
+	if v2.MustHash(a) == v2.MustHash(b) || v2.MustHash(a) == v2.MustHash(c) {
+		t.Error("expected recursive slices and maps to contribute")
+	}
+
+	if _, err := v2.Hash(makeCyclic()); err != nil {
+		t.Errorf("expected runtime cycles to be detected: %v", err)
+	}
+
+	type broken struct {
+		Next *broken
+		Fn   func()
+	}
+
+	for range 2 {
+		if _, err := v2.Hash(&broken{Next: &broken{}}); err == nil {
+			t.Error("expected an error for unsupported recursive types")
+		}
+	}
+}
+
+func TestHasher_WarnFunc(t *testing.T) {
+	var warnings []string
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{
+		WarnFunc: func(path string, reason string) {
+			warnings = append(warnings, path+": "+reason)
+		},
+	})
+
+	_, err := hasher.Hash(struct {
+		Items []any
+		Meta  map[string]any
+	}{
+		Items: []any{1, nil},
+		Meta:  map[string]any{"key": nil},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{
+		"Items[1]: nil interface skipped",
+		"Meta[key]: nil interface skipped",
+	}
+
+	if !slices.Equal(warnings, want) {
+		t.Errorf("warnings mismatch:\n  got:  %q\n  want: %q", warnings, want)
+	}
+}
+
+type unorderedTags []string
+
+func (unorderedTags) DatahashOptions() datahash.FieldOptions {
+	return datahash.FieldOptions{Unordered: true}
+}
+
+func TestHasher_Optioner(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	type item struct {
+		Tags unorderedTags
+	}
+
+	a, err := hasher.Hash(item{Tags: unorderedTags{"a", "b"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := hasher.Hash(&item{Tags: unorderedTags{"b", "a"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a != b {
+		t.Errorf("expected equal hashes for unordered tags: %d != %d", a, b)
+	}
+
+	c, err := hasher.Hash(struct{ Tags []string }{Tags: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a == c {
+		t.Errorf("expected different hashes for ordered and unordered tags")
+	}
+}
+
+func TestHasher_SkipSyncPrimitives(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	type guarded struct {
+		mu    sync.Mutex
+		once  *sync.Once
+		Value int
+	}
+
+	unlocked := &guarded{Value: 1}
+	locked := &guarded{Value: 1, once: &sync.Once{}}
+
+	locked.mu.Lock()
+	defer locked.mu.Unlock()
+
+	a, err := hasher.Hash(unlocked)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := hasher.Hash(locked)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a != b {
+		t.Errorf("expected sync primitives to be skipped: %d != %d", a, b)
+	}
+}
+
+func TestHasher_UnsupportedTypeErrorsRepeatedly(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	for range 2 {
+		if _, err := hasher.Hash(struct{ Fn func() }{}); err == nil {
+			t.Fatal("expected error for unsupported type")
+		}
+	}
+}
+
+type thirdParty struct {
+	ID        int
+	Revision  int
+	UpdatedAt string
+}
+
+func TestHasher_Exclude(t *testing.T) {
+	var opts datahash.Options
+
+	if err := json.Unmarshal([]byte(`{"Exclude": {"datahash_test.thirdParty": ["Revision", "UpdatedAt"]}}`), &opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	hasher := datahash.New(fnv.New64a, opts)
+
+	a, err := hasher.Hash(thirdParty{ID: 1, Revision: 1, UpdatedAt: "monday"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := hasher.Hash(thirdParty{ID: 1, Revision: 2, UpdatedAt: "tuesday"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a != b {
+		t.Errorf("expected excluded fields to be ignored: %d != %d", a, b)
+	}
+
+	qualified := datahash.New(fnv.New64a, datahash.Options{
+		Exclude: map[string][]string{"github.com/go-sqlt/datahash_test.thirdParty": {"Revision", "UpdatedAt"}},
+	})
+
+	c, err := qualified.Hash(thirdParty{ID: 1, Revision: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a != c {
+		t.Errorf("expected fully qualified type names to match: %d != %d", a, c)
+	}
+}
+
+type lazyNode struct {
+	Value int
+}
+
+func TestHasher_MaxDepth(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{MaxDepth: 10})
+
+	var deep any = "leaf"
+
+	for range 100 {
+		deep = []any{deep}
+	}
+
+	if _, err := hasher.Hash(deep); !errors.Is(err, datahash.ErrDepthLimit) {
+		t.Errorf("expected ErrDepthLimit, got %v", err)
+	}
+
+	if _, err := hasher.Canonicalize(deep); !errors.Is(err, datahash.ErrDepthLimit) {
+		t.Errorf("expected ErrDepthLimit from Canonicalize, got %v", err)
+	}
+
+	// Maps, slices and the interfaces of their elements each count as a level.
+	shallow := map[string]any{"a": []any{1, map[string]any{"b": 2}}}
+
+	if _, err := hasher.Hash(shallow); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := datahash.New(fnv.New64a, datahash.Options{MaxDepth: 3}).Hash(shallow); !errors.Is(err, datahash.ErrDepthLimit) {
+		t.Errorf("expected ErrDepthLimit, got %v", err)
+	}
+
+	if _, err := hasher.Hash(shallow); err != nil {
+		t.Errorf("expected the depth to be reset between calls: %v", err)
+	}
+}
+
+func TestHasher_MaxElements(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{MaxElements: 5})
+
+	if _, err := hasher.Hash(make([]int, 6)); !errors.Is(err, datahash.ErrElementLimit) {
+		t.Errorf("expected ErrElementLimit, got %v", err)
+	}
+
+	if _, err := hasher.Hash([][]int{{1, 2}, {3, 4}}); !errors.Is(err, datahash.ErrElementLimit) {
+		t.Errorf("expected nested elements to count, got %v", err)
+	}
+
+	if _, err := hasher.Hash(map[int]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6}); !errors.Is(err, datahash.ErrElementLimit) {
+		t.Errorf("expected ErrElementLimit for maps, got %v", err)
+	}
+
+	if _, err := hasher.Hash(slices.Values(make([]int, 6))); !errors.Is(err, datahash.ErrElementLimit) {
+		t.Errorf("expected ErrElementLimit for iterators, got %v", err)
+	}
+
+	if _, err := hasher.Hash([]int{1, 2, 3, 4, 5}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	for _, opts := range []datahash.Options{
+		{MaxElements: 3, TruncateElements: true},
+		{MaxElements: 3, TruncateElements: true, UnorderedSlice: true, UnorderedSeq: true},
+	} {
+		truncating := datahash.New(fnv.New64a, opts)
+
+		if truncating.MustHash([]int{1, 2, 3, 4}) != truncating.MustHash([]int{1, 2, 3, 5}) {
+			t.Error("expected elements beyond the limit to be ignored")
+		}
+
+		if truncating.MustHash([]int{1, 2, 3, 4}) == truncating.MustHash([]int{1, 2, 3}) {
+			t.Error("expected truncated collections to differ from complete ones")
+		}
+
+		if truncating.MustHash(slices.Values([]int{1, 2, 3, 4})) != truncating.MustHash(slices.Values([]int{1, 2, 3, 5})) {
+			t.Error("expected iterator elements beyond the limit to be ignored")
+		}
+
+		canonical, err := truncating.Canonicalize([]int{1, 2, 3, 4})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if len(canonical.([]any)) != 3 {
+			t.Errorf("expected 3 canonical elements, got %v", canonical)
+		}
+	}
+}
+
+func TestHasher_MaxSeqElements(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{MaxSeqElements: 100})
+
+	endless := func(yield func(*lazyNode) bool) {
+		for i := 0; ; i++ {
+			if !yield(&lazyNode{Value: i}) {
+				return
+			}
+		}
+	}
+
+	if _, err := hasher.Hash(iter.Seq[*lazyNode](endless)); !errors.Is(err, datahash.ErrSeqLimit) {
+		t.Errorf("expected ErrSeqLimit, got %v", err)
+	}
+
+	var nested func(yield func(any) bool)
+
+	nested = func(yield func(any) bool) {
+		yield(iter.Seq[any](nested))
+	}
+
+	if _, err := hasher.Hash(iter.Seq[any](nested)); !errors.Is(err, datahash.ErrSeqLimit) {
+		t.Errorf("expected ErrSeqLimit, got %v", err)
+	}
+
+	if _, err := hasher.Hash(slices.Values([]int{1, 2, 3})); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type seqNode struct {
+	Value    int
+	Children iter.Seq[*seqNode]
+}
+
+func TestHasher_UnorderedSeqCycle(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{UnorderedSeq: true})
+
+	root := &seqNode{Value: 1}
+	root.Children = func(yield func(*seqNode) bool) {
+		yield(root)
+	}
+
+	if _, err := hasher.Hash(root); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type loginEvent struct {
+	User string
+}
+
+type logoutEvent struct {
+	User string
+}
+
+func TestHasher_StrictSchema(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{StrictSchema: true})
+
+	type envelope struct {
+		Payload any
+	}
+
+	if err := hasher.Allow(loginEvent{}, reflect.TypeFor[int]()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := hasher.Hash(envelope{Payload: loginEvent{User: "alice"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := hasher.Hash([]any{1, 2}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := hasher.Hash(envelope{Payload: logoutEvent{User: "alice"}}); !errors.Is(err, datahash.ErrNotAllowed) {
+		t.Errorf("expected ErrNotAllowed, got %v", err)
+	}
+
+	if err := hasher.Allow(func() {}); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
+
+func TestHasher_NilMarker(t *testing.T) {
+	type one struct{ A *int }
+
+	type iface struct{ V any }
+
+	plain := datahash.New(fnv.New64a, datahash.Options{UnorderedStruct: true})
+	marked := datahash.New(fnv.New64a, datahash.Options{UnorderedStruct: true, NilMarker: true})
+
+	pairs := [][2]any{
+		{[]*int{nil}, []*int{}},
+		{iface{}, iface{V: ""}},
+		{[]any{nil}, []any{}},
+	}
+
+	for _, p := range pairs {
+		if a, b := plain.MustHash(p[0]), plain.MustHash(p[1]); a != b {
+			t.Errorf("expected %#v and %#v to collide without NilMarker", p[0], p[1])
+		}
+
+		if a, b := marked.MustHash(p[0]), marked.MustHash(p[1]); a == b {
+			t.Errorf("expected different hashes for %#v and %#v", p[0], p[1])
+		}
+	}
+
+	zero := datahash.New(fnv.New64a, datahash.Options{NilMarker: true, ZeroNil: true})
+
+	if a, b := zero.MustHash(one{}), zero.MustHash(one{A: new(int)}); a != b {
+		t.Error("expected ZeroNil to take precedence over NilMarker")
+	}
+}
+
+func TestHasher_DistinguishNil(t *testing.T) {
+	pairs := [][2]any{
+		{[]string(nil), []string{}},
+		{[]byte(nil), []byte{}},
+		{map[string]int(nil), map[string]int{}},
+		{struct{ S []int }{}, struct{ S []int }{S: []int{}}},
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	distinct := datahash.New(fnv.New64a, datahash.Options{DistinguishNil: true})
+	unordered := datahash.New(fnv.New64a, datahash.Options{DistinguishNil: true, UnorderedSlice: true})
+
+	for _, p := range pairs {
+		if a, b := plain.MustHash(p[0]), plain.MustHash(p[1]); a != b {
+			t.Errorf("expected %#v and %#v to collide without DistinguishNil", p[0], p[1])
+		}
+
+		if a, b := distinct.MustHash(p[0]), distinct.MustHash(p[1]); a == b {
+			t.Errorf("expected different hashes for %#v and %#v", p[0], p[1])
+		}
+
+		if a, b := unordered.MustHash(p[0]), unordered.MustHash(p[1]); a == b {
+			t.Errorf("expected different unordered hashes for %#v and %#v", p[0], p[1])
+		}
+	}
+}
+
+func TestHasher_NormalizeFloats(t *testing.T) {
+	otherNaN := math.Float64frombits(0x7ff8000000000000)
+
+	pairs := [][2]any{
+		{math.NaN(), otherNaN},
+		{math.Copysign(0, -1), 0.0},
+		{float32(math.Copysign(0, -1)), float32(0)},
+		{complex(math.NaN(), math.Copysign(0, -1)), complex(otherNaN, 0)},
+		{[]float64{math.Copysign(0, -1)}, []float64{0}},
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	normalized := datahash.New(fnv.New64a, datahash.Options{NormalizeFloats: true})
+
+	for _, p := range pairs {
+		if a, b := plain.MustHash(p[0]), plain.MustHash(p[1]); a == b {
+			t.Errorf("expected different hashes for %v and %v without NormalizeFloats", p[0], p[1])
+		}
+
+		if a, b := normalized.MustHash(p[0]), normalized.MustHash(p[1]); a != b {
+			t.Errorf("expected equal hashes for %v and %v", p[0], p[1])
+		}
+	}
+
+	if normalized.MustHash(1.5) != plain.MustHash(1.5) {
+		t.Error("expected regular floats to be unchanged")
+	}
+}
+
+func TestHasher_NormalizeNumbers(t *testing.T) {
+	type config struct {
+		Values map[string]any
+	}
+
+	equal := [][2]any{
+		{int8(5), int64(5)},
+		{int64(5), uint(5)},
+		{uint(5), 5.0},
+		{float32(5), complex(5, 0)},
+		{int64(-3), -3.0},
+		{math.Copysign(0, -1), 0},
+		{uint64(1 << 63), float64(1 << 63)},
+		{[]any{int32(1), 2.0}, []any{uint8(1), int(2)}},
+		{config{Values: map[string]any{"port": 8080}}, config{Values: map[string]any{"port": 8080.0}}},
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	normalized := datahash.New(fnv.New64a, datahash.Options{NormalizeNumbers: true})
+
+	if plain.MustHash(5) == plain.MustHash(5.0) {
+		t.Error("expected different hashes for 5 and 5.0 without NormalizeNumbers")
+	}
+
+	for _, p := range equal {
+		if a, b := normalized.MustHash(p[0]), normalized.MustHash(p[1]); a != b {
+			t.Errorf("expected equal hashes for %v and %v", p[0], p[1])
+		}
+	}
+
+	different := [][2]any{
+		{5, 5.5},
+		{-1, uint64(math.MaxUint64)},
+		{complex(1, 1), 1},
+		{int64(math.MaxInt64), uint64(math.MaxInt64) + 1},
+	}
+
+	for _, p := range different {
+		if a, b := normalized.MustHash(p[0]), normalized.MustHash(p[1]); a == b {
+			t.Errorf("expected different hashes for %v and %v", p[0], p[1])
+		}
+	}
+}
+
+func TestHasher_StrongUnordered(t *testing.T) {
+	pairs := [][2]any{
+		{[]string{"a", "a", "b"}, []string{"b"}},
+		{[]string{"a", "a"}, []string{}},
+		{[]string{"a", "a", "b", "b"}, []string{"c", "c"}},
+		{struct{ A, B []string }{[]string{"x", "x"}, nil}, struct{ A, B []string }{nil, []string{"y", "y"}}},
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{UnorderedSlice: true})
+	strong := datahash.New(fnv.New64a, datahash.Options{UnorderedSlice: true, StrongUnordered: true})
+
+	for _, p := range pairs {
+		if a, b := plain.MustHash(p[0]), plain.MustHash(p[1]); a != b {
+			t.Errorf("expected %v and %v to collide with XOR", p[0], p[1])
+		}
+
+		if a, b := strong.MustHash(p[0]), strong.MustHash(p[1]); a == b {
+			t.Errorf("expected different hashes for %v and %v", p[0], p[1])
+		}
+	}
+
+	if strong.MustHash([]string{"a", "b", "c"}) != strong.MustHash([]string{"c", "a", "b"}) {
+		t.Error("expected order independence")
+	}
+
+	if strong.MustHash(map[string]int{"a": 1, "b": 1}) == strong.MustHash(map[string]int{"a": 1}) {
+		t.Error("expected different map hashes")
+	}
+}
+
+func TestHasher_TypeAware(t *testing.T) {
+	type point struct{ X, Y int }
+
+	type size struct{ X, Y int }
+
+	pairs := [][2]any{
+		{int(42), uint64(42)},
+		{point{1, 2}, size{1, 2}},
+		{[]any{int8(1)}, []any{int16(1)}},
+		{map[string]any{"a": "x"}, map[string]any{"a": []byte("x")}},
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	aware := datahash.New(fnv.New64a, datahash.Options{TypeAware: true})
+
+	for _, p := range pairs {
+		if a, b := plain.MustHash(p[0]), plain.MustHash(p[1]); a != b {
+			t.Errorf("expected %#v and %#v to collide without TypeAware", p[0], p[1])
+		}
+
+		if a, b := aware.MustHash(p[0]), aware.MustHash(p[1]); a == b {
+			t.Errorf("expected different hashes for %#v and %#v", p[0], p[1])
+		}
+	}
+
+	hash, err := datahash.FuncFor[point](aware)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := hash(point{1, 2}); got != aware.MustHash(point{1, 2}) {
+		t.Errorf("expected FuncFor to match Hash")
+	}
+
+	hashAny, err := datahash.FuncFor[any](aware)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := hashAny(point{1, 2}); got != aware.MustHash(point{1, 2}) {
+		t.Errorf("expected FuncFor[any] to match Hash")
+	}
+}
+
+func TestHasher_MustHash(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	want, err := hasher.Hash([]int{1, 2, 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := hasher.MustHash([]int{1, 2, 3}); got != want {
+		t.Errorf("expected %d, got %d", want, got)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for unsupported type")
+		}
+	}()
+
+	hasher.MustHash(func() {})
+}
+
+func TestHasher_Encode(t *testing.T) {
+	value := map[string]any{"a": []int{1, 2}, "b": "c"}
+
+	for _, opts := range []datahash.Options{{}, {Header: true}} {
+		hasher := datahash.New(fnv.New64a, opts)
+
+		var buf bytes.Buffer
+
+		if err := hasher.Encode(value, &buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		h := fnv.New64a()
+		_, _ = h.Write(buf.Bytes())
+
+		want, err := hasher.Hash(value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if got := h.Sum64(); got != want {
+			t.Errorf("expected the encoded stream to hash to %d, got %d", want, got)
+		}
+
+		if opts.Header {
+			if _, _, err := datahash.ParseHeader(buf.Bytes()); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	if err := hasher.Encode("value", failingWriter{}); !errors.Is(err, errWrite) {
+		t.Errorf("expected write error, got %v", err)
+	}
+
+	hasher = datahash.New(fnv.New64a, datahash.Options{Header: true})
+
+	if err := hasher.Encode("value", &headerFailingWriter{}); !errors.Is(err, errWrite) {
+		t.Errorf("expected header write error, got %v", err)
+	}
+}
+
+var errWrite = errors.New("write failed")
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errWrite }
+
+// headerFailingWriter fails only the first write.
+type headerFailingWriter struct{ n int }
+
+func (w *headerFailingWriter) Write(p []byte) (int, error) {
+	if w.n++; w.n == 1 {
+		return 0, errWrite
+	}
+
+	return len(p), nil
+}
+
+func TestHasher_ConcurrentFirstUse(t *testing.T) {
+	type leaf struct {
+		Name string
+		Tags []string
+	}
+
+	type tree struct {
+		Leaves map[string]leaf
+		Any    []any
+		Ptr    *leaf
+	}
+
+	value := tree{
+		Leaves: map[string]leaf{"a": {Name: "a", Tags: []string{"x"}}},
+		Any:    []any{leaf{Name: "b"}, 1},
+		Ptr:    &leaf{Name: "c"},
+	}
+
+	want := datahash.New(fnv.New64a, datahash.Options{}).MustHash(value)
+
+	for range 20 {
+		hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+		var (
+			wg    sync.WaitGroup
+			start = make(chan struct{})
+		)
+
+		for range 8 {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				<-start
+
+				if got, err := hasher.Hash(value); err != nil || got != want {
+					t.Errorf("expected %d, got %d (%v)", want, got, err)
+				}
+
+				_ = hasher.Report()
+			}()
+		}
+
+		close(start)
+		wg.Wait()
+	}
+}
+
+func TestHasher_Scalars(t *testing.T) {
+	type (
+		myString string
+		myInt    int
+		myInt64  int64
+		myInt32  int32
+		myUint   uint
+		myUint64 uint64
+		myUint32 uint32
+		myFloat  float64
+		myBool   bool
+	)
+
+	// Named types take the reflection path, the predeclared ones the fast path.
+	pairs := [][2]any{
+		{"key", myString("key")},
+		{-42, myInt(-42)},
+		{int64(1 << 40), myInt64(1 << 40)},
+		{int32(-7), myInt32(-7)},
+		{uint(7), myUint(7)},
+		{uint64(1 << 63), myUint64(1 << 63)},
+		{uint32(9), myUint32(9)},
+		{math.Pi, myFloat(math.Pi)},
+		{math.Copysign(0, -1), myFloat(math.Copysign(0, -1))},
+		{true, myBool(true)},
+		{false, myBool(false)},
+	}
+
+	for _, opts := range []datahash.Options{
+		{},
+		{NormalizeFloats: true},
+		{Header: true},
+		{FoldCase: true, NormalizeNumbers: true},
+	} {
+		hasher := datahash.New(fnv.New64a, opts)
+
+		for _, p := range pairs {
+			if a, b := hasher.MustHash(p[0]), hasher.MustHash(p[1]); a != b {
+				t.Errorf("expected equal hashes for %T and %T with %+v", p[0], p[1], opts)
+			}
+		}
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	if allocs := testing.AllocsPerRun(100, func() { _, _ = hasher.Hash("key") }); allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+
+	datahash.RegisterHashFunc(hasher, func(v int, w io.Writer) error {
+		_, err := w.Write([]byte("int"))
+
+		return err
+	})
+
+	if hasher.MustHash(1) != hasher.MustHash(2) {
+		t.Error("expected registered hash functions to replace the fast path")
+	}
+}
+
+func TestHasher_Precompile(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	if err := hasher.Precompile(loginEvent{}, reflect.TypeFor[map[string][]int](), nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := len(hasher.Report()); got == 0 {
+		t.Error("expected compiled types in report")
+	}
+
+	err := hasher.Precompile(1, func() {}, reflect.TypeFor[func(int)]())
+	if err == nil {
+		t.Fatal("expected error for unsupported types")
+	}
+
+	if got := strings.Count(err.Error(), "unsupported type"); got != 2 {
+		t.Errorf("expected both unsupported types to be reported, got %v", err)
+	}
+}
+
+type entity struct {
+	ID   int
+	Name string
+}
+
+type graph struct {
+	Root     *entity
+	Children []*entity
+}
+
+func TestHasher_Identity(t *testing.T) {
+	byID := func(ptr reflect.Value) (any, bool) {
+		if e, ok := ptr.Interface().(*entity); ok {
+			return e.ID, true
+		}
+
+		return nil, false
+	}
+
+	// The same logical node is shared in the first graph and duplicated in the second.
+	shared := &entity{ID: 2, Name: "shared"}
+	first := graph{Root: shared, Children: []*entity{shared, {ID: 3}}}
+	second := graph{Root: &entity{ID: 2, Name: "shared"}, Children: []*entity{{ID: 2, Name: "shared"}, {ID: 3}}}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	a, _ := plain.Hash(first)
+	b, _ := plain.Hash(second)
+
+	if a == b {
+		t.Fatal("expected different hashes without Identity")
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{Identity: byID})
+
+	a, err := hasher.Hash(first)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err = hasher.Hash(second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a != b {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", b, a)
+	}
+}
+
+func TestHasher_StrictKinds(t *testing.T) {
+	type item struct {
+		Name   string
+		Events chan int
+	}
+
+	type config struct {
+		Items []item
+		Seq   iter.Seq[int]
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{StrictKinds: true})
+
+	_, err := hasher.Hash(config{})
+
+	var ke *datahash.KindError
+
+	if !errors.As(err, &ke) {
+		t.Fatalf("expected KindError, got %v", err)
+	}
+
+	if ke.Kind != reflect.Chan || ke.Path != "Items[].Events" {
+		t.Errorf("unexpected error: %v", ke)
+	}
+
+	if _, err := hasher.Hash(slices.Values([]int{1})); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := datahash.New(fnv.New64a, datahash.Options{}).Hash(config{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewDigest(t *testing.T) {
+	hasher := datahash.NewDigest(sha256.New, datahash.Options{UnorderedSlice: true})
+
+	a, err := hasher.HashBytes([]string{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, _ := hasher.HashBytes([]string{"c", "b", "a"})
+	c, _ := hasher.HashBytes([]string{"a", "b"})
+
+	if len(a) != sha256.Size || !bytes.Equal(a, b) || bytes.Equal(a, c) {
+		t.Errorf("unexpected digests:\n  %x\n  %x\n  %x", a, b, c)
+	}
+
+	short, _ := hasher.Hash([]string{"a", "b", "c"})
+
+	if short != binary.BigEndian.Uint64(a) {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", short, binary.BigEndian.Uint64(a))
+	}
+
+	// HashBytes of a 64-bit Hasher is the big-endian encoding of Hash.
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	sum, _ := plain.Hash("x")
+	sumBytes, _ := plain.HashBytes("x")
+
+	if !bytes.Equal(sumBytes, binary.BigEndian.AppendUint64(nil, sum)) {
+		t.Errorf("unexpected digest %x for hash %d", sumBytes, sum)
+	}
+}
+
+func TestNewDigest_FullSubDigests(t *testing.T) {
+	digest := func(hasher *datahash.Hasher, value any) []byte {
+		t.Helper()
+
+		sum, err := hasher.HashBytes(value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return sum
+	}
+
+	// Equal elements cancel out in the XOR-folded 64-bit sub-hashes of FormatV1.
+	a, b := []string{"a", "a"}, []string{"b", "b"}
+
+	v1 := datahash.NewDigest(sha256.New, datahash.Options{UnorderedSlice: true})
+
+	if !bytes.Equal(digest(v1, a), digest(v1, b)) {
+		t.Error("expected the FormatV1 digests to collide")
+	}
+
+	for _, opts := range []datahash.Options{
+		{UnorderedSlice: true, Format: datahash.FormatV2},
+		{UnorderedSlice: true, Format: datahash.FormatV2, SortedUnordered: true},
+	} {
+		v2 := datahash.NewDigest(sha256.New, opts)
+
+		if bytes.Equal(digest(v2, a), digest(v2, b)) {
+			t.Errorf("%+v: expected different digests", opts)
+		}
+
+		if !bytes.Equal(digest(v2, map[string]int{"a": 1, "b": 2}), digest(v2, map[string]int{"b": 2, "a": 1})) ||
+			!bytes.Equal(digest(v2, []string{"a", "b"}), digest(v2, []string{"b", "a"})) {
+			t.Errorf("%+v: expected order-independent digests", opts)
+		}
+	}
+
+	// The stream of a map holds the full sub-digest of its entry.
+	var stream bytes.Buffer
+
+	if err := datahash.NewDigest(sha256.New, datahash.Options{Format: datahash.FormatV2}).Encode(map[string]int{"a": 1}, &stream); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if stream.Len() < sha256.Size {
+		t.Errorf("stream of %d bytes is shorter than a sub-digest", stream.Len())
+	}
+}
+
+func TestNewSum(t *testing.T) {
+	hasher := datahash.NewSum(sha256.New, datahash.Options{})
+
+	got, err := hasher.Hash(map[string]int{"a": 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, _ := hasher.Hasher().HashBytes(map[string]int{"a": 1})
+
+	if len(got) != sha256.Size || !bytes.Equal(got, want) {
+		t.Errorf("digest mismatch:\n  got:  %x\n  want: %x", got, want)
+	}
+}
+
+func TestNew32(t *testing.T) {
+	value := struct {
+		Name string
+		Tags []string
+	}{"a", []string{"x", "y"}}
+
+	got, err := datahash.New32(crc32.NewIEEE, datahash.Options{}).Hash(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+
+	if err := datahash.New(fnv.New64a, datahash.Options{}).Encode(value, &buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := crc32.ChecksumIEEE(buf.Bytes()); got != want {
+		t.Errorf("expected %d, got %d", want, got)
+	}
+
+	a, _ := datahash.New32(fnv.New32a, datahash.Options{}).Hash(map[string]int{"a": 1, "b": 2})
+	b, _ := datahash.New32(fnv.New32a, datahash.Options{}).Hash(map[string]int{"b": 2, "a": 1})
+
+	if a != b || a == 0 {
+		t.Errorf("expected equal non-zero map hashes, got %d and %d", a, b)
+	}
+}
+
+func TestNewMaphash(t *testing.T) {
+	seed := maphash.MakeSeed()
+	value := map[string][]int{"a": {1, 2}}
+
+	a, err := datahash.NewMaphash(seed, datahash.Options{}).Hash(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, _ := datahash.NewMaphash(seed, datahash.Options{}).Hash(value)
+	other, _ := datahash.NewMaphash(maphash.MakeSeed(), datahash.Options{}).Hash(value)
+
+	if a != b || a == other {
+		t.Errorf("unexpected hashes: %d, %d, %d", a, b, other)
+	}
+}
+
+func TestNewHMAC(t *testing.T) {
+	value := struct{ User, Role string }{"alice", "admin"}
+
+	a, err := datahash.NewHMAC(sha256.New, []byte("key-1"), datahash.Options{}).HashBytes(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, _ := datahash.NewHMAC(sha256.New, []byte("key-1"), datahash.Options{}).HashBytes(value)
+	c, _ := datahash.NewHMAC(sha256.New, []byte("key-2"), datahash.Options{}).HashBytes(value)
+	plain, _ := datahash.NewDigest(sha256.New, datahash.Options{}).HashBytes(value)
+
+	if !hmac.Equal(a, b) || hmac.Equal(a, c) || bytes.Equal(a, plain) {
+		t.Errorf("unexpected MACs:\n  %x\n  %x\n  %x", a, b, c)
+	}
+}
+
+func TestHasher_PathError(t *testing.T) {
+	type meta struct {
+		Values []any
+	}
+
+	type item struct {
+		Meta meta
+	}
+
+	type order struct {
+		Items []item
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	value := order{Items: []item{{}, {}, {}, {Meta: meta{Values: []any{1, func() {}}}}}}
+
+	_, err := hasher.Hash(value)
+
+	var pe *datahash.PathError
+
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected PathError, got %v", err)
+	}
+
+	if pe.Path != "Items[3].Meta.Values[1]" {
+		t.Errorf("unexpected path: %s", pe.Path)
+	}
+
+	type static struct {
+		Items []struct{ Fn func() }
+	}
+
+	if _, err := hasher.Hash(static{}); !errors.As(err, &pe) || pe.Path != "Items[].Fn" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type failingBinary struct{}
+
+func (failingBinary) MarshalBinary() ([]byte, error) { return nil, errors.New("boom") }
+
+func TestHasher_MarshalerError(t *testing.T) {
+	type config struct {
+		Services map[string][]failingBinary
+	}
+
+	_, err := datahash.New(fnv.New64a, datahash.Options{}).Hash(config{Services: map[string][]failingBinary{"api": {{}, {}}}})
+
+	var (
+		me *datahash.MarshalerError
+		pe *datahash.PathError
+	)
+
+	if !errors.As(err, &me) || me.Strategy != datahash.StrategyBinary || me.Type != reflect.TypeFor[failingBinary]() {
+		t.Fatalf("expected MarshalerError, got %v", err)
+	}
+
+	if !errors.As(err, &pe) || pe.Path != "Services[api][0]" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+type xmlMarshaler struct {
+	ID   string
+	Temp []byte
+}
+
+func (x xmlMarshaler) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return e.EncodeElement(x.ID, start)
+}
+
+func TestHasher_XML(t *testing.T) {
+	a, b := xmlMarshaler{ID: "a", Temp: []byte{1}}, xmlMarshaler{ID: "a", Temp: []byte{2}}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	hasher := datahash.New(fnv.New64a, datahash.Options{XML: true})
+
+	if plain.MustHash(a) == plain.MustHash(b) {
+		t.Error("expected xml.Marshaler to be ignored without XML")
+	}
+
+	f := fnv.New64a()
+	_, _ = f.Write([]byte("<xmlMarshaler>a</xmlMarshaler>"))
+
+	if hasher.MustHash(a) != f.Sum64() || hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected values to hash by their XML encoding")
+	}
+
+	type tagged struct {
+		Value xmlMarshaler `datahash:"xml"`
+	}
+
+	if plain.MustHash(tagged{a}) != plain.MustHash(tagged{b}) {
+		t.Error("expected the xml tag directive to force xml.Marshaler")
+	}
+}
+
+func TestHasher_HashWriterTo(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	if hasher.MustHash(customStream{"abc"}) != hasher.MustHash(customHash{"abc"}) {
+		t.Error("expected HashWriterTo to write into the stream like HashWriter")
+	}
+
+	if hasher.MustHash(bothWriters{customStream{"abc"}}) != hasher.MustHash(customStream{"abc"}) {
+		t.Error("expected HashWriterTo to take precedence over HashWriter")
+	}
+
+	var buf bytes.Buffer
+
+	if err := hasher.Encode(customStream{"abc"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != "custom:abc" {
+		t.Errorf("expected the written bytes in the encoded stream, got %q", buf.String())
+	}
+}
+
+func TestHasher_Positional(t *testing.T) {
+	type v1 struct {
+		Name  string
+		Count int
+	}
+
+	type v2 struct {
+		Title string
+		Total int
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{Positional: true})
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	if hasher.MustHash(v1{"a", 1}) != hasher.MustHash(v2{"a", 1}) {
+		t.Error("expected renamed fields to hash equally in Positional mode")
+	}
+
+	if plain.MustHash(v1{"a", 1}) == plain.MustHash(v2{"a", 1}) {
+		t.Error("expected renamed fields to hash differently by default")
+	}
+
+	type reordered struct {
+		Count int
+		Name  string
+	}
+
+	if hasher.MustHash(v1{"a", 1}) == hasher.MustHash(reordered{1, "a"}) {
+		t.Error("expected reordered fields to hash differently")
+	}
+
+	type omitting struct {
+		A string `datahash:"omitzero"`
+		B string `datahash:"omitzero"`
+		C string `datahash:"omitzero"`
+	}
+
+	if hasher.MustHash(omitting{A: "x"}) == hasher.MustHash(omitting{B: "x"}) {
+		t.Error("expected skipped fields to keep the positions of later fields")
+	}
+
+	if hasher.MustHash(omitting{A: "x"}) != hasher.MustHash(struct{ A string }{"x"}) {
+		t.Error("expected trailing skipped fields to be dropped")
+	}
+
+	canonical, err := hasher.Canonicalize(omitting{B: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(canonical, []any{nil, "x"}) {
+		t.Errorf("expected positional canonical form, got %#v", canonical)
+	}
+}
+
+func TestHasher_SortedMaps(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{SortedMaps: true})
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	a := map[string]int{}
+	b := map[string]int{}
+
+	for i := range 100 {
+		a[fmt.Sprint(i)] = i
+		b[fmt.Sprint(99-i)] = 99 - i
+	}
+
+	if hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected sorted maps to hash independently of insertion order")
+	}
+
+	if hasher.MustHash(a) == plain.MustHash(a) {
+		t.Error("expected sorted maps to hash differently from folded maps")
+	}
+
+	b["0"] = 1
+
+	if hasher.MustHash(a) == hasher.MustHash(b) {
+		t.Error("expected changed values to change the hash")
+	}
+
+	if hasher.MustHash(map[string]string{"X": "v"}) != hasher.MustHash(struct{ X string }{"v"}) {
+		t.Error("expected the entries to be written as an ordered list")
+	}
+}
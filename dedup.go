@@ -0,0 +1,140 @@
+package datahash
+
+import (
+	"context"
+	"iter"
+)
+
+// SeenSet records hashes for deduplication.
+//
+// Add reports whether sum was added, i.e. it was not seen before. Implementations may
+// forget old hashes to bound memory, so a forgotten value is emitted again.
+type SeenSet interface {
+	Add(sum uint64) bool
+}
+
+// NewMapSet returns a SeenSet that remembers every hash. Its memory grows with the number of unique values.
+func NewMapSet() SeenSet {
+	return mapSet{}
+}
+
+type mapSet map[uint64]struct{}
+
+func (s mapSet) Add(sum uint64) bool {
+	if _, ok := s[sum]; ok {
+		return false
+	}
+
+	s[sum] = struct{}{}
+
+	return true
+}
+
+// NewWindowSet returns a SeenSet that remembers the last size unique hashes.
+// Duplicates are detected within the window only, which bounds memory for unbounded streams.
+func NewWindowSet(size int) SeenSet {
+	return &windowSet{
+		ring: make([]uint64, 0, max(size, 1)),
+		set:  make(map[uint64]struct{}, max(size, 1)),
+	}
+}
+
+type windowSet struct {
+	ring []uint64
+	next int
+	set  map[uint64]struct{}
+}
+
+func (s *windowSet) Add(sum uint64) bool {
+	if _, ok := s.set[sum]; ok {
+		return false
+	}
+
+	if len(s.ring) < cap(s.ring) {
+		s.ring = append(s.ring, sum)
+	} else {
+		delete(s.set, s.ring[s.next])
+
+		s.ring[s.next] = sum
+		s.next = (s.next + 1) % len(s.ring)
+	}
+
+	s.set[sum] = struct{}{}
+
+	return true
+}
+
+// Dedup returns an iterator over the content-unique values of seq.
+//
+// Values are hashed with h and dropped if seen reports their hash as known.
+// A nil seen remembers every hash, in a new set per iteration (see NewMapSet). Values that cannot be hashed
+// are yielded together with the error, so the caller decides whether to stop.
+func Dedup[T any](h *Hasher, seq iter.Seq[T], seen SeenSet) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		set := seen
+		if set == nil {
+			set = NewMapSet()
+		}
+
+		for value := range seq {
+			sum, err := h.Hash(value)
+			if err != nil {
+				if !yield(value, err) {
+					return
+				}
+
+				continue
+			}
+
+			if set.Add(sum) && !yield(value, nil) {
+				return
+			}
+		}
+	}
+}
+
+// DedupChan consumes in and sends its content-unique values to the returned channel,
+// which is closed once in is closed or ctx is done. Cancel ctx to stop a consumer that
+// no longer drains the returned channel; otherwise the goroutine blocks on its next send.
+// Values that cannot be hashed are passed to onError and dropped; a nil onError drops them
+// silently. The seen set is only used by the goroutine started by DedupChan.
+func DedupChan[T any](ctx context.Context, h *Hasher, in <-chan T, seen SeenSet, onError func(T, error)) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		for value, err := range Dedup(h, chanSeq(ctx, in), seen) {
+			if err != nil {
+				if onError != nil {
+					onError(value, err)
+				}
+
+				continue
+			}
+
+			select {
+			case out <- value:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+func chanSeq[T any](ctx context.Context, in <-chan T) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case value, ok := <-in:
+				if !ok || !yield(value) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
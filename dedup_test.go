@@ -0,0 +1,145 @@
+package datahash_test
+
+import (
+	"context"
+	"hash/fnv"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type event struct {
+	ID   int
+	Body string
+}
+
+func TestDedup(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	events := []event{{1, "a"}, {2, "b"}, {1, "a"}, {3, "c"}, {2, "b"}}
+
+	unique := datahash.Dedup(hasher, slices.Values(events), nil)
+
+	// Every iteration starts with an empty set.
+	for range 2 {
+		var got []event
+
+		for value, err := range unique {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got = append(got, value)
+		}
+
+		want := []event{{1, "a"}, {2, "b"}, {3, "c"}}
+
+		if !slices.Equal(got, want) {
+			t.Errorf("dedup mismatch:\n  got:  %v\n  want: %v", got, want)
+		}
+	}
+}
+
+func TestDedup_Window(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	var got []int
+
+	for value, err := range datahash.Dedup(hasher, slices.Values([]int{1, 2, 1, 3, 1}), datahash.NewWindowSet(2)) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got = append(got, value)
+	}
+
+	// 1 is forgotten after 2 and 3 filled the window.
+	want := []int{1, 2, 3, 1}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("dedup mismatch:\n  got:  %v\n  want: %v", got, want)
+	}
+}
+
+func TestDedupChan(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	in := make(chan any)
+
+	go func() {
+		defer close(in)
+
+		for _, v := range []any{"a", "b", "a", func() {}, "c", "b"} {
+			in <- v
+		}
+	}()
+
+	var errs []error
+
+	var got []any
+
+	for value := range datahash.DedupChan(context.Background(), hasher, in, datahash.NewWindowSet(16), func(_ any, err error) {
+		errs = append(errs, err)
+	}) {
+		got = append(got, value)
+	}
+
+	if !slices.Equal(got, []any{"a", "b", "c"}) {
+		t.Errorf("unexpected values: %v", got)
+	}
+
+	if len(errs) != 1 || errs[0] == nil {
+		t.Errorf("expected one error, got %v", errs)
+	}
+}
+
+func TestDedupChan_Cancel(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	in := make(chan string) // Never closed.
+
+	out := datahash.DedupChan(ctx, hasher, in, nil, nil)
+
+	in <- "a"
+
+	if got := <-out; got != "a" {
+		t.Fatalf("unexpected value: %q", got)
+	}
+
+	// Deliver a value nobody receives, so the goroutine blocks on its send.
+	in <- "b"
+
+	cancel()
+
+	waitClosed(t, out)
+
+	// A goroutine waiting on an idle input stops as well.
+	ctx, cancel = context.WithCancel(context.Background())
+
+	idle := datahash.DedupChan(ctx, hasher, make(chan string), nil, nil)
+
+	cancel()
+
+	waitClosed(t, idle)
+}
+
+func waitClosed[T any](t *testing.T, out <-chan T) {
+	t.Helper()
+
+	timeout := time.After(time.Second)
+
+	for {
+		select {
+		case _, ok := <-out:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("output channel not closed after cancel")
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package datahash
+
+import (
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+// defaultHasher is the Hasher used by the package-level functions, created on first use.
+var defaultHasher = sync.OnceValue(func() *Hasher {
+	return New(xxhash.New, Options{})
+})
+
+// Hash computes the hash of value with a default Hasher using xxhash and default Options.
+// It is meant for tests and small tools; services should create their own Hasher with New.
+func Hash(value any) (uint64, error) {
+	return defaultHasher().Hash(value)
+}
@@ -0,0 +1,30 @@
+package datahash_test
+
+import (
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHash(t *testing.T) {
+	value := map[string][]int{"a": {1, 2}, "b": {3}}
+
+	got, err := datahash.Hash(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, err := datahash.New(xxhash.New, datahash.Options{}).Hash(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != want {
+		t.Errorf("expected %d, got %d", want, got)
+	}
+
+	if _, err := datahash.Hash(func() {}); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
@@ -0,0 +1,201 @@
+package datahash
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+)
+
+// FieldDiff describes a path at which two values hash differently.
+type FieldDiff struct {
+	Path string
+	A, B any // Values at Path, or nil if the value is absent or skipped on that side.
+}
+
+// Diff reports the paths at which a and b differ in their hashed content, using the same
+// Options as Hash. Structs, maps and ordered slices and arrays are compared field by field,
+// entry by entry and index by index. Everything else, e.g. values with custom hashing or
+// unordered sets, is compared as a whole. Diff returns no differences iff a and b hash equally.
+func (h *Hasher) Diff(a, b any) ([]FieldDiff, error) {
+	if err := h.checkStream("Diff"); err != nil {
+		return nil, err
+	}
+
+	c := h.acquire()
+	defer h.containerPool.Put(c)
+
+	var diffs []FieldDiff
+
+	err := h.diff(reflect.ValueOf(a), reflect.ValueOf(b), h.cfg, "", c, nil, &diffs)
+
+	return diffs, err
+}
+
+func (h *Hasher) diff(a, b reflect.Value, cfg config, path string, c *container, seen []uintptr, diffs *[]FieldDiff) error {
+	for a.IsValid() && (a.Kind() == reflect.Pointer || a.Kind() == reflect.Interface) {
+		if a.Kind() == reflect.Pointer && !a.IsNil() {
+			if slices.Contains(seen, a.Pointer()) {
+				return nil
+			}
+
+			seen = append(seen, a.Pointer())
+		}
+
+		a = deref(a, cfg)
+	}
+
+	for b.IsValid() && (b.Kind() == reflect.Pointer || b.Kind() == reflect.Interface) {
+		b = deref(b, cfg)
+	}
+
+	sa, err := h.diffSum(a, cfg, c)
+	if err != nil {
+		return diffErr(err, path)
+	}
+
+	sb, err := h.diffSum(b, cfg, c)
+	if err != nil {
+		return diffErr(err, path)
+	}
+
+	if sa == sb && a.IsValid() == b.IsValid() {
+		return nil
+	}
+
+	if !a.IsValid() || !b.IsValid() || a.Type() != b.Type() {
+		*diffs = append(*diffs, FieldDiff{Path: path, A: diffValue(a), B: diffValue(b)})
+
+		return nil
+	}
+
+	t := a.Type()
+
+	cfg = cfg.with(typeOptions(t))
+
+	if info := h.typeInfo(t, cfg); info == nil || info.Strategy != StrategyKind {
+		*diffs = append(*diffs, FieldDiff{Path: path, A: diffValue(a), B: diffValue(b)})
+
+		return nil
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct:
+		sfs := h.structFields(t, cfg)
+
+		for i := range sfs {
+			sf := &sfs[i]
+			fa, fb := sf.value(a), sf.value(b)
+
+			if sf.skips(fa, cfg) {
+				fa = reflect.Value{}
+			}
+
+			if sf.skips(fb, cfg) {
+				fb = reflect.Value{}
+			}
+
+			if err := h.diff(fa, fb, sf.cfg, appendPath(path, string(sf.name)), c, seen, diffs); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case t.Kind() == reflect.Slice && !cfg.unorderedSlice && t.Elem().Kind() != reflect.Uint8,
+		t.Kind() == reflect.Array && !cfg.unorderedArray:
+		for i := range max(a.Len(), b.Len()) {
+			var ea, eb reflect.Value
+
+			if i < a.Len() {
+				ea = a.Index(i)
+			}
+
+			if i < b.Len() {
+				eb = b.Index(i)
+			}
+
+			if err := h.diff(ea, eb, h.cfg, path+"["+strconv.Itoa(i)+"]", c, seen, diffs); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case t.Kind() == reflect.Map:
+		keys := a.MapKeys()
+
+		// The map a is the set of the keys seen so far, since different keys may print alike.
+		for _, key := range b.MapKeys() {
+			if !a.MapIndex(key).IsValid() {
+				keys = append(keys, key)
+			}
+		}
+
+		slices.SortStableFunc(keys, func(x, y reflect.Value) int {
+			return cmp.Compare(fmt.Sprint(x), fmt.Sprint(y))
+		})
+
+		for _, key := range keys {
+			if err := h.diff(a.MapIndex(key), b.MapIndex(key), h.cfg, fmt.Sprintf("%s[%v]", path, key), c, seen, diffs); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	*diffs = append(*diffs, FieldDiff{Path: path, A: diffValue(a), B: diffValue(b)})
+
+	return nil
+}
+
+// diffSum returns the hash of v in a sub container of c, and 0 for invalid values.
+func (h *Hasher) diffSum(v reflect.Value, cfg config, c *container) (uint64, error) {
+	if !v.IsValid() {
+		return 0, nil
+	}
+
+	hf, err := h.makeHashFunc(v.Type(), cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	return h.subHash(c, func(tmp *container) error { return hf(v, tmp) })
+}
+
+// deref returns the element of a pointer or interface, with Options.ZeroNil applied to nil pointers.
+func deref(v reflect.Value, cfg config) reflect.Value {
+	if v.IsNil() {
+		if cfg.zeroNil && v.Kind() == reflect.Pointer {
+			return reflect.Zero(v.Type().Elem())
+		}
+
+		return reflect.Value{}
+	}
+
+	return v.Elem()
+}
+
+func diffErr(err error, path string) error {
+	if path == "" {
+		return err
+	}
+
+	return prefixPath(err, path)
+}
+
+func diffValue(v reflect.Value) any {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+
+	return v.Interface()
+}
+
+func appendPath(path, elem string) string {
+	if path == "" {
+		return elem
+	}
+
+	return path + "." + elem
+}
@@ -0,0 +1,91 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"reflect"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_Diff(t *testing.T) {
+	type address struct {
+		City string
+		Zip  string
+	}
+
+	type user struct {
+		Name    string
+		Secret  string `datahash:"-"`
+		Address *address
+		Tags    []string
+		Labels  map[string]string
+		Roles   []string `datahash:"set"`
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	a := user{
+		Name:    "alice",
+		Secret:  "x",
+		Address: &address{City: "Berlin", Zip: "10115"},
+		Tags:    []string{"a", "b"},
+		Labels:  map[string]string{"env": "prod", "team": "core"},
+		Roles:   []string{"admin", "dev"},
+	}
+
+	b := user{
+		Name:    "alice",
+		Secret:  "y",
+		Address: &address{City: "Berlin", Zip: "10117"},
+		Tags:    []string{"a", "c", "d"},
+		Labels:  map[string]string{"env": "dev", "team": "core", "tier": "1"},
+		Roles:   []string{"dev", "admin"},
+	}
+
+	diffs, err := hasher.Diff(a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []datahash.FieldDiff{
+		{Path: "Address.Zip", A: "10115", B: "10117"},
+		{Path: "Tags[1]", A: "b", B: "c"},
+		{Path: "Tags[2]", A: nil, B: "d"},
+		{Path: "Labels[env]", A: "prod", B: "dev"},
+		{Path: "Labels[tier]", A: nil, B: "1"},
+	}
+
+	if !reflect.DeepEqual(diffs, want) {
+		t.Errorf("diff mismatch:\n  got:  %v\n  want: %v", diffs, want)
+	}
+
+	b.Roles = []string{"dev"}
+
+	diffs, _ = hasher.Diff(a, b)
+
+	if len(diffs) != 6 || diffs[5].Path != "Roles" {
+		t.Errorf("expected unordered set to differ as a whole: %v", diffs)
+	}
+
+	if diffs, _ := hasher.Diff(a, a); len(diffs) != 0 {
+		t.Errorf("unexpected differences: %v", diffs)
+	}
+}
+
+func TestHasher_DiffMapKeys(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	// 1 and "1" print alike and interleave when sorted by their printed form.
+	a := map[any]int{1: 1, "1": 2, 2: 3}
+	b := map[any]int{1: 4, "1": 5, 2: 3}
+
+	diffs, err := hasher.Diff(a, b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(diffs) != 2 || diffs[0].Path != "[1]" || diffs[1].Path != "[1]" {
+		t.Errorf("expected each differing key to be reported once: %v", diffs)
+	}
+}
@@ -0,0 +1,62 @@
+package datahash
+
+import (
+	"errors"
+	"hash"
+)
+
+// ErrCollision is returned by DualHasher.Verify when exactly one of the two hashes matches,
+// which indicates an accidental collision in the matching algorithm.
+var ErrCollision = errors.New("datahash: hash collision detected")
+
+// Pair holds the hashes of a value computed with two independent algorithms.
+type Pair struct {
+	First, Second uint64
+}
+
+// DualHasher hashes values with two independent hash algorithms using the same Options.
+//
+// Use it in pipelines where false merges are costly: two distinct values are only
+// treated as equal if both hashes collide at the same time.
+type DualHasher struct {
+	first, second *Hasher
+}
+
+// NewDual creates a DualHasher from two hash.Hash64 constructors, e.g. xxhash.New and fnv.New64a.
+func NewDual[H1, H2 hash.Hash64](first func() H1, second func() H2, opts Options) *DualHasher {
+	return &DualHasher{
+		first:  New(first, opts),
+		second: New(second, opts),
+	}
+}
+
+// Hash computes both hashes of value.
+func (d *DualHasher) Hash(value any) (Pair, error) {
+	first, err := d.first.Hash(value)
+	if err != nil {
+		return Pair{}, err
+	}
+
+	second, err := d.second.Hash(value)
+	if err != nil {
+		return Pair{}, err
+	}
+
+	return Pair{First: first, Second: second}, nil
+}
+
+// Verify reports whether value hashes to want.
+//
+// It returns ErrCollision if only one of the two hashes matches.
+func (d *DualHasher) Verify(value any, want Pair) (bool, error) {
+	got, err := d.Hash(value)
+	if err != nil {
+		return false, err
+	}
+
+	if (got.First == want.First) != (got.Second == want.Second) {
+		return false, ErrCollision
+	}
+
+	return got == want, nil
+}
@@ -0,0 +1,38 @@
+package datahash_test
+
+import (
+	"errors"
+	"hash/fnv"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-sqlt/datahash"
+)
+
+func TestDualHasher(t *testing.T) {
+	hasher := datahash.NewDual(xxhash.New, fnv.New64a, datahash.Options{})
+
+	pair, err := hasher.Hash([]string{"a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pair.First == pair.Second {
+		t.Errorf("expected independent hashes, got %+v", pair)
+	}
+
+	ok, err := hasher.Verify([]string{"a", "b"}, pair)
+	if err != nil || !ok {
+		t.Errorf("expected verification to succeed, got %v, %v", ok, err)
+	}
+
+	ok, err = hasher.Verify([]string{"b", "a"}, pair)
+	if err != nil || ok {
+		t.Errorf("expected verification to fail without error, got %v, %v", ok, err)
+	}
+
+	_, err = hasher.Verify([]string{"a", "b"}, datahash.Pair{First: pair.First, Second: pair.Second + 1})
+	if !errors.Is(err, datahash.ErrCollision) {
+		t.Errorf("expected ErrCollision, got %v", err)
+	}
+}
@@ -0,0 +1,260 @@
+package datahash
+
+import (
+	"errors"
+	"reflect"
+)
+
+// HashEncoder can be implemented by types that want to define custom hashing behavior
+// with the same framing as the rest of the stream.
+//
+// Unlike HashWriter, EncodeHash receives an Encoder that separates list items, folds set
+// items order-independently and delimits nested collections, so custom encodings cannot
+// collide by shifting bytes between items. HashEncoder takes precedence over
+// HashWriterTo and HashWriter.
+type HashEncoder interface {
+	EncodeHash(e *Encoder) error
+}
+
+// Encoder writes framed values into the stream of a Hash call. It is only valid during EncodeHash.
+//
+// Every Write call and every nested list or set is one item of the enclosing list or set.
+// Items written outside of any list or set are separated like the items of a list, without
+// its delimiters. Key starts an item that is completed by the following value.
+type Encoder struct {
+	h      *Hasher
+	frames []encoderFrame // The first frame is the implicit top-level list.
+}
+
+type encoderFrame struct {
+	set   bool
+	first bool
+	key   bool       // A key was written, the next value completes the item.
+	out   *container // Container the collection is written to.
+	tmp   *container // Container of the current item of a set.
+	fold  folder
+}
+
+var errEncoderUnbalanced = errors.New("datahash: unbalanced Encoder lists or sets")
+
+// WriteString writes s as a single item.
+func (e *Encoder) WriteString(s string) error {
+	return e.item(func(c *container) error { return c.write(stringToBytes(s)) })
+}
+
+// WriteBytes writes b as a single item.
+func (e *Encoder) WriteBytes(b []byte) error {
+	return e.item(func(c *container) error { return c.write(b) })
+}
+
+// WriteUint64 writes v as a single item.
+func (e *Encoder) WriteUint64(v uint64) error {
+	return e.item(func(c *container) error { return c.writeUint64(v) })
+}
+
+// WriteInt64 writes v as a single item.
+func (e *Encoder) WriteInt64(v int64) error {
+	return e.item(func(c *container) error { return c.writeUint64(uint64(v)) }) //nolint:gosec
+}
+
+// WriteFloat64 writes v as a single item.
+func (e *Encoder) WriteFloat64(v float64) error {
+	return e.item(func(c *container) error { return c.writeFloat64(v) })
+}
+
+// WriteBool writes v as a single item.
+func (e *Encoder) WriteBool(v bool) error {
+	return e.item(func(c *container) error {
+		if v {
+			return c.write(byteTrue[:])
+		}
+
+		return c.write(byteFalse[:])
+	})
+}
+
+// WriteValue writes v as a single item, hashed like any other value by the Hasher.
+func (e *Encoder) WriteValue(v any) error {
+	rv := reflect.ValueOf(v)
+
+	if !rv.IsValid() {
+		return e.item(func(*container) error { return nil })
+	}
+
+	hf, err := e.h.makeHashFunc(rv.Type(), e.h.cfg)
+	if err != nil {
+		return err
+	}
+
+	return e.item(func(c *container) error { return hf(rv, c) })
+}
+
+// Key starts an item with the given name, like a struct field or map key.
+// The next value written completes the item.
+func (e *Encoder) Key(name string) error {
+	if err := e.beginItem(); err != nil {
+		return err
+	}
+
+	c := e.target()
+
+	if err := twoErr(
+		c.write(stringToBytes(name)),
+		c.write(colon[:]),
+	); err != nil {
+		return err
+	}
+
+	e.frames[len(e.frames)-1].key = true
+
+	return nil
+}
+
+// BeginList starts an ordered list. It must be closed with EndList.
+func (e *Encoder) BeginList() error {
+	if err := e.beginItem(); err != nil {
+		return err
+	}
+
+	c := e.target()
+
+	if err := c.write(startList[:]); err != nil {
+		return err
+	}
+
+	e.frames = append(e.frames, encoderFrame{first: true, out: c})
+
+	return nil
+}
+
+// EndList closes the list started by the last BeginList.
+func (e *Encoder) EndList() error {
+	if len(e.frames) == 1 || e.frames[len(e.frames)-1].set {
+		return errEncoderUnbalanced
+	}
+
+	f := e.frames[len(e.frames)-1]
+	e.frames = e.frames[:len(e.frames)-1]
+
+	if err := f.out.write(endList[:]); err != nil {
+		return err
+	}
+
+	e.endItem()
+
+	return nil
+}
+
+// BeginSet starts an unordered set, whose items are folded independently of their order.
+// It must be closed with EndSet.
+func (e *Encoder) BeginSet() error {
+	if err := e.beginItem(); err != nil {
+		return err
+	}
+
+	c := e.target()
+
+	if err := c.write(startSet[:]); err != nil {
+		return err
+	}
+
+	e.frames = append(e.frames, encoderFrame{set: true, out: c, tmp: e.h.sub(c), fold: e.h.folder()})
+
+	return nil
+}
+
+// EndSet closes the set started by the last BeginSet.
+func (e *Encoder) EndSet() error {
+	if len(e.frames) == 1 || !e.frames[len(e.frames)-1].set {
+		return errEncoderUnbalanced
+	}
+
+	f := e.frames[len(e.frames)-1]
+	e.frames = e.frames[:len(e.frames)-1]
+
+	e.h.containerPool.Put(f.tmp)
+
+	if err := f.fold.write(f.out); err != nil {
+		return err
+	}
+
+	if err := f.out.write(endSet[:]); err != nil {
+		return err
+	}
+
+	e.endItem()
+
+	return nil
+}
+
+func (e *Encoder) item(write func(c *container) error) error {
+	if err := e.beginItem(); err != nil {
+		return err
+	}
+
+	if err := write(e.target()); err != nil {
+		return err
+	}
+
+	e.endItem()
+
+	return nil
+}
+
+// target returns the container the current item is written to.
+func (e *Encoder) target() *container {
+	f := &e.frames[len(e.frames)-1]
+
+	if f.set {
+		return f.tmp
+	}
+
+	return f.out
+}
+
+func (e *Encoder) beginItem() error {
+	f := &e.frames[len(e.frames)-1]
+
+	switch {
+	case f.key:
+		return nil
+	case f.set:
+		f.tmp.Reset()
+
+		return nil
+	case f.first:
+		f.first = false
+
+		return nil
+	default:
+		return f.out.write(comma[:])
+	}
+}
+
+func (e *Encoder) endItem() {
+	f := &e.frames[len(e.frames)-1]
+	f.key = false
+
+	if f.set {
+		f.fold.addHash(f.tmp.hash)
+	}
+}
+
+// encode runs EncodeHash of v into c and checks that all lists and sets were closed.
+func (h *Hasher) encode(v HashEncoder, c *container) error {
+	e := &Encoder{h: h, frames: []encoderFrame{{first: true, out: c}}}
+
+	err := v.EncodeHash(e)
+
+	for _, f := range e.frames {
+		if f.set {
+			h.containerPool.Put(f.tmp)
+		}
+	}
+
+	if err == nil && len(e.frames) > 1 {
+		err = errEncoderUnbalanced
+	}
+
+	return err
+}
@@ -0,0 +1,137 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type tagSet struct {
+	Name string
+	Tags []string
+}
+
+func (s tagSet) EncodeHash(e *datahash.Encoder) error {
+	if err := e.BeginList(); err != nil {
+		return err
+	}
+
+	if err := e.Key("name"); err != nil {
+		return err
+	}
+
+	if err := e.WriteString(s.Name); err != nil {
+		return err
+	}
+
+	if err := e.Key("tags"); err != nil {
+		return err
+	}
+
+	if err := e.BeginSet(); err != nil {
+		return err
+	}
+
+	for _, tag := range s.Tags {
+		if err := e.WriteString(tag); err != nil {
+			return err
+		}
+	}
+
+	if err := e.EndSet(); err != nil {
+		return err
+	}
+
+	return e.EndList()
+}
+
+type topLevel []string
+
+func (s topLevel) EncodeHash(e *datahash.Encoder) error {
+	for _, v := range s {
+		if err := e.WriteString(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type unbalanced struct{}
+
+func (unbalanced) EncodeHash(e *datahash.Encoder) error {
+	return e.BeginSet()
+}
+
+func TestHashEncoder(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	hash := func(v any) uint64 {
+		t.Helper()
+
+		h, err := hasher.Hash(v)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return h
+	}
+
+	a := hash(tagSet{Name: "x", Tags: []string{"a", "b", "c"}})
+	b := hash(tagSet{Name: "x", Tags: []string{"c", "a", "b"}})
+
+	if a != b {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", b, a)
+	}
+
+	if hash(tagSet{Name: "x", Tags: []string{"ab", "c"}}) == hash(tagSet{Name: "x", Tags: []string{"a", "bc"}}) {
+		t.Error("expected framed items not to collide")
+	}
+
+	if hash(tagSet{Name: "xa", Tags: nil}) == hash(tagSet{Name: "x", Tags: []string{"a"}}) {
+		t.Error("expected keys to delimit items")
+	}
+
+	if hash(topLevel{"ab", "c"}) == hash(topLevel{"a", "bc"}) {
+		t.Error("expected top-level items not to collide")
+	}
+
+	if hash(topLevel{"ab"}) != hash("ab") {
+		t.Error("expected a single top-level item to hash like the value")
+	}
+
+	// Sets are folded like unordered slices.
+	unordered := datahash.New(fnv.New64a, datahash.Options{UnorderedSlice: true})
+
+	want, _ := unordered.Hash([]string{"a", "b"})
+
+	got, err := hasher.Hash(setOnly([]string{"b", "a"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != want {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", got, want)
+	}
+
+	if _, err := hasher.Hash(unbalanced{}); err == nil {
+		t.Error("expected error for unbalanced encoder")
+	}
+}
+
+type setOnly []string
+
+func (s setOnly) EncodeHash(e *datahash.Encoder) error {
+	if err := e.BeginSet(); err != nil {
+		return err
+	}
+
+	for _, v := range s {
+		if err := e.WriteString(v); err != nil {
+			return err
+		}
+	}
+
+	return e.EndSet()
+}
@@ -0,0 +1,103 @@
+package datahash
+
+import (
+	"bytes"
+	"errors"
+	"iter"
+)
+
+// Equal reports whether a and b have the same canonical stream, i.e. whether they are
+// equal under the configured Options without relying on the hash function.
+//
+// Both streams are produced incrementally and compared as they are written, so the traversal
+// stops at the first difference instead of hashing both values completely.
+func (h *Hasher) Equal(a, b any) (bool, error) {
+	if err := h.checkStream("Equal"); err != nil {
+		return false, err
+	}
+
+	var errA, errB error
+
+	nextA, stopA := iter.Pull(h.stream(a, &errA))
+	defer stopA()
+
+	nextB, stopB := iter.Pull(h.stream(b, &errB))
+	defer stopB()
+
+	var pa, pb []byte
+
+	for {
+		if len(pa) == 0 {
+			pa, _ = nextA()
+		}
+
+		if len(pb) == 0 {
+			pb, _ = nextB()
+		}
+
+		if err := errors.Join(errA, errB); err != nil {
+			return false, err
+		}
+
+		if len(pa) == 0 || len(pb) == 0 {
+			return len(pa) == len(pb), nil
+		}
+
+		n := min(len(pa), len(pb))
+
+		if !bytes.Equal(pa[:n], pb[:n]) {
+			return false, nil
+		}
+
+		pa, pb = pa[n:], pb[n:]
+	}
+}
+
+var errStreamStopped = errors.New("datahash: stream stopped")
+
+// stream returns an iterator over the chunks of the canonical stream of value.
+// The chunks are only valid until the next one is requested. Hashing errors are stored in err.
+func (h *Hasher) stream(value any, err *error) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		c := h.acquire()
+
+		hash := c.hash
+		c.hash = &streamHash{yield: yield}
+
+		if h.header != nil && !yield(h.header) {
+			c.hash = hash
+			h.containerPool.Put(c)
+
+			return
+		}
+
+		if e := h.hash(value, c); e != nil && !errors.Is(e, errStreamStopped) {
+			*err = e
+		}
+
+		c.hash = hash
+		h.containerPool.Put(c)
+	}
+}
+
+// streamHash is a hash.Hash64 that yields the written bytes instead of hashing them.
+type streamHash struct {
+	captureHash
+
+	yield   func([]byte) bool
+	stopped bool // Set once yield returned false; HashWriters may keep writing after errors.
+}
+
+func (s *streamHash) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if s.stopped || !s.yield(p) {
+		s.stopped = true
+
+		return 0, errStreamStopped
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,72 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"slices"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_Equal(t *testing.T) {
+	type item struct {
+		Name string
+		Tags []string
+		Meta map[string]int
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{UnorderedSlice: true})
+
+	tests := []struct {
+		name string
+		a, b any
+		want bool
+	}{
+		{"equal", item{"a", []string{"x", "y"}, map[string]int{"k": 1}}, item{"a", []string{"y", "x"}, map[string]int{"k": 1}}, true},
+		{"different", item{"a", nil, nil}, item{"b", nil, nil}, false},
+		{"prefix", []int{1, 2}, []int{1, 2, 3}, false},
+		{"nil", nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := hasher.Equal(tt.a, tt.b)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("Equal = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := hasher.Equal(func() {}, 1); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
+
+func TestHasher_EqualEarlyExit(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	consumed := 0
+
+	infinite := func(yield func(int) bool) {
+		for i := 0; ; i++ {
+			consumed++
+
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	equal, err := hasher.Equal(slices.Values([]any{1, 2}), infinite)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if equal || consumed > 3 {
+		t.Errorf("expected early exit, consumed %d elements", consumed)
+	}
+}
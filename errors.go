@@ -0,0 +1,72 @@
+package datahash
+
+import (
+	"errors"
+	"reflect"
+)
+
+var errorType = reflect.TypeFor[error]()
+
+// hashError returns a hashFunc writing the Error string of values of t, followed by the
+// unwrap tree of the error if Options.ErrorChain is set.
+func (h *Hasher) hashError(t reflect.Type, cfg config, info *TypeInfo) hashFunc {
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+			return nil
+		}
+
+		if !value.CanInterface() {
+			return errors.New("cannot use error on unexported fields that are not accessible via reflection")
+		}
+
+		if value.Kind() == reflect.Pointer && value.IsNil() {
+			return nil
+		}
+
+		i, ok := value.Interface().(error)
+		if !ok || i == nil {
+			h.warn(c, info, "nil error skipped")
+
+			return nil
+		}
+
+		if !h.opts.ErrorChain {
+			return c.write(stringToBytes(i.Error()))
+		}
+
+		return writeErrorChain(i, c)
+	}
+}
+
+// writeErrorChain writes the Error string of err and the chains of the errors it wraps,
+// via Unwrap() error or Unwrap() []error, as a nested list.
+func writeErrorChain(err error, c *container) error {
+	if err := twoErr(c.write(startList[:]), c.write(stringToBytes(err.Error()))); err != nil {
+		return err
+	}
+
+	var wrapped []error
+
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		wrapped = []error{e.Unwrap()}
+	case interface{ Unwrap() []error }:
+		wrapped = e.Unwrap()
+	}
+
+	for _, w := range wrapped {
+		if w == nil {
+			continue
+		}
+
+		if err := c.write(comma[:]); err != nil {
+			return err
+		}
+
+		if err := writeErrorChain(w, c); err != nil {
+			return err
+		}
+	}
+
+	return c.write(endList[:])
+}
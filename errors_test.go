@@ -0,0 +1,71 @@
+package datahash_test
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type opError struct {
+	Op  string
+	err error
+}
+
+func (e *opError) Error() string { return e.Op + " failed" }
+func (e *opError) Unwrap() error { return e.err }
+
+func TestHasher_Errors(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{Errors: true})
+
+	type result struct {
+		Value int
+		Err   error
+	}
+
+	f := fnv.New64a()
+	_, _ = f.Write([]byte("boom"))
+
+	if hasher.MustHash(errors.New("boom")) != f.Sum64() {
+		t.Error("expected errors to hash by their Error string")
+	}
+
+	if hasher.MustHash(result{Err: errors.New("boom")}) != hasher.MustHash(result{Err: fmt.Errorf("%s", "boom")}) {
+		t.Error("expected error fields of different concrete types to hash by their message")
+	}
+
+	chain := datahash.New(fnv.New64a, datahash.Options{Errors: true, ErrorChain: true})
+
+	a := result{Err: &opError{Op: "read", err: fs.ErrNotExist}}
+	b := result{Err: &opError{Op: "read", err: fs.ErrPermission}}
+
+	if hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected errors with equal messages to hash equally without ErrorChain")
+	}
+
+	if chain.MustHash(a) == chain.MustHash(b) {
+		t.Error("expected ErrorChain to hash the wrapped errors")
+	}
+
+	joined := errors.Join(fs.ErrNotExist, fs.ErrPermission)
+
+	if chain.MustHash(joined) == chain.MustHash(errors.Join(fs.ErrPermission, fs.ErrNotExist)) {
+		t.Error("expected ErrorChain to keep the order of joined errors")
+	}
+
+	if chain.MustHash(result{Err: fmt.Errorf("read: %w", fs.ErrNotExist)}) != chain.MustHash(result{Err: fmt.Errorf("read: %w", fs.ErrNotExist)}) {
+		t.Error("expected equal wrapped errors to hash equally")
+	}
+
+	canonical, err := hasher.Canonicalize(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := canonical.(map[string]any)["Err"]; got != "read failed" {
+		t.Errorf("expected errors to canonicalize to their message, got %#v", got)
+	}
+}
@@ -0,0 +1,119 @@
+// Package etag provides an HTTP middleware that derives ETags from response bodies using a datahash.Hasher.
+//
+// Usage:
+//
+//	hasher := datahash.New(xxhash.New, datahash.Options{})
+//	http.Handle("/", etag.Handler(hasher, mux))
+package etag
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-sqlt/datahash"
+)
+
+// Handler wraps next so that successful GET and HEAD responses are buffered and
+// tagged with an ETag computed from the response body by hasher. HEAD requests are
+// served by next as GET requests without sending the body, so both get the same ETag.
+//
+// Requests whose If-None-Match header matches the ETag are answered with
+// 304 Not Modified and an empty body. Responses that already carry an ETag,
+// and responses with a status other than 200 OK, are passed through unchanged.
+func Handler(hasher *datahash.Hasher, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+
+			return
+		}
+
+		rec := &recorder{header: w.Header(), status: http.StatusOK, head: r.Method == http.MethodHead}
+
+		if rec.head {
+			r = r.Clone(r.Context())
+			r.Method = http.MethodGet
+		}
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status != http.StatusOK || w.Header().Get("ETag") != "" {
+			rec.flush(w)
+
+			return
+		}
+
+		sum, err := hasher.Hash(rec.body.Bytes())
+		if err != nil {
+			rec.flush(w)
+
+			return
+		}
+
+		tag := `"` + strconv.FormatUint(sum, 16) + `"`
+
+		w.Header().Set("ETag", tag)
+
+		if match(r.Header.Get("If-None-Match"), tag) {
+			w.Header().Del("Content-Length")
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		rec.flush(w)
+	})
+}
+
+// match reports whether the If-None-Match header value matches tag using weak comparison.
+func match(header, tag string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recorder buffers the status and body written by the wrapped handler.
+// Headers are written directly to the underlying http.ResponseWriter.
+type recorder struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+	head        bool // Whether the body is dropped for a HEAD request.
+}
+
+// flush writes the recorded status and, unless the request is a HEAD request, the body to w.
+func (r *recorder) flush(w http.ResponseWriter) {
+	w.WriteHeader(r.status)
+
+	if !r.head {
+		_, _ = w.Write(r.body.Bytes())
+	}
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+
+	r.status = status
+	r.wroteHeader = true
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+
+	return r.body.Write(b)
+}
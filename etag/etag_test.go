@@ -0,0 +1,99 @@
+package etag_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-sqlt/datahash"
+	"github.com/go-sqlt/datahash/etag"
+)
+
+func TestHandler(t *testing.T) {
+	hasher := datahash.New(xxhash.New, datahash.Options{})
+
+	handler := etag.Handler(hasher, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+
+	if rec.Body.String() != "hello" {
+		t.Fatalf("unexpected body: %q", rec.Body.String())
+	}
+
+	tag := rec.Header().Get("ETag")
+	if tag == "" {
+		t.Fatal("missing ETag header")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", `"other", W/`+tag)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+
+	if rec.Body.Len() != 0 {
+		t.Fatalf("unexpected body: %q", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("If-None-Match", tag)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" {
+		t.Fatalf("expected POST to pass through, got status %d and ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+}
+
+func TestHandler_Head(t *testing.T) {
+	hasher := datahash.New(xxhash.New, datahash.Options{})
+
+	handler := etag.Handler(hasher, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "hello.txt", time.Time{}, strings.NewReader("hello"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	tag := rec.Header().Get("ETag")
+	if tag == "" {
+		t.Fatal("missing ETag header")
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodHead, "/", nil))
+
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != tag {
+		t.Fatalf("expected HEAD to get the ETag of GET, got status %d and ETag %q", rec.Code, rec.Header().Get("ETag"))
+	}
+
+	if rec.Body.Len() != 0 {
+		t.Fatalf("unexpected body: %q", rec.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodHead, "/", nil)
+	req.Header.Set("If-None-Match", tag)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("unexpected status: %d", rec.Code)
+	}
+}
@@ -0,0 +1,177 @@
+package datahash
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Strategy names the way a type is hashed.
+type Strategy string
+
+// Strategies reported by TypeInfo.
+const (
+	StrategyHashWriter   Strategy = "HashWriter"
+	StrategyHashWriterTo Strategy = "HashWriterTo"
+	StrategyHashEncoder  Strategy = "HashEncoder"
+	StrategyHashMethod   Strategy = "hash method"
+	StrategyCustom       Strategy = "custom"
+	StrategyBinary       Strategy = "BinaryMarshaler"
+	StrategyText         Strategy = "TextMarshaler"
+	StrategyJSON         Strategy = "JSONMarshaler"
+	StrategyXML          Strategy = "XMLMarshaler"
+	StrategyStringer     Strategy = "Stringer"
+	StrategyGob          Strategy = "GobEncoder"
+	StrategyError        Strategy = "error"
+	StrategyBuiltin      Strategy = "built-in"
+	StrategyKind         Strategy = "kind"
+	StrategyUnsupported  Strategy = "unsupported"
+)
+
+// TypeInfo describes how a type is hashed by a Hasher.
+//
+// TypeInfo graphs of recursive types contain cycles.
+type TypeInfo struct {
+	Type     reflect.Type
+	Strategy Strategy
+	Reason   string      // Why the strategy was chosen, or the error for unsupported types.
+	Fields   []FieldInfo // Struct fields in declared order, including skipped ones.
+	Key      *TypeInfo   // Map key type.
+	Elem     *TypeInfo   // Element type of pointers, arrays, slices and maps.
+
+	skips      atomic.Uint64
+	viaPointer bool // Whether addressable values are hashed via *Type.
+}
+
+// Skips returns how many values of the type were skipped without an error so far,
+// for example nil interfaces or revisited pointers (see Options.WarnFunc).
+func (info *TypeInfo) Skips() uint64 {
+	return info.skips.Load()
+}
+
+// FieldInfo describes how a struct field is hashed.
+type FieldInfo struct {
+	Name    string
+	Skipped bool
+	Reason  string    // Why the field is skipped.
+	Info    *TypeInfo // Nil if the field is skipped.
+}
+
+// ExplainType describes which strategy t and each of its fields, keys and elements
+// resolve to and why, one type per line. Compiling t as a side effect, it can be used
+// to audit how values will be hashed before relying on it.
+//
+// Example output:
+//
+//	datahash_test.order: kind (ordered fields)
+//	  Secret: skipped (tagged datahash:"-")
+//	  Created time.Time: BinaryMarshaler (implements encoding.BinaryMarshaler)
+//	  Items []string: kind (ordered list)
+//	    elem string: kind
+func (h *Hasher) ExplainType(t reflect.Type) string {
+	_, _ = h.makeHashFunc(t, h.cfg)
+
+	h.compileMu.Lock()
+	defer h.compileMu.Unlock()
+
+	var b strings.Builder
+
+	h.typeInfo(t, h.cfg).write(&b, "", "", nil)
+
+	return b.String()
+}
+
+// Report returns the TypeInfo of every type compiled by h so far, sorted by type name.
+// It is suitable for dumping at startup in services that treat their hashing
+// configuration as part of their contract.
+func (h *Hasher) Report() []*TypeInfo {
+	var infos []*TypeInfo
+
+	h.compileMu.Lock()
+	defer h.compileMu.Unlock()
+
+	h.typeInfoMap.Range(func(_, v any) bool {
+		if info := v.(*TypeInfo); !slices.Contains(infos, info) {
+			infos = append(infos, info)
+		}
+
+		return true
+	})
+
+	slices.SortStableFunc(infos, func(a, b *TypeInfo) int {
+		return strings.Compare(a.Type.String(), b.Type.String())
+	})
+
+	return infos
+}
+
+func (h *Hasher) typeInfo(t reflect.Type, cfg config) *TypeInfo {
+	v, _ := h.typeInfoMap.Load(cacheKey{typ: t, cfg: cfg})
+	info, _ := v.(*TypeInfo)
+
+	return info
+}
+
+// String describes info and the types it contains, one type per line.
+func (info *TypeInfo) String() string {
+	var b strings.Builder
+
+	info.write(&b, "", "", nil)
+
+	return b.String()
+}
+
+func (info *TypeInfo) write(b *strings.Builder, indent, label string, stack []*TypeInfo) {
+	if info == nil {
+		return
+	}
+
+	b.WriteString(indent)
+	b.WriteString(label)
+	b.WriteString(info.Type.String())
+	b.WriteString(": ")
+
+	if slices.Contains(stack, info) {
+		b.WriteString("recursive\n")
+
+		return
+	}
+
+	b.WriteString(string(info.Strategy))
+
+	if info.Reason != "" {
+		b.WriteString(" (")
+		b.WriteString(info.Reason)
+		b.WriteString(")")
+	}
+
+	if skips := info.Skips(); skips > 0 {
+		b.WriteString(" [")
+		b.WriteString(strconv.FormatUint(skips, 10))
+		b.WriteString(" skipped]")
+	}
+
+	b.WriteByte('\n')
+
+	stack = append(stack, info)
+	indent += "  "
+
+	for _, f := range info.Fields {
+		if f.Skipped {
+			b.WriteString(indent)
+			b.WriteString(f.Name)
+			b.WriteString(": skipped (")
+			b.WriteString(f.Reason)
+			b.WriteString(")\n")
+
+			continue
+		}
+
+		f.Info.write(b, indent, f.Name+" ", stack)
+	}
+
+	info.Key.write(b, indent, "key ", stack)
+	info.Elem.write(b, indent, "elem ", stack)
+}
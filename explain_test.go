@@ -0,0 +1,72 @@
+package datahash_test
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type explained struct {
+	Secret  string `datahash:"-"`
+	Created time.Time
+	Items   []string
+	Fn      func()
+	Next    *explained
+}
+
+func TestHasher_ExplainType(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	got := hasher.ExplainType(reflect.TypeFor[explained]())
+	want := `datahash_test.explained: unsupported (datahash: unsupported type: "func()" (missing HashWriter or marshaling interface) (at Fn))
+  Secret: skipped (tagged datahash:"-")
+  Created time.Time: BinaryMarshaler (implements encoding.BinaryMarshaler)
+  Items []string: kind (ordered list)
+    elem string: kind
+  Fn func(): unsupported (datahash: unsupported type: "func()" (missing HashWriter or marshaling interface))
+`
+
+	if got != want {
+		t.Errorf("explanation mismatch:\n  got:\n%s\n  want:\n%s", got, want)
+	}
+
+	got = hasher.ExplainType(reflect.TypeFor[*node]())
+	want = `*datahash_test.node: kind
+  elem datahash_test.node: kind (ordered fields)
+    Value int: kind
+    Next *datahash_test.node: recursive
+`
+
+	if got != want {
+		t.Errorf("explanation mismatch:\n  got:\n%s\n  want:\n%s", got, want)
+	}
+}
+
+func TestHasher_Report(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	if _, err := hasher.Hash([]any{nil, 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+
+	for _, info := range hasher.Report() {
+		got = append(got, fmt.Sprintf("%s %s %d", info.Type, info.Strategy, info.Skips()))
+	}
+
+	want := []string{
+		"[]interface {} kind 0",
+		"int kind 0",
+		"interface {} kind 1",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("report mismatch:\n  got:  %q\n  want: %q", got, want)
+	}
+}
@@ -0,0 +1,39 @@
+package datahash
+
+import "reflect"
+
+// marshalFallback returns the hash function that Options.MarshalFallback uses for values of type t
+// whose marshaler with strategy failed, and notes it in info. A failed BinaryMarshaler falls back
+// to TextMarshaler, a failed TextMarshaler to json.Marshaler, and a failed json.Marshaler, or
+// a type without the next marshaler, to its kind. It returns nil if the fallback does not compile.
+func (h *Hasher) marshalFallback(t reflect.Type, cfg config, failed Strategy, info *TypeInfo) hashFunc {
+	if !h.opts.MarshalFallback {
+		return nil
+	}
+
+	cfg.prefer = StrategyKind
+
+	switch failed {
+	case StrategyBinary:
+		if t.Implements(textMarshalerType) {
+			cfg.prefer, cfg.text = StrategyText, true
+
+			break
+		}
+
+		fallthrough
+	case StrategyText:
+		if t.Implements(jsonMarshalerType) {
+			cfg.prefer, cfg.json = StrategyJSON, true
+		}
+	}
+
+	hf, err := h.compile(t, cfg)
+	if err != nil {
+		return nil
+	}
+
+	info.Reason += "; falls back to " + string(h.typeInfo(t, cfg).Strategy) + " on error"
+
+	return hf
+}
@@ -0,0 +1,88 @@
+package datahash_test
+
+import (
+	"errors"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+// fragile fails to marshal to binary while it is not sealed.
+type fragile struct {
+	Name   string
+	Sealed bool
+}
+
+func (f fragile) MarshalBinary() ([]byte, error) {
+	if !f.Sealed {
+		return nil, errors.New("not sealed")
+	}
+
+	return []byte("sealed:" + f.Name), nil
+}
+
+func (f fragile) MarshalText() ([]byte, error) {
+	return []byte(f.Name), nil
+}
+
+// brittle fails to marshal to binary and has no other marshaler.
+type brittle struct {
+	ID int
+}
+
+func (brittle) MarshalBinary() ([]byte, error) {
+	return nil, errors.New("unsupported")
+}
+
+func TestHasher_MarshalFallback(t *testing.T) {
+	strict := datahash.New(fnv.New64a, datahash.Options{})
+
+	var merr *datahash.MarshalerError
+
+	if _, err := strict.Hash(fragile{Name: "a"}); !errors.As(err, &merr) {
+		t.Fatalf("expected a MarshalerError without MarshalFallback, got %v", err)
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{MarshalFallback: true})
+
+	if hasher.MustHash(fragile{Name: "a", Sealed: true}) != strict.MustHash(fragile{Name: "a", Sealed: true}) {
+		t.Error("expected succeeding marshalers to hash as without MarshalFallback")
+	}
+
+	type plain struct{ F fragile }
+
+	type text struct {
+		F fragile `datahash:"text"`
+	}
+
+	if hasher.MustHash(plain{fragile{Name: "a"}}) != hasher.MustHash(text{fragile{Name: "a"}}) {
+		t.Error("expected a failed BinaryMarshaler to fall back to TextMarshaler")
+	}
+
+	type shadow struct {
+		ID int
+	}
+
+	if hasher.MustHash(brittle{ID: 1}) != hasher.MustHash(shadow{ID: 1}) {
+		t.Error("expected a failed BinaryMarshaler without other marshalers to fall back to the fields")
+	}
+
+	if hasher.MustHash(&brittle{ID: 1}) != hasher.MustHash(brittle{ID: 1}) {
+		t.Error("expected pointers to fall back like their values")
+	}
+
+	if hasher.MustHash(brittle{ID: 1}) == hasher.MustHash(brittle{ID: 2}) {
+		t.Error("expected fallback hashes to depend on the fields")
+	}
+
+	if info := hasher.ExplainType(reflect.TypeFor[brittle]()); !strings.Contains(info, "falls back to kind on error") {
+		t.Errorf("expected the fallback in the explanation, got %q", info)
+	}
+
+	if hasher.Header() == strict.Header() {
+		t.Error("expected MarshalFallback in the header")
+	}
+}
@@ -0,0 +1,82 @@
+package datahash
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// fieldReader hashes a scalar struct field in place from its address.
+type fieldReader func(p unsafe.Pointer, c *container) error
+
+// scalarReader returns a fieldReader producing the same stream as the compiled hash function of
+// the field sf, or nil if the field must be read via its reflect.Value, e.g. because its type has
+// a custom strategy or zero values may be skipped.
+func (h *Hasher) scalarReader(sf reflect.StructField, cfg, fcfg config, tag fieldTag) fieldReader {
+	if cfg.ignoreZero || tag.omitZero || tag.omitEmpty || tag.jsonEmpty || h.opts.NormalizeNumbers {
+		return nil
+	}
+
+	if info := h.typeInfo(sf.Type, fcfg); info == nil || info.Strategy != StrategyKind || h.marshalsViaPointer(sf.Type, fcfg) {
+		return nil
+	}
+
+	switch sf.Type.Kind() {
+	case reflect.String:
+		if h.opts.NormalizeUnicode || fcfg.foldCase || h.opts.StringTransform != nil {
+			return nil
+		}
+
+		return func(p unsafe.Pointer, c *container) error { return c.write(stringToBytes(*(*string)(p))) }
+	case reflect.Int:
+		return func(p unsafe.Pointer, c *container) error { return c.writeUint64(uint64(*(*int)(p))) }
+	case reflect.Int8:
+		return func(p unsafe.Pointer, c *container) error { return c.writeUint64(uint64(*(*int8)(p))) }
+	case reflect.Int16:
+		return func(p unsafe.Pointer, c *container) error { return c.writeUint64(uint64(*(*int16)(p))) }
+	case reflect.Int32:
+		return func(p unsafe.Pointer, c *container) error { return c.writeUint64(uint64(*(*int32)(p))) }
+	case reflect.Int64:
+		return func(p unsafe.Pointer, c *container) error { return c.writeUint64(uint64(*(*int64)(p))) }
+	case reflect.Uint:
+		return func(p unsafe.Pointer, c *container) error { return c.writeUint64(uint64(*(*uint)(p))) }
+	case reflect.Uint8:
+		return func(p unsafe.Pointer, c *container) error { return c.writeUint64(uint64(*(*uint8)(p))) }
+	case reflect.Uint16:
+		return func(p unsafe.Pointer, c *container) error { return c.writeUint64(uint64(*(*uint16)(p))) }
+	case reflect.Uint32:
+		return func(p unsafe.Pointer, c *container) error { return c.writeUint64(uint64(*(*uint32)(p))) }
+	case reflect.Uint64:
+		return func(p unsafe.Pointer, c *container) error { return c.writeUint64(*(*uint64)(p)) }
+	case reflect.Float32:
+		return h.floatReader(func(p unsafe.Pointer) float64 { return float64(*(*float32)(p)) })
+	case reflect.Float64:
+		return h.floatReader(func(p unsafe.Pointer) float64 { return *(*float64)(p) })
+	case reflect.Bool:
+		return func(p unsafe.Pointer, c *container) error {
+			if *(*bool)(p) {
+				return c.write(byteTrue[:])
+			}
+
+			return c.write(byteFalse[:])
+		}
+	}
+
+	return nil
+}
+
+func (h *Hasher) floatReader(load func(p unsafe.Pointer) float64) fieldReader {
+	if h.opts.NormalizeFloats {
+		return func(p unsafe.Pointer, c *container) error { return c.writeFloat64(normalizeFloat(load(p))) }
+	}
+
+	return func(p unsafe.Pointer, c *container) error { return c.writeFloat64(load(p)) }
+}
+
+// structBase returns the address of the struct value v, or nil if it is not addressable.
+func structBase(v reflect.Value) unsafe.Pointer {
+	if !v.CanAddr() {
+		return nil
+	}
+
+	return v.Addr().UnsafePointer()
+}
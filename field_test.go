@@ -0,0 +1,84 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"math"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type scalarFields struct {
+	S   string
+	I   int
+	I8  int8
+	I16 int16
+	I32 int32
+	I64 int64
+	U   uint
+	U8  uint8
+	U16 uint16
+	U32 uint32
+	U64 uint64
+	F32 float32
+	F64 float64
+	B   bool
+	Tag string `datahash:"fold,name=tag"`
+	Opt int    `datahash:"omitzero"`
+	P   *int
+}
+
+func TestHasher_ScalarFields(t *testing.T) {
+	n := 7
+	values := []scalarFields{
+		{},
+		{S: "Hello", I: -1, I8: -2, I16: -3, I32: -4, I64: math.MinInt64, U: 1, U8: 2, U16: 3, U32: 4, U64: math.MaxUint64,
+			F32: 1.5, F64: math.Copysign(0, -1), B: true, Tag: "MiXed", P: &n},
+		{F64: math.NaN(), Opt: 3},
+	}
+
+	for _, opts := range []datahash.Options{
+		{},
+		{IgnoreZero: true},
+		{NormalizeFloats: true},
+		{NormalizeNumbers: true},
+		{FoldCase: true},
+		{UnorderedStruct: true},
+	} {
+		hasher := datahash.New(fnv.New64a, opts)
+
+		for _, v := range values {
+			// Struct values stored in interfaces are not addressable and read via
+			// reflection, those behind pointers and in slices are read in place.
+			for _, pair := range [][2]any{
+				{v, &v},
+				{[]any{v}, []scalarFields{v}},
+			} {
+				want, err := hasher.Hash(pair[0])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := hasher.Hash(pair[1])
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got != want {
+					t.Errorf("%+v: hash of addressable %T differs: %d != %d", opts, pair[1], got, want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkHasher_ScalarFields(b *testing.B) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+	value := &scalarFields{S: "Hello", I: 1, U: 2, F64: 3, B: true}
+
+	for b.Loop() {
+		if _, err := hasher.Hash(value); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
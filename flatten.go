@@ -0,0 +1,78 @@
+package datahash
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+)
+
+// promoted returns the fields of the embedded struct field sf that are flattened into its parent,
+// if Options.FlattenEmbedded or the flatten directive applies. Like encoding/json, embedded
+// structs and pointers to structs without an explicit name are flattened. They must be hashed
+// by their fields, so embedded types hashed by a marshaler or built-in handling stay nested,
+// as do embedded recursive types.
+func (h *Hasher) promoted(sf reflect.StructField, tag fieldTag, fcfg config) ([]structField, bool) {
+	if !sf.Anonymous || !h.opts.FlattenEmbedded && !tag.flatten || tag.name != "" {
+		return nil, false
+	}
+
+	t := sf.Type
+
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	if info := h.typeInfo(t, fcfg); info == nil || info.Strategy != StrategyKind {
+		return nil, false
+	}
+
+	sfs, ok := h.structFieldsMap.Load(cacheKey{typ: t, cfg: fcfg.with(typeOptions(t))})
+	if !ok {
+		return nil, false
+	}
+
+	return sfs.([]structField), true
+}
+
+// structFields returns the fields of the compiled struct type t that are hashed under cfg.
+func (h *Hasher) structFields(t reflect.Type, cfg config) []structField {
+	v, _ := h.structFieldsMap.Load(cacheKey{typ: t, cfg: cfg.with(typeOptions(t))})
+	sfs, _ := v.([]structField)
+
+	return sfs
+}
+
+// indexPath returns the index path of sf within its struct.
+func (sf *structField) indexPath() []int {
+	if sf.path != nil {
+		return sf.path
+	}
+
+	return []int{sf.idx}
+}
+
+// addField appends sf to the fields of the struct type t. Like encoding/json, a field promoted
+// from an embedded struct is shadowed by a field of the same name at a shallower depth, while
+// fields of the same name at the same depth are rejected.
+func addField(sfs []structField, sf structField, t reflect.Type) ([]structField, error) {
+	for i, other := range sfs {
+		if string(other.name) != string(sf.name) {
+			continue
+		}
+
+		switch depth, otherDepth := len(sf.indexPath()), len(other.indexPath()); {
+		case depth > otherDepth:
+			return sfs, nil
+		case depth < otherDepth:
+			return append(slices.Delete(sfs, i, i+1), sf), nil
+		}
+
+		return nil, fmt.Errorf("datahash: duplicate field name %q in %s", sf.name, t)
+	}
+
+	return append(sfs, sf), nil
+}
@@ -0,0 +1,97 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"testing"
+	"time"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type baseModel struct {
+	ID      int
+	Created string
+}
+
+func TestHasher_FlattenEmbedded(t *testing.T) {
+	type flat struct {
+		ID      int
+		Created string
+		Name    string
+	}
+
+	type embedded struct {
+		baseModel
+		Name string
+	}
+
+	type embeddedPointer struct {
+		*baseModel
+		Name string
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{FlattenEmbedded: true})
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	want := hasher.MustHash(flat{ID: 1, Created: "today", Name: "x"})
+
+	if got := hasher.MustHash(embedded{baseModel{1, "today"}, "x"}); got != want {
+		t.Error("expected embedded fields to hash like fields of the parent")
+	}
+
+	if got := hasher.MustHash(embeddedPointer{&baseModel{1, "today"}, "x"}); got != want {
+		t.Error("expected fields of embedded pointers to hash like fields of the parent")
+	}
+
+	if hasher.MustHash(embeddedPointer{Name: "x"}) != hasher.MustHash(struct{ Name string }{"x"}) {
+		t.Error("expected fields promoted from nil pointers to be skipped")
+	}
+
+	if plain.MustHash(embedded{baseModel{1, "today"}, "x"}) == want {
+		t.Error("expected embedded structs to stay nested without FlattenEmbedded")
+	}
+
+	type shadowing struct {
+		baseModel
+		ID int
+	}
+
+	if hasher.MustHash(shadowing{baseModel{1, "today"}, 2}) != hasher.MustHash(struct {
+		Created string
+		ID      int
+	}{"today", 2}) {
+		t.Error("expected fields of the parent to shadow promoted fields")
+	}
+
+	type tagged struct {
+		baseModel `datahash:"flatten"`
+		Name      string
+	}
+
+	if plain.MustHash(tagged{baseModel{1, "today"}, "x"}) != want {
+		t.Error("expected the flatten directive to flatten a single embedded struct")
+	}
+
+	type withTime struct {
+		time.Time
+	}
+
+	if hasher.MustHash(withTime{time.Unix(1, 0)}) == hasher.MustHash(withTime{time.Unix(2, 0)}) {
+		t.Error("expected embedded types with built-in handling to stay nested")
+	}
+
+	unordered := datahash.New(fnv.New64a, datahash.Options{FlattenEmbedded: true, UnorderedStruct: true})
+
+	if unordered.MustHash(embedded{baseModel{1, "today"}, "x"}) != unordered.MustHash(flat{Name: "x", ID: 1, Created: "today"}) {
+		t.Error("expected promoted fields to be elements of unordered structs")
+	}
+
+	canonical, err := hasher.Canonicalize(embedded{baseModel{1, "today"}, "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m := canonical.(map[string]any); len(m) != 3 || m["ID"] != int64(1) {
+		t.Errorf("expected promoted fields in the canonical form, got %#v", m)
+	}
+}
@@ -0,0 +1,70 @@
+package datahash
+
+import "fmt"
+
+// Format identifies a version of the canonical stream format.
+//
+// Formats are frozen: for the same value and Options, a Format always produces the same stream
+// and therefore the same hash, across releases of this package. Fixes and improvements that
+// change streams are only made available as new Formats, so hashes persisted under one Format
+// stay valid until the Format is changed explicitly.
+type Format uint8
+
+// Supported formats.
+const (
+	// FormatV1 is the original stream format. It is used if Options.Format is zero.
+	FormatV1 Format = 1
+
+	// FormatV2 writes a back-reference to the first occurrence of revisited pointers instead of
+	// skipping them, so that differently shaped cyclic graphs hash differently. Only pointers of
+	// recursive types are tracked, so shared pointers of other types hash by their content.
+	// Recursive types are hashed fully, while FormatV1 hashes nothing for recursive references,
+	// e.g. for the Next field of a linked list node. The nullable types of database/sql hash like
+	// nil pointers if they are invalid and like their payload otherwise. The addresses of net/netip
+	// and net.IP are hashed canonically, so the 4-byte and 16-byte forms of a net.IP hash equally.
+	// Byte arrays are hashed as raw bytes, and [16]byte types named UUID as their raw bytes instead
+	// of by their TextMarshaler. Addressable values of types whose marshaler has a pointer receiver
+	// are hashed by the marshaler, like in encoding/json, so they may hash differently from copies.
+	// Hashers of NewDigest and NewHMAC combine unordered collections from full sub-digests.
+	FormatV2 Format = 2
+)
+
+// LatestFormat is the most recent Format.
+const LatestFormat = FormatV2
+
+func (f Format) String() string {
+	switch f {
+	case FormatHashstructureV2:
+		return "hashstructure/v2"
+	case FormatGohugoioHashstructure:
+		return "gohugoio/hashstructure"
+	case FormatJCS:
+		return "jcs"
+	case FormatCBOR:
+		return "cbor"
+	}
+
+	return fmt.Sprintf("v%d", uint8(f))
+}
+
+// checkStream returns an error if the hashes of h are not computed from the canonical stream, as
+// in compatibility and interoperable formats, so that the stream based method cannot disagree with Hash.
+func (h *Hasher) checkStream(method string) error {
+	if h.opts.Format >= formatCompat {
+		return fmt.Errorf("datahash: %s is not supported in format %s", method, h.opts.Format)
+	}
+
+	return nil
+}
+
+// resolveFormat returns the format selected by f, panicking for unknown formats.
+func resolveFormat(f Format) Format {
+	switch f {
+	case 0:
+		return FormatV1
+	case FormatV1, FormatV2, FormatHashstructureV2, FormatGohugoioHashstructure, FormatJCS, FormatCBOR:
+		return f
+	}
+
+	panic(fmt.Sprintf("datahash: unknown format %d", f))
+}
@@ -0,0 +1,206 @@
+package datahash_test
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"hash/fnv"
+	"math"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type formatItem struct {
+	Name    string
+	Count   int
+	Price   float64
+	Active  bool
+	Tags    []string
+	Attrs   map[string]int
+	Parent  *formatParent
+	Zero    int
+	Created time.Time
+	Secret  string `datahash:"-"`
+	Renamed uint8  `datahash:"name=r"`
+	Empty   string `datahash:"omitempty"`
+	Set     []int  `datahash:"set"`
+}
+
+type formatParent struct {
+	Name string
+}
+
+type formatShared struct {
+	A, B *formatParent
+}
+
+func sharedParent() formatShared {
+	p := &formatParent{Name: "p"}
+
+	return formatShared{A: p, B: p}
+}
+
+// formatVersion has a pointer-receiver marshaler, used for addressable values in FormatV2.
+type formatVersion struct {
+	Major, Minor uint8
+}
+
+func (v *formatVersion) MarshalBinary() ([]byte, error) {
+	return []byte{v.Major, v.Minor}, nil
+}
+
+type formatRelease struct {
+	Name    string
+	Version formatVersion
+}
+
+type formatUUID [16]byte
+
+func goldenItem() *formatItem {
+	return &formatItem{
+		Name: "a", Count: -2, Price: 9.5, Active: true, Tags: []string{"x", "y"},
+		Attrs: map[string]int{"k": 1, "l": 2}, Parent: &formatParent{Name: "p"},
+		Created: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC), Secret: "s", Renamed: 3, Set: []int{2, 1},
+	}
+}
+
+// formatGolden locks the streams of each Format. Entries must never be changed:
+// a failing test means that a change breaks hashes persisted by users.
+var formatGolden = []struct {
+	format datahash.Format
+	opts   datahash.Options
+	value  any
+	stream string
+	fnv64a uint64
+}{
+	{datahash.FormatV1, datahash.Options{}, nil, "", 14695981039346656037},
+	{datahash.FormatV1, datahash.Options{}, true, "01", 12638152016183539244},
+	{datahash.FormatV1, datahash.Options{}, -1, "ffffffffffffffff", 10157053723145373757},
+	{datahash.FormatV1, datahash.Options{}, uint16(7), "0700000000000000", 5465015992139406178},
+	{datahash.FormatV1, datahash.Options{}, math.Pi, "182d4454fb210940", 11757427181621902203},
+	{datahash.FormatV1, datahash.Options{}, float32(1.5), "000000000000f83f", 12291987159633788032},
+	{datahash.FormatV1, datahash.Options{}, complex(1, 2), "000000000000f03f0000000000000040", 3391805261187160056},
+	{datahash.FormatV1, datahash.Options{}, "hello", "68656c6c6f", 11831194018420276491},
+	{datahash.FormatV1, datahash.Options{}, []byte("raw"), "726177", 9941345542212637073},
+	{datahash.FormatV1, datahash.Options{}, [2]int{1, 2}, "06010000000000000003020000000000000007", 8210793020840980972},
+	{datahash.FormatV1, datahash.Options{}, []string{"a", "b"}, "0661036207", 5053140330201300234},
+	{datahash.FormatV1, datahash.Options{}, []string(nil), "0607", 588776415145865754},
+	{datahash.FormatV1, datahash.Options{}, map[string]int{"a": 1, "b": 2}, "040cb17febeddb2abf05", 7480364000290049100},
+	{datahash.FormatV1, datahash.Options{}, []any{1, "x", nil}, "06010000000000000003780307", 6253072322854387517},
+	{datahash.FormatV1, datahash.Options{}, goldenItem(), "064e616d65026103436f756e7402feffffffffffffff03507269636502000000000000234003416374697665020103546167730206780379070341747472730204508aa253d71e2a280503506172656e7402064e616d65027007035a65726f020000000000000000034372656174656402010000000edd25742500000006ffff0372020300000000000000035365740204a321d2206db7706f0507", 3039158848621341745},
+	{datahash.FormatV1, datahash.Options{UnorderedStruct: true}, goldenItem(), "04294c0c51b47166f305", 17342841015047418332},
+	{datahash.FormatV1, datahash.Options{IgnoreZero: true}, goldenItem(), "064e616d65026103436f756e7402feffffffffffffff03507269636502000000000000234003416374697665020103546167730206780379070341747472730204508aa253d71e2a280503506172656e7402064e616d65027007034372656174656402010000000edd25742500000006ffff0372020300000000000000035365740204a321d2206db7706f0507", 14055790900291975240},
+	{datahash.FormatV1, datahash.Options{UnorderedSlice: true}, []int{3, 1, 2}, "0445a2db135608b2a805", 17645463890579864133},
+	{datahash.FormatV1, datahash.Options{ZeroNil: true}, (*int)(nil), "0000000000000000", 12161962213042174405},
+	{datahash.FormatV1, datahash.Options{Header: true}, "x", "4448012fe9da629f17967c78", 14081221758341839804},
+	{datahash.FormatV1, datahash.Options{}, sharedParent(), "064102064e616d6502700703420207", 3275325063444223564},
+	{datahash.FormatV2, datahash.Options{}, goldenItem(), "064e616d65026103436f756e7402feffffffffffffff03507269636502000000000000234003416374697665020103546167730206780379070341747472730204508aa253d71e2a280503506172656e7402064e616d65027007035a65726f020000000000000000034372656174656402010000000edd25742500000006ffff0372020300000000000000035365740204a321d2206db7706f0507", 3039158848621341745},
+	{datahash.FormatV2, datahash.Options{}, sharedParent(), "064102064e616d65027007034202064e616d6502700707", 5350381423452726804},
+	{datahash.FormatV2, datahash.Options{Header: true}, "x", "4448022fe9da629f17967c78", 15840389023282086119},
+	{datahash.FormatV2, datahash.Options{}, sql.NullString{String: "a", Valid: true}, "61", 12638187200555641996},
+	{datahash.FormatV2, datahash.Options{}, sql.NullInt64{}, "", 14695981039346656037},
+	{datahash.FormatV2, datahash.Options{NilMarker: true}, sql.NullInt64{}, "08", 12638161911788193143},
+	{datahash.FormatV2, datahash.Options{}, []sql.NullInt64{{Int64: 1, Valid: true}, {}}, "0601000000000000000307", 5654303314984900658},
+	{datahash.FormatV2, datahash.Options{}, netip.MustParseAddr("::ffff:1.2.3.4"), "00000000000000000000ffff01020304800000000000000000", 1021880591001962169},
+	{datahash.FormatV2, datahash.Options{}, net.ParseIP("1.2.3.4"), "00000000000000000000ffff01020304200000000000000000", 14289481692793896857},
+	{datahash.FormatV2, datahash.Options{}, netip.MustParsePrefix("10.0.0.0/8"), "00000000000000000000ffff0a0000002000000000000000000800000000000000", 1119014713012866187},
+	{datahash.FormatV2, datahash.Options{}, [4]byte{1, 2, 3, 4}, "01020304", 13725386680924731485},
+	{datahash.FormatV2, datahash.Options{}, formatUUID{0: 0x6b, 15: 0xc8}, "6b0000000000000000000000000000c8", 3373430061047673590},
+	{datahash.FormatV2, datahash.Options{}, &formatRelease{Name: "x", Version: formatVersion{Major: 1, Minor: 2}}, "064e616d6502780356657273696f6e02010207", 16939198477891392361},
+	{datahash.FormatV2, datahash.Options{}, formatRelease{Name: "x", Version: formatVersion{Major: 1, Minor: 2}}, "064e616d6502780356657273696f6e02064d616a6f72020100000000000000034d696e6f720202000000000000000707", 12522624907083548393},
+}
+
+func TestFormat_Golden(t *testing.T) {
+	for _, g := range formatGolden {
+		opts := g.opts
+		opts.Format = g.format
+
+		hasher := datahash.New(fnv.New64a, opts)
+
+		var stream hexWriter
+
+		if err := hasher.Encode(g.value, &stream); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		sum, err := hasher.Hash(g.value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if string(stream) != g.stream || sum != g.fnv64a {
+			t.Errorf("%s stream of %#v changed:\n  got:  %s %d\n  want: %s %d", g.format, g.value, stream, sum, g.stream, g.fnv64a)
+		}
+	}
+}
+
+type hexWriter []byte
+
+func (w *hexWriter) Write(p []byte) (int, error) {
+	*w = hex.AppendEncode(*w, p)
+
+	return len(p), nil
+}
+
+func TestFormat_Default(t *testing.T) {
+	value := goldenItem()
+
+	a, _ := datahash.New(fnv.New64a, datahash.Options{}).Hash(value)
+	b, _ := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV1}).Hash(value)
+
+	if a != b {
+		t.Errorf("expected the zero Format to select FormatV1, got %d and %d", a, b)
+	}
+
+	if got := datahash.New(fnv.New64a, datahash.Options{}).Header().Version; got != datahash.FormatV1 {
+		t.Errorf("expected header version %s, got %s", datahash.FormatV1, got)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("expected panic for unknown format")
+		}
+	}()
+
+	datahash.New(fnv.New64a, datahash.Options{Format: 99})
+}
+
+func TestFormat_V2References(t *testing.T) {
+	type ring struct {
+		Name string
+		Next any
+	}
+
+	v1 := datahash.New(fnv.New64a, datahash.Options{})
+	v2 := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV2})
+
+	// a -> b -> a and a -> b -> b only differ in the target of the back-reference.
+	a, b := &ring{Name: "a"}, &ring{Name: "b"}
+	a.Next, b.Next = b, a
+
+	c, d := &ring{Name: "a"}, &ring{Name: "b"}
+	c.Next, d.Next = d, d
+
+	if v1.MustHash(a) != v1.MustHash(c) {
+		t.Error("expected FormatV1 to skip revisited pointers")
+	}
+
+	if v2.MustHash(a) == v2.MustHash(c) {
+		t.Error("expected FormatV2 to distinguish the cycle targets")
+	}
+
+	if v2.MustHash(a) != v2.MustHash(a) {
+		t.Error("expected cyclic hashes to be deterministic")
+	}
+
+	if v2.MustHash(sharedParent()) != v2.MustHash(formatShared{A: &formatParent{Name: "p"}, B: &formatParent{Name: "p"}}) {
+		t.Error("expected shared pointers of non-recursive types to hash by content")
+	}
+
+	if v1.MustHash(sharedParent()) != v1.MustHash(formatShared{A: &formatParent{Name: "p"}}) {
+		t.Error("expected FormatV1 to skip shared pointers")
+	}
+}
@@ -0,0 +1,125 @@
+package datahash
+
+import (
+	"io"
+	"io/fs"
+	"path"
+)
+
+// FSOptions configures Hasher.HashFS.
+type FSOptions struct {
+	// IgnoreModes excludes file modes and permissions, so only names and contents are hashed.
+	IgnoreModes bool
+
+	// Skip excludes entries for which it returns true. Skipping a directory skips its contents.
+	// The path is relative to the root passed to HashFS, using forward slashes.
+	Skip func(path string, d fs.DirEntry) bool
+}
+
+// HashFS computes a 64-bit hash of the file tree rooted at root in fsys.
+//
+// The hash covers the relative path, type and permissions of every entry and the contents of
+// regular files, which are streamed into the hash function. Entries are visited in lexical
+// order, so the result only depends on the tree, not on the order the file system returns it.
+func (h *Hasher) HashFS(fsys fs.FS, root string, opts FSOptions) (uint64, error) {
+	c := h.acquire()
+	defer h.containerPool.Put(c)
+
+	if err := c.write(startList[:]); err != nil {
+		return 0, err
+	}
+
+	first := true
+
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel := "."
+
+		if name != root {
+			rel = name[len(root)+1:]
+
+			if root == "." {
+				rel = name
+			}
+		}
+
+		if opts.Skip != nil && rel != "." && opts.Skip(rel, d) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+
+			return nil
+		}
+
+		if !first {
+			if err := c.write(comma[:]); err != nil {
+				return err
+			}
+		} else {
+			first = false
+		}
+
+		if err := twoErr(
+			c.write(stringToBytes(rel)),
+			c.write(colon[:]),
+		); err != nil {
+			return err
+		}
+
+		if !opts.IgnoreModes {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+
+			if err := twoErr(
+				c.writeUint64(uint64(info.Mode())),
+				c.write(colon[:]),
+			); err != nil {
+				return err
+			}
+		} else if d.IsDir() {
+			if err := twoErr(
+				c.write(byteTrue[:]),
+				c.write(colon[:]),
+			); err != nil {
+				return err
+			}
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		return h.hashFile(fsys, name, c)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := c.write(endList[:]); err != nil {
+		return 0, err
+	}
+
+	return c.hash.Sum64(), nil
+}
+
+// hashFile streams the contents of the named file into c, followed by its length.
+func (h *Hasher) hashFile(fsys fs.FS, name string, c *container) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	n, err := io.Copy(c.hash, f)
+	if err != nil {
+		return &fs.PathError{Op: "read", Path: path.Clean(name), Err: err}
+	}
+
+	return c.writeUint64(uint64(n)) //nolint:gosec
+}
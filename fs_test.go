@@ -0,0 +1,88 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_HashFS(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	tree := func() fstest.MapFS {
+		return fstest.MapFS{
+			"src/main.go":      {Data: []byte("package main"), Mode: 0o644},
+			"src/util/util.go": {Data: []byte("package util"), Mode: 0o644},
+			"README.md":        {Data: []byte("readme"), Mode: 0o644},
+			"build/out.bin":    {Data: []byte{1, 2, 3}, Mode: 0o755},
+		}
+	}
+
+	hashFS := func(fsys fs.FS, root string, opts datahash.FSOptions) uint64 {
+		t.Helper()
+
+		sum, err := hasher.HashFS(fsys, root, opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return sum
+	}
+
+	base := hashFS(tree(), ".", datahash.FSOptions{})
+
+	if got := hashFS(tree(), ".", datahash.FSOptions{}); got != base {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", got, base)
+	}
+
+	changed := tree()
+	changed["src/main.go"].Data = []byte("package main2")
+
+	if hashFS(changed, ".", datahash.FSOptions{}) == base {
+		t.Error("expected content change to change the hash")
+	}
+
+	renamed := tree()
+	renamed["src/other.go"] = renamed["src/main.go"]
+	delete(renamed, "src/main.go")
+
+	if hashFS(renamed, ".", datahash.FSOptions{}) == base {
+		t.Error("expected rename to change the hash")
+	}
+
+	chmod := tree()
+	chmod["README.md"].Mode = 0o600
+
+	if hashFS(chmod, ".", datahash.FSOptions{}) == base {
+		t.Error("expected mode change to change the hash")
+	}
+
+	if hashFS(chmod, ".", datahash.FSOptions{IgnoreModes: true}) != hashFS(tree(), ".", datahash.FSOptions{IgnoreModes: true}) {
+		t.Error("expected mode change to be ignored")
+	}
+
+	skipBuild := datahash.FSOptions{Skip: func(path string, _ fs.DirEntry) bool { return path == "build" }}
+
+	rebuilt := tree()
+	rebuilt["build/out.bin"].Data = []byte{4}
+
+	if hashFS(rebuilt, ".", skipBuild) != hashFS(tree(), ".", skipBuild) {
+		t.Error("expected skipped directory to be ignored")
+	}
+
+	moved := fstest.MapFS{
+		"other/main.go":      tree()["src/main.go"],
+		"other/util/util.go": tree()["src/util/util.go"],
+	}
+
+	if hashFS(moved, "other", datahash.FSOptions{}) != hashFS(tree(), "src", datahash.FSOptions{}) {
+		t.Error("expected hash to be relative to the root")
+	}
+
+	if _, err := hasher.HashFS(tree(), "missing", datahash.FSOptions{}); err == nil {
+		t.Error("expected error for missing root")
+	}
+}
@@ -6,4 +6,5 @@ require (
 	github.com/cespare/xxhash/v2 v2.3.0
 	github.com/gohugoio/hashstructure v0.5.0
 	github.com/mitchellh/hashstructure/v2 v2.0.2
+	golang.org/x/text v0.34.0
 )
@@ -0,0 +1,75 @@
+package datahash
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"reflect"
+)
+
+var gobEncoderType = reflect.TypeFor[gob.GobEncoder]()
+
+// hashGobEncoder returns a hashFunc writing the GobEncode output of values of t.
+func (h *Hasher) hashGobEncoder(t reflect.Type, cfg config, info *TypeInfo) hashFunc {
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+			return nil
+		}
+
+		if !value.CanInterface() {
+			return errors.New("cannot use gob.GobEncoder on unexported fields that are not accessible via reflection")
+		}
+
+		if value.Kind() == reflect.Pointer && value.IsNil() {
+			return nil
+		}
+
+		i, ok := value.Interface().(gob.GobEncoder)
+		if !ok || i == nil {
+			h.warn(c, info, "nil gob.GobEncoder skipped")
+
+			return nil
+		}
+
+		v, err := i.GobEncode()
+		if err != nil {
+			return marshalerErr(t, StrategyGob, err)
+		}
+
+		return c.write(v)
+	}
+}
+
+// gobFallback returns a hashFunc writing the encoding/gob encoding of values of t, which could
+// not be compiled otherwise, or nil if encoding/gob cannot encode t either. Gob encodes struct
+// fields of func and chan types by skipping them, so structs with such fields fall back as a whole.
+func (h *Hasher) gobFallback(t reflect.Type, cfg config) hashFunc {
+	probe := reflect.New(t).Elem()
+
+	if t.Kind() == reflect.Pointer {
+		probe = reflect.New(t.Elem())
+	}
+
+	if gob.NewEncoder(io.Discard).EncodeValue(probe) != nil {
+		return nil
+	}
+
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+			return nil
+		}
+
+		if value.Kind() == reflect.Pointer && value.IsNil() {
+			return nil
+		}
+
+		var buf bytes.Buffer
+
+		if err := gob.NewEncoder(&buf).EncodeValue(value); err != nil {
+			return marshalerErr(t, StrategyGob, err)
+		}
+
+		return c.write(buf.Bytes())
+	}
+}
@@ -0,0 +1,80 @@
+package datahash_test
+
+import (
+	"errors"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type gobOnly struct {
+	value string
+}
+
+func (g gobOnly) GobEncode() ([]byte, error) { return []byte(g.value), nil }
+
+type gobFields struct {
+	Name     string
+	Callback func()
+}
+
+func TestHasher_Gob(t *testing.T) {
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	hasher := datahash.New(fnv.New64a, datahash.Options{Gob: true})
+
+	f := fnv.New64a()
+	_, _ = f.Write([]byte("a"))
+
+	if hasher.MustHash(gobOnly{value: "a"}) != f.Sum64() {
+		t.Error("expected GobEncoder implementations to hash by GobEncode")
+	}
+
+	if _, err := plain.Hash(gobFields{}); err == nil {
+		t.Fatal("expected func fields to be unsupported without Gob")
+	}
+
+	a := gobFields{Name: "a", Callback: func() {}}
+
+	if hasher.MustHash(a) != hasher.MustHash(gobFields{Name: "a"}) {
+		t.Error("expected the gob fallback to skip func fields")
+	}
+
+	if hasher.MustHash(a) == hasher.MustHash(gobFields{Name: "b"}) {
+		t.Error("expected the gob fallback to hash exported fields")
+	}
+
+	if explain := hasher.ExplainType(reflect.TypeFor[gobFields]()); !strings.Contains(explain, "encoding/gob fallback") {
+		t.Errorf("expected the fallback to be explained, got %s", explain)
+	}
+
+	if _, err := hasher.Hash(func() {}); !errors.Is(err, datahash.ErrUnsupportedType) {
+		t.Errorf("expected types gob cannot encode to stay unsupported, got %v", err)
+	}
+
+	type typo struct {
+		Name string `datahash:",omitzeroo"`
+	}
+
+	if _, err := hasher.Hash(typo{}); err == nil || errors.Is(err, datahash.ErrUnsupportedType) {
+		t.Errorf("expected unknown directives to fail despite Gob, got %v", err)
+	}
+
+	type forced struct {
+		Name string `datahash:"text"`
+	}
+
+	if _, err := hasher.Hash(forced{}); err == nil {
+		t.Error("expected forced marshalers the type lacks to fail despite Gob")
+	}
+
+	strict := datahash.New(fnv.New64a, datahash.Options{Gob: true, StrictKinds: true})
+
+	var kindErr *datahash.KindError
+
+	if _, err := strict.Hash(make(chan int)); !errors.As(err, &kindErr) {
+		t.Errorf("expected a KindError despite Gob, got %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package datahash
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+	"slices"
+)
+
+var (
+	// syntaxNoiseTypes are skipped as struct fields in GoSyntax mode: positions carry
+	// no structure, and the legacy ast.Object resolution forms cycles through declarations.
+	syntaxNoiseTypes = []reflect.Type{
+		reflect.TypeFor[token.Pos](),
+		reflect.TypeFor[*token.File](),
+		reflect.TypeFor[*token.FileSet](),
+		reflect.TypeFor[*ast.Object](),
+		reflect.TypeFor[*ast.Scope](),
+	}
+
+	typesObjectType  = reflect.TypeFor[types.Object]()
+	typesTypeType    = reflect.TypeFor[types.Type]()
+	typesPackageType = reflect.TypeFor[*types.Package]()
+	typesScopeType   = reflect.TypeFor[*types.Scope]()
+)
+
+func isSyntaxNoise(t reflect.Type) bool {
+	return slices.Contains(syntaxNoiseTypes, t)
+}
+
+// qualifyByPath qualifies package-level identifiers by their full package path.
+func qualifyByPath(p *types.Package) string {
+	return p.Path()
+}
+
+// hashGoTypes returns a hashFunc for go/types values, or nil.
+//
+// Objects and types are hashed by their package-qualified descriptions, packages by
+// their path and scopes by the descriptions of their objects, which avoids the
+// cycles between types, scopes and packages.
+func (h *Hasher) hashGoTypes(t reflect.Type, cfg config) hashFunc {
+	if t.Kind() == reflect.Interface {
+		return nil
+	}
+
+	var describe func(v any) string
+
+	switch {
+	case t == typesPackageType:
+		describe = func(v any) string {
+			return v.(*types.Package).Path()
+		}
+	case t == typesScopeType:
+		return func(value reflect.Value, c *container) error {
+			if !value.IsValid() || value.IsNil() || (cfg.ignoreZero && isZero(value)) {
+				return nil
+			}
+
+			scope := value.Interface().(*types.Scope)
+
+			if err := c.write(startList[:]); err != nil {
+				return err
+			}
+
+			for i, name := range scope.Names() {
+				if i > 0 {
+					if err := c.write(comma[:]); err != nil {
+						return err
+					}
+				}
+
+				if err := c.write(stringToBytes(types.ObjectString(scope.Lookup(name), qualifyByPath))); err != nil {
+					return err
+				}
+			}
+
+			return c.write(endList[:])
+		}
+	case t.Implements(typesObjectType):
+		describe = func(v any) string {
+			return types.ObjectString(v.(types.Object), qualifyByPath)
+		}
+	case t.Implements(typesTypeType):
+		describe = func(v any) string {
+			return types.TypeString(v.(types.Type), qualifyByPath)
+		}
+	default:
+		return nil
+	}
+
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+			return nil
+		}
+
+		if value.Kind() == reflect.Pointer && value.IsNil() {
+			return nil
+		}
+
+		if !value.CanInterface() {
+			return errors.New("datahash: cannot hash go/types values in unexported fields that are not accessible via reflection")
+		}
+
+		return c.write(stringToBytes(describe(value.Interface())))
+	}
+}
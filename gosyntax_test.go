@@ -0,0 +1,67 @@
+package datahash_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_GoSyntax(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{GoSyntax: true})
+
+	fset := token.NewFileSet()
+
+	parse := func(src string) *ast.File {
+		t.Helper()
+
+		file, err := parser.ParseFile(fset, "p.go", src, parser.ParseComments)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return file
+	}
+
+	hash := func(value any) uint64 {
+		t.Helper()
+
+		got, err := hasher.Hash(value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return got
+	}
+
+	a := parse("package p\n\nfunc f(x int) int { return x }\n")
+	b := parse("\n\npackage p\n\n\n\nfunc f(x int) int {\n\treturn x\n}\n")
+	c := parse("package p\n\nfunc f(x int) int { return -x }\n")
+
+	if hash(a) != hash(b) {
+		t.Error("expected position changes to be ignored")
+	}
+
+	if hash(a) == hash(c) {
+		t.Error("expected structural changes to change the hash")
+	}
+
+	check := func(file *ast.File) *types.Package {
+		t.Helper()
+
+		pkg, err := new(types.Config).Check("example.com/p", fset, []*ast.File{file}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return pkg
+	}
+
+	if hash(check(a).Scope().Lookup("f")) != hash(check(b).Scope().Lookup("f")) {
+		t.Error("expected equal hashes for equal type-checked objects")
+	}
+}
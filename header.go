@@ -0,0 +1,156 @@
+package datahash
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// headerMagic starts every stream header.
+var headerMagic = [2]byte{'D', 'H'}
+
+// HeaderSize is the length of an encoded stream header in bytes.
+const HeaderSize = len(headerMagic) + 1 + 8
+
+// ErrHeader is returned by ParseHeader for input that does not start with a stream header.
+var ErrHeader = errors.New("datahash: missing or invalid stream header")
+
+// Header describes the format of a canonical stream.
+type Header struct {
+	Version Format // Format of the stream.
+	Options uint64 // Fingerprint of the Options that affect the stream.
+}
+
+// Header returns the stream header of h. With Options.Header, the header prefixes every
+// stream, so digests computed with different format versions or Options never collide.
+//
+// The options fingerprint covers all boolean Options, GoSyntax and Exclude. Hook functions
+// such as SkipField, Identity or WarnFunc cannot be fingerprinted; for Combiner and
+// StringTransform, it only covers whether they are set.
+func (h *Hasher) Header() Header {
+	return Header{Version: h.opts.Format, Options: h.fingerprint()}
+}
+
+// ParseHeader parses the stream header at the start of b and returns the remaining bytes.
+func ParseHeader(b []byte) (Header, []byte, error) {
+	if len(b) < HeaderSize || b[0] != headerMagic[0] || b[1] != headerMagic[1] {
+		return Header{}, b, ErrHeader
+	}
+
+	return Header{
+		Version: Format(b[2]),
+		Options: binary.LittleEndian.Uint64(b[3:HeaderSize]),
+	}, b[HeaderSize:], nil
+}
+
+// AppendBinary appends the encoded header to b.
+func (hd Header) AppendBinary(b []byte) ([]byte, error) {
+	b = append(b, headerMagic[:]...)
+	b = append(b, byte(hd.Version))
+
+	return binary.LittleEndian.AppendUint64(b, hd.Options), nil
+}
+
+func (h *Hasher) fingerprint() uint64 {
+	f := fnv.New64a()
+
+	for _, flag := range []bool{
+		h.cfg.unorderedStruct, h.cfg.unorderedArray, h.cfg.unorderedSlice,
+		h.cfg.unorderedSeq, h.cfg.unorderedSeq2, h.cfg.text, h.cfg.json, h.cfg.str,
+		h.cfg.zeroNil, h.cfg.ignoreZero, h.opts.GoSyntax, h.opts.StrictSchema, h.opts.StrictKinds,
+	} {
+		if flag {
+			_, _ = f.Write(byteTrue[:])
+		} else {
+			_, _ = f.Write(byteFalse[:])
+		}
+	}
+
+	// Options added after FormatV1 only contribute when set, so existing fingerprints stay stable.
+	for _, opt := range []struct {
+		name string
+		set  bool
+	}{
+		{"TypeAware", h.opts.TypeAware},
+		{"NilMarker", h.opts.NilMarker},
+		{"DistinguishNil", h.opts.DistinguishNil},
+		{"NormalizeFloats", h.opts.NormalizeFloats},
+		{"StrongUnordered", h.opts.StrongUnordered},
+		{"Combiner", h.opts.Combiner != nil},
+		{"NormalizeTime", h.opts.NormalizeTime},
+		{"TimeLocation", h.opts.TimeLocation},
+		{"NormalizeNumbers", h.opts.NormalizeNumbers},
+		{"FoldCase", h.opts.FoldCase},
+		{"StringTransform", h.opts.StringTransform != nil},
+		{"NoCycleDetection", h.opts.NoCycleDetection},
+		{"Memo", h.opts.Memo != nil},
+		{"NormalizeJSON", h.opts.NormalizeJSON},
+		{"Gob", h.opts.Gob},
+		{"XML", h.cfg.xml},
+		{"AutoAddress", h.opts.AutoAddress},
+		{"MarshalFallback", h.opts.MarshalFallback},
+		{"Positional", h.opts.Positional},
+		{"SortedMaps", h.opts.SortedMaps},
+		{"SortedUnordered", h.opts.SortedUnordered},
+		{"FlattenEmbedded", h.opts.FlattenEmbedded},
+		{"JSONTags", h.opts.JSONTags},
+		{"ExplicitOnly", h.opts.ExplicitOnly},
+		{"Errors", h.opts.Errors},
+		{"ErrorChain", h.opts.Errors && h.opts.ErrorChain},
+	} {
+		if opt.set {
+			_, _ = f.Write(comma[:])
+			_, _ = f.Write(stringToBytes(opt.name))
+		}
+	}
+
+	if h.opts.NormalizeUnicode {
+		_, _ = f.Write(comma[:])
+		_, _ = f.Write(stringToBytes("NormalizeUnicode=" + unicodeFormName(h.opts.UnicodeForm)))
+	}
+
+	if h.opts.Funcs != ModeDefault {
+		_, _ = f.Write(comma[:])
+		_, _ = f.Write(stringToBytes("Funcs=" + h.opts.Funcs.String()))
+	}
+
+	if h.opts.Chans != ModeDefault {
+		_, _ = f.Write(comma[:])
+		_, _ = f.Write(stringToBytes("Chans=" + h.opts.Chans.String()))
+	}
+
+	if h.opts.TruncateElements && h.opts.MaxElements > 0 {
+		_, _ = f.Write(comma[:])
+		_, _ = f.Write(stringToBytes("TruncateElements=" + strconv.Itoa(h.opts.MaxElements)))
+	}
+
+	if h.opts.HashMethod != "" {
+		_, _ = f.Write(comma[:])
+		_, _ = f.Write(stringToBytes("HashMethod=" + h.opts.HashMethod))
+	}
+
+	if h.opts.ChunkSize > 0 {
+		_, _ = f.Write(comma[:])
+		_, _ = f.Write(stringToBytes("ChunkSize=" + strconv.Itoa(h.opts.ChunkSize)))
+	}
+
+	if h.opts.TruncateTime != 0 {
+		_, _ = f.Write(comma[:])
+		_, _ = f.Write(stringToBytes("TruncateTime=" + h.opts.TruncateTime.String()))
+	}
+
+	for _, typ := range slices.Sorted(maps.Keys(h.opts.Exclude)) {
+		fields := slices.Sorted(slices.Values(h.opts.Exclude[typ]))
+
+		_, _ = f.Write(comma[:])
+		_, _ = f.Write(stringToBytes(typ))
+		_, _ = f.Write(colon[:])
+		_, _ = f.Write(stringToBytes(strings.Join(fields, string(comma[:]))))
+	}
+
+	return f.Sum64()
+}
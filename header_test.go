@@ -0,0 +1,56 @@
+package datahash_test
+
+import (
+	"errors"
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_Header(t *testing.T) {
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	headed := datahash.New(fnv.New64a, datahash.Options{Header: true})
+	unordered := datahash.New(fnv.New64a, datahash.Options{Header: true, UnorderedSlice: true})
+
+	if plain.Header() != headed.Header() {
+		t.Error("expected Header option not to affect the fingerprint")
+	}
+
+	if headed.Header() == unordered.Header() {
+		t.Error("expected different fingerprints for different options")
+	}
+
+	combined := datahash.New(fnv.New64a, datahash.Options{Header: true, Combiner: datahash.NewAddCombiner})
+
+	if headed.Header() == combined.Header() {
+		t.Error("expected a custom Combiner to change the fingerprint")
+	}
+
+	a, _ := plain.Hash("value")
+	b, _ := headed.Hash("value")
+
+	if a == b {
+		t.Error("expected header to change the hash")
+	}
+
+	encoded, err := unordered.Header().AppendBinary(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	encoded = append(encoded, "rest"...)
+
+	header, rest, err := datahash.ParseHeader(encoded)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if header != unordered.Header() || header.Version != datahash.FormatV1 || string(rest) != "rest" {
+		t.Errorf("unexpected header %+v with rest %q", header, rest)
+	}
+
+	if _, _, err := datahash.ParseHeader([]byte("rest")); !errors.Is(err, datahash.ErrHeader) {
+		t.Errorf("expected ErrHeader, got %v", err)
+	}
+}
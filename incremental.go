@@ -0,0 +1,186 @@
+package datahash
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// FieldDigests holds the digests of the fields of a struct hashed in UnorderedStruct mode, so the
+// hash of a large struct can be updated by re-hashing only its changed fields. The field digests
+// are recombined like the fields of any unordered struct, e.g. by XOR.
+type FieldDigests struct {
+	h    *Hasher
+	typ  reflect.Type
+	cfg  config
+	sfs  []structField
+	sums []uint64
+	wide [][]byte // Full field digests of wide Hashers, see NewDigest.
+	set  []bool   // Whether the field is hashed, i.e. not skipped as zero or by its tag.
+}
+
+// FieldDigests hashes each field of value, a struct or a non-nil pointer to a struct whose type is
+// hashed in UnorderedStruct mode, e.g. via Options.UnorderedStruct or FieldOptions. Sum64 of the
+// result equals the hash of the struct value.
+func (h *Hasher) FieldDigests(value any) (*FieldDigests, error) {
+	v, err := structValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	t := v.Type()
+
+	if err := h.checkStream("FieldDigests"); err != nil {
+		return nil, err
+	}
+
+	if _, err := h.makeHashFunc(t, h.cfg); err != nil {
+		return nil, err
+	}
+
+	cfg := h.cfg.with(typeOptions(t))
+
+	sfs, ok := h.structFieldsMap.Load(cacheKey{typ: t, cfg: cfg})
+	if info := h.typeInfo(t, cfg); !ok || !cfg.unorderedStruct || info == nil || info.Strategy != StrategyKind {
+		return nil, fmt.Errorf("datahash: FieldDigests requires %s to be hashed as an unordered struct", t)
+	}
+
+	d := &FieldDigests{
+		h:    h,
+		typ:  t,
+		cfg:  cfg,
+		sfs:  sfs.([]structField),
+		sums: make([]uint64, len(sfs.([]structField))),
+		set:  make([]bool, len(sfs.([]structField))),
+	}
+
+	if h.wide {
+		d.wide = make([][]byte, len(d.sums))
+	}
+
+	return d, d.update(v, func(*structField) bool { return true })
+}
+
+// Update re-hashes the named fields of value, which must be of the type passed to
+// Hasher.FieldDigests. Fields are named as they are hashed, i.e. by their name=<name> tag
+// directive or their Go name.
+func (d *FieldDigests) Update(value any, names ...string) error {
+	v, err := structValue(value)
+	if err != nil {
+		return err
+	}
+
+	if v.Type() != d.typ {
+		return fmt.Errorf("datahash: FieldDigests of %s cannot be updated with %s", d.typ, v.Type())
+	}
+
+	for _, name := range names {
+		if d.index(name) < 0 {
+			return fmt.Errorf("datahash: unknown field %q of %s", name, d.typ)
+		}
+	}
+
+	return d.update(v, func(sf *structField) bool {
+		for _, name := range names {
+			if string(sf.name) == name {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Digest returns the digest of the named field, or false if the field is unknown or skipped.
+func (d *FieldDigests) Digest(name string) (uint64, bool) {
+	if i := d.index(name); i >= 0 && d.set[i] {
+		return d.sums[i], true
+	}
+
+	return 0, false
+}
+
+// Sum64 returns the hash of the struct, as Hasher.Hash returns it.
+func (d *FieldDigests) Sum64() (uint64, error) {
+	c := d.h.acquire()
+	defer d.h.containerPool.Put(c)
+
+	fold := d.h.folder()
+
+	for i, sum := range d.sums {
+		switch {
+		case !d.set[i]:
+		case fold.wide:
+			fold.digests = append(fold.digests, d.wide[i])
+		default:
+			fold.add(sum)
+		}
+	}
+
+	if err := twoErr(d.h.writeType(d.typ, c), c.write(startSet[:])); err != nil {
+		return 0, err
+	}
+
+	if err := d.h.closeSet(&fold, false, c); err != nil {
+		return 0, err
+	}
+
+	return c.hash.Sum64(), nil
+}
+
+func (d *FieldDigests) update(v reflect.Value, selected func(*structField) bool) error {
+	c := d.h.acquire()
+	defer d.h.containerPool.Put(c)
+
+	tmp := d.h.sub(c)
+	defer d.h.containerPool.Put(tmp)
+
+	base := structBase(v)
+
+	for i := range d.sfs {
+		if !selected(&d.sfs[i]) {
+			continue
+		}
+
+		ok, err := d.h.fieldSum(&d.sfs[i], v, base, d.cfg, c, tmp)
+		if err != nil {
+			return err
+		}
+
+		d.sums[i], d.set[i] = 0, ok
+
+		if ok {
+			d.sums[i] = tmp.hash.Sum64()
+
+			if d.wide != nil {
+				d.wide[i] = tmp.hash.Sum(d.wide[i][:0])
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d *FieldDigests) index(name string) int {
+	for i := range d.sfs {
+		if string(d.sfs[i].name) == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// structValue returns the struct value or the struct pointed to by value.
+func structValue(value any) (reflect.Value, error) {
+	v := reflect.ValueOf(value)
+
+	if v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("datahash: expected a struct or a pointer to a struct, got %T", value)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,73 @@
+package datahash_test
+
+import (
+	"crypto/sha256"
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type document struct {
+	Title string
+	Body  string `datahash:"name=content"`
+	Tags  []string
+	Views int `datahash:"omitzero"`
+}
+
+func TestHasher_FieldDigests(t *testing.T) {
+	for i, hasher := range []*datahash.Hasher{
+		datahash.New(fnv.New64a, datahash.Options{UnorderedStruct: true}),
+		datahash.New(fnv.New64a, datahash.Options{UnorderedStruct: true, StrongUnordered: true, Header: true}),
+		datahash.New(fnv.New64a, datahash.Options{UnorderedStruct: true, IgnoreZero: true, TypeAware: true}),
+		datahash.NewDigest(sha256.New, datahash.Options{UnorderedStruct: true, Format: datahash.FormatV2}),
+	} {
+		doc := &document{Title: "a", Body: "long text", Tags: []string{"x"}}
+
+		digests, err := hasher.FieldDigests(doc)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		check := func() {
+			t.Helper()
+
+			got, err := digests.Sum64()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := hasher.MustHash(*doc); got != want {
+				t.Errorf("hasher %d: Sum64 %d != Hash %d", i, got, want)
+			}
+		}
+
+		check()
+
+		doc.Body = "other text"
+		if err := digests.Update(doc, "content"); err != nil {
+			t.Fatal(err)
+		}
+
+		check()
+
+		doc.Views, doc.Title = 3, ""
+		if err := digests.Update(*doc, "Views", "Title"); err != nil {
+			t.Fatal(err)
+		}
+
+		check()
+
+		if _, ok := digests.Digest("Views"); !ok {
+			t.Error("expected a digest of the set field")
+		}
+
+		if err := digests.Update(doc, "Body"); err == nil {
+			t.Error("expected an error for a field name that is not hashed")
+		}
+	}
+
+	if _, err := datahash.New(fnv.New64a, datahash.Options{}).FieldDigests(document{}); err == nil {
+		t.Error("expected an error for ordered structs")
+	}
+}
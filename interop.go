@@ -0,0 +1,55 @@
+package datahash
+
+import "fmt"
+
+// Formats whose stream is a standard serialization of the value instead of the canonical stream,
+// so that hashes computed by datahash can be reproduced by services in other languages, which
+// hash the same serialization of the same data with the same hash function. Hash, MustHash,
+// HashBytes, FuncFor and Encode use the serialization; Options do not apply to it, and other
+// stream based methods fail.
+const (
+	// FormatJCS hashes the JSON Canonicalization Scheme (RFC 8785) serialization of the value:
+	// its encoding/json representation with object keys sorted by their UTF-16 code units,
+	// numbers formatted like ECMAScript and no insignificant whitespace. Numbers are IEEE 754
+	// doubles, so integers beyond 2^53 lose precision, as in JavaScript.
+	FormatJCS Format = formatInterop + iota
+
+	// FormatCBOR hashes the RFC 8949 core deterministic CBOR encoding of the value: integers and
+	// lengths in their shortest form, floats in the shortest of half, single and double precision
+	// that preserves their value, and map keys sorted by the bytewise order of their encodings.
+	// Structs are encoded as maps of their exported fields, named by their cbor or json tag or
+	// their Go name. Byte slices and arrays are byte strings, nil pointers, slices and maps null,
+	// time.Time values RFC 3339 strings in UTC with tag 0 and other encoding.TextMarshaler
+	// implementations text strings. Cyclic values, complex numbers, funcs and channels fail.
+	FormatCBOR
+)
+
+// formatInterop is the first interoperable format.
+const formatInterop Format = 0xc0
+
+// encodeInterop returns the serialization of value in the interoperable format of h.
+func (h *Hasher) encodeInterop(value any) ([]byte, error) {
+	switch h.opts.Format {
+	case FormatJCS:
+		return appendJCS(nil, value)
+	case FormatCBOR:
+		return appendCBOR(nil, value)
+	}
+
+	return nil, fmt.Errorf("datahash: unknown interoperable format %s", h.opts.Format)
+}
+
+// hashInterop writes the serialization of value to a pooled container, which the caller
+// returns to the pool after reading the hash.
+func (h *Hasher) hashInterop(value any) (*container, error) {
+	c := h.containerPool.Get().(*container)
+
+	c.hash.Reset()
+
+	b, err := h.encodeInterop(value)
+	if err != nil {
+		return c, err
+	}
+
+	return c, c.write(b)
+}
@@ -0,0 +1,217 @@
+package datahash
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var rawMessageType = reflect.TypeFor[json.RawMessage]()
+
+// hashRawJSON returns a hashFunc for json.RawMessage values in NormalizeJSON mode, or nil.
+//
+// Raw messages are hashed by their RFC 8785 serialization, so that semantically equal JSON texts,
+// which only differ in the order of object keys, whitespace, escapes or number formatting, hash
+// equally. Empty raw messages hash like empty byte slices. Marshalers forced by struct tags take precedence.
+func (h *Hasher) hashRawJSON(t reflect.Type, cfg config) hashFunc {
+	if !h.opts.NormalizeJSON || t != rawMessageType || cfg.prefer != "" {
+		return nil
+	}
+
+	return h.distinguishNil(func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) || value.Len() == 0 {
+			return nil
+		}
+
+		b, err := appendJCSRaw(nil, value.Bytes())
+		if err != nil {
+			return fmt.Errorf("%w in json.RawMessage", err)
+		}
+
+		return c.write(b)
+	}, cfg)
+}
+
+// appendJCS appends the RFC 8785 serialization of the encoding/json representation of value.
+func appendJCS(dst []byte, value any) ([]byte, error) {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return appendJCSRaw(dst, b)
+}
+
+// appendJCSRaw appends the RFC 8785 serialization of the JSON text b.
+func appendJCSRaw(dst []byte, b []byte) ([]byte, error) {
+	if !json.Valid(b) {
+		return nil, errors.New("datahash: invalid JSON text")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v any
+
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	return appendJCSValue(dst, v)
+}
+
+func appendJCSValue(dst []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, "null"...), nil
+	case bool:
+		return strconv.AppendBool(dst, v), nil
+	case string:
+		return appendJCSString(dst, v), nil
+	case json.Number:
+		f, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("datahash: JSON number %s is not an IEEE 754 double", v)
+		}
+
+		return appendJCSNumber(dst, f)
+	case []any:
+		dst = append(dst, '[')
+
+		for i, e := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+
+			var err error
+
+			if dst, err = appendJCSValue(dst, e); err != nil {
+				return nil, err
+			}
+		}
+
+		return append(dst, ']'), nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+
+		for k := range v {
+			keys = append(keys, k)
+		}
+
+		slices.SortFunc(keys, func(a, b string) int {
+			return slices.Compare(utf16.Encode([]rune(a)), utf16.Encode([]rune(b)))
+		})
+
+		dst = append(dst, '{')
+
+		for i, k := range keys {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+
+			dst = append(appendJCSString(dst, k), ':')
+
+			var err error
+
+			if dst, err = appendJCSValue(dst, v[k]); err != nil {
+				return nil, err
+			}
+		}
+
+		return append(dst, '}'), nil
+	}
+
+	return nil, fmt.Errorf("datahash: unexpected JSON value %T", v)
+}
+
+// appendJCSString appends s as a JSON string, escaping only quotes, backslashes and control characters.
+func appendJCSString(dst []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	dst = append(dst, '"')
+
+	for i := range len(s) {
+		switch b := s[i]; b {
+		case '"', '\\':
+			dst = append(dst, '\\', b)
+		case '\b':
+			dst = append(dst, '\\', 'b')
+		case '\f':
+			dst = append(dst, '\\', 'f')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		default:
+			if b < 0x20 {
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xf])
+			} else {
+				dst = append(dst, b)
+			}
+		}
+	}
+
+	return append(dst, '"')
+}
+
+// appendJCSNumber appends f formatted like the ECMAScript Number.prototype.toString.
+func appendJCSNumber(dst []byte, f float64) ([]byte, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, errors.New("datahash: NaN and infinite numbers are not valid JSON")
+	}
+
+	if f == 0 {
+		return append(dst, '0'), nil
+	}
+
+	if f < 0 {
+		dst, f = append(dst, '-'), -f
+	}
+
+	// The shortest digits that round-trip, and the position n of the decimal point relative to them.
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+
+	n, k := e+1, len(digits)
+
+	switch {
+	case k <= n && n <= 21:
+		dst = append(dst, digits...)
+		dst = append(dst, strings.Repeat("0", n-k)...)
+	case 0 < n && n <= 21:
+		dst = append(dst, digits[:n]...)
+		dst = append(dst, '.')
+		dst = append(dst, digits[n:]...)
+	case -6 < n && n <= 0:
+		dst = append(dst, "0."...)
+		dst = append(dst, strings.Repeat("0", -n)...)
+		dst = append(dst, digits...)
+	default:
+		dst = append(dst, digits[0])
+
+		if k > 1 {
+			dst = append(dst, '.')
+			dst = append(dst, digits[1:]...)
+		}
+
+		dst = append(dst, 'e')
+
+		if n-1 >= 0 {
+			dst = append(dst, '+')
+		}
+
+		dst = strconv.AppendInt(dst, int64(n-1), 10)
+	}
+
+	return dst, nil
+}
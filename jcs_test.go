@@ -0,0 +1,88 @@
+package datahash_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"hash/fnv"
+	"math"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestFormat_JCS(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatJCS})
+
+	type item struct {
+		Name  string  `json:"name"`
+		Price float64 `json:"price"`
+	}
+
+	for _, c := range []struct {
+		value any
+		want  string
+	}{
+		{map[string]any{"b": 2, "a": 1, "c": []any{true, nil}}, `{"a":1,"b":2,"c":[true,null]}`},
+		{item{Name: "<tag>", Price: 4.50}, `{"name":"<tag>","price":4.5}`},
+		{map[string]int{"\u20ac": 1, "\r": 2, "\U0001F600": 3, "\ufb33": 4}, "{\"\\r\":2,\"\u20ac\":1,\"\U0001F600\":3,\"\ufb33\":4}"},
+		{" \x1f\"\\\t", `"` + " " + `\u001f\"\\\t"`},
+		{333333333.33333329, "333333333.3333333"},
+		{1e30, "1e+30"},
+		{2e-3, "0.002"},
+		{0.000000000000000000000000001, "1e-27"},
+		{1e21, "1e+21"},
+		{1e20, "100000000000000000000"},
+		{math.Copysign(0, -1), "0"},
+		{-5e-7, "-5e-7"},
+		{uint64(1) << 60, "1152921504606847000"},
+	} {
+		var buf bytes.Buffer
+
+		if err := hasher.Encode(c.value, &buf); err != nil {
+			t.Fatal(err)
+		}
+
+		if buf.String() != c.want {
+			t.Errorf("%v: expected %s, got %s", c.value, c.want, buf.String())
+		}
+
+		f := fnv.New64a()
+		_, _ = f.Write([]byte(c.want))
+
+		if got := hasher.MustHash(c.value); got != f.Sum64() {
+			t.Errorf("%v: expected the hash of the serialization", c.value)
+		}
+	}
+
+	if _, err := hasher.Hash(math.NaN()); err == nil {
+		t.Error("expected an error for NaN")
+	}
+}
+
+func TestHasher_NormalizeJSON(t *testing.T) {
+	type event struct {
+		Payload json.RawMessage
+	}
+
+	a := event{Payload: json.RawMessage(`{"a":1,"b":[2,"A"]}`)}
+	b := event{Payload: json.RawMessage(` { "b": [2.0, "A"], "a": 1e0 } `)}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	normalized := datahash.New(fnv.New64a, datahash.Options{NormalizeJSON: true})
+
+	if plain.MustHash(a) == plain.MustHash(b) {
+		t.Error("expected raw messages to hash by their bytes without NormalizeJSON")
+	}
+
+	if normalized.MustHash(a) != normalized.MustHash(b) {
+		t.Error("expected semantically equal raw messages to hash equally")
+	}
+
+	if normalized.MustHash(a) == normalized.MustHash(event{Payload: json.RawMessage(`{"a":1,"b":[2,"B"]}`)}) {
+		t.Error("expected different raw messages to hash differently")
+	}
+
+	if _, err := normalized.Hash(event{Payload: json.RawMessage(`{"a":`)}); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+}
@@ -0,0 +1,55 @@
+package datahash
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// kubernetesManagedFields are the ObjectMeta fields maintained by the API server.
+var kubernetesManagedFields = map[string]bool{
+	"ResourceVersion": true,
+	"Generation":      true,
+	"ManagedFields":   true,
+}
+
+// KubernetesOptions returns opts with a SkipField hook tuned for Kubernetes-style objects.
+//
+// The Status field of any struct with an ObjectMeta field is skipped, as are the
+// server-managed ObjectMeta fields ResourceVersion, Generation and ManagedFields.
+// Types are matched by name, so the preset works without importing the Kubernetes modules.
+// An existing SkipField hook in opts is kept and consulted as well.
+func KubernetesOptions(opts Options) Options {
+	skip := opts.SkipField
+
+	opts.SkipField = func(parent reflect.Type, field reflect.StructField) bool {
+		if skip != nil && skip(parent, field) {
+			return true
+		}
+
+		if parent.Name() == "ObjectMeta" {
+			return kubernetesManagedFields[field.Name]
+		}
+
+		if field.Name == "Status" {
+			_, ok := parent.FieldByName("ObjectMeta")
+
+			return ok
+		}
+
+		return false
+	}
+
+	return opts
+}
+
+// SpecHash hashes obj with h and formats the result for use as a "spec hash"
+// annotation or label value. Use a Hasher created with KubernetesOptions so that
+// status and server-managed metadata do not affect the result.
+func SpecHash(h *Hasher, obj any) (string, error) {
+	sum, err := h.Hash(obj)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatUint(sum, 16), nil
+}
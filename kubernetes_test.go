@@ -0,0 +1,66 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type ObjectMeta struct {
+	Name            string
+	ResourceVersion string
+	Generation      int64
+	ManagedFields   []string
+}
+
+type deploymentSpec struct {
+	Replicas int
+}
+
+type deploymentStatus struct {
+	ReadyReplicas int
+}
+
+type deployment struct {
+	ObjectMeta
+	Spec   deploymentSpec
+	Status deploymentStatus
+}
+
+func TestSpecHash(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.KubernetesOptions(datahash.Options{}))
+
+	a, err := datahash.SpecHash(hasher, deployment{
+		ObjectMeta: ObjectMeta{Name: "web", ResourceVersion: "1", Generation: 1},
+		Spec:       deploymentSpec{Replicas: 3},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := datahash.SpecHash(hasher, deployment{
+		ObjectMeta: ObjectMeta{Name: "web", ResourceVersion: "42", Generation: 7, ManagedFields: []string{"kubectl"}},
+		Spec:       deploymentSpec{Replicas: 3},
+		Status:     deploymentStatus{ReadyReplicas: 3},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a != b {
+		t.Errorf("expected status and managed metadata to be ignored: %s != %s", a, b)
+	}
+
+	c, err := datahash.SpecHash(hasher, deployment{
+		ObjectMeta: ObjectMeta{Name: "web"},
+		Spec:       deploymentSpec{Replicas: 5},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a == c {
+		t.Errorf("expected spec changes to change the hash")
+	}
+}
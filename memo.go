@@ -0,0 +1,71 @@
+package datahash
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// Memo caches the digests of structs behind pointers across Hash calls, so subobjects shared
+// by many values, such as a *Config referenced from thousands of parents, are hashed once.
+// Structs that can reach themselves through their fields, e.g. linked list nodes, are not memoized,
+// since their digests depend on the pointers visited before. A Memo must only be used by a single
+// Hasher. It is safe for concurrent use.
+//
+// Digests are keyed by pointer and never invalidated automatically: call Forget after mutating
+// a memoized struct and Reset to drop all digests. A Memo keeps the memoized structs reachable.
+type Memo struct {
+	sums sync.Map // Map with key memoKey and value uint64
+}
+
+type memoKey struct {
+	ptr unsafe.Pointer
+	typ reflect.Type
+	cfg config
+}
+
+// NewMemo returns an empty Memo for Options.Memo.
+func NewMemo() *Memo {
+	return &Memo{}
+}
+
+// Forget drops the digests of the struct pointed to by ptr, which must be a pointer.
+func (m *Memo) Forget(ptr any) {
+	v := reflect.ValueOf(ptr)
+
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return
+	}
+
+	m.sums.Range(func(key, _ any) bool {
+		if k := key.(memoKey); k.ptr == v.UnsafePointer() && k.typ == v.Type() {
+			m.sums.Delete(key)
+		}
+
+		return true
+	})
+}
+
+// Reset drops all digests.
+func (m *Memo) Reset() {
+	m.sums.Clear()
+}
+
+// hashMemo writes the digest of the struct behind the pointer value, hashing it with ehf
+// in a sub container on the first use.
+func (h *Hasher) hashMemo(value reflect.Value, ehf hashFunc, cfg config, c *container) error {
+	key := memoKey{ptr: value.UnsafePointer(), typ: value.Type(), cfg: cfg}
+
+	if sum, ok := h.opts.Memo.sums.Load(key); ok {
+		return c.writeUint64(sum.(uint64))
+	}
+
+	sum, err := h.subHash(c, func(tmp *container) error { return ehf(value.Elem(), tmp) })
+	if err != nil {
+		return err
+	}
+
+	h.opts.Memo.sums.Store(key, sum)
+
+	return c.writeUint64(sum)
+}
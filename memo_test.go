@@ -0,0 +1,99 @@
+package datahash_test
+
+import (
+	"hash"
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type countingWriter struct {
+	calls *int
+}
+
+func (w countingWriter) WriteHash(h hash.Hash64) error {
+	*w.calls++
+
+	_, err := h.Write([]byte("counted"))
+
+	return err
+}
+
+type memoConfig struct {
+	Name    string
+	Counter countingWriter
+}
+
+type memoParent struct {
+	ID     int
+	Config *memoConfig
+}
+
+func TestHasher_Memo(t *testing.T) {
+	var calls int
+
+	memo := datahash.NewMemo()
+	hasher := datahash.New(fnv.New64a, datahash.Options{Memo: memo})
+
+	config := &memoConfig{Name: "a", Counter: countingWriter{calls: &calls}}
+	parents := []memoParent{{ID: 1, Config: config}, {ID: 2, Config: config}}
+
+	first := hasher.MustHash(parents)
+
+	if hasher.MustHash(parents) != first || hasher.MustHash(parents[0]) == hasher.MustHash(parents[1]) {
+		t.Fatal("expected memoized hashes to be stable and distinguish parents")
+	}
+
+	if calls != 1 {
+		t.Errorf("expected the shared config to be hashed once, got %d", calls)
+	}
+
+	config.Name = "b"
+
+	if hasher.MustHash(parents) != first {
+		t.Error("expected the stale digest until the config is forgotten")
+	}
+
+	memo.Forget(config)
+
+	if hasher.MustHash(parents) == first {
+		t.Error("expected a new hash after Forget")
+	}
+
+	config.Name = "a"
+	memo.Reset()
+
+	if hasher.MustHash(parents) != first {
+		t.Error("expected the original hash after Reset")
+	}
+
+	if calls != 3 {
+		t.Errorf("expected the config to be hashed again after invalidation, got %d calls", calls)
+	}
+
+	other := &memoConfig{Name: "a", Counter: countingWriter{calls: &calls}}
+
+	if hasher.MustHash(memoParent{ID: 1, Config: other}) != hasher.MustHash(parents[0]) {
+		t.Error("expected equal structs behind different pointers to hash equally")
+	}
+}
+
+type memoNode struct {
+	Value int
+	Next  *memoNode
+}
+
+func TestHasher_MemoCycle(t *testing.T) {
+	for _, format := range []datahash.Format{datahash.FormatV1, datahash.FormatV2} {
+		hasher := datahash.New(fnv.New64a, datahash.Options{Memo: datahash.NewMemo(), Format: format})
+		plain := datahash.New(fnv.New64a, datahash.Options{Format: format})
+
+		n := &memoNode{Value: 1}
+		n.Next = n
+
+		if hasher.MustHash(n) != plain.MustHash(n) {
+			t.Errorf("expected cyclic graphs to hash as without Memo in %v", format)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package datahash
+
+import (
+	"errors"
+	"reflect"
+)
+
+var uint64Type = reflect.TypeFor[uint64]()
+
+// hashMethod returns the index of the Options.HashMethod method of t and whether t has one,
+// i.e. an exported method of that name with the signature func() uint64.
+func (h *Hasher) hashMethod(t reflect.Type) (int, bool) {
+	if h.opts.HashMethod == "" || t.Kind() == reflect.Interface {
+		return 0, false
+	}
+
+	m, ok := t.MethodByName(h.opts.HashMethod)
+	if !ok || m.Type.NumIn() != 1 || m.Type.NumOut() != 1 || m.Type.Out(0) != uint64Type {
+		return 0, false
+	}
+
+	return m.Index, true
+}
+
+// hashByMethod returns a hashFunc writing the uint64 returned by the method with the given index.
+func (h *Hasher) hashByMethod(index int, cfg config) hashFunc {
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+			return nil
+		}
+
+		if !value.CanInterface() {
+			return errors.New("cannot use " + h.opts.HashMethod + " on unexported fields that are not accessible via reflection")
+		}
+
+		if value.Kind() == reflect.Pointer && value.IsNil() {
+			return nil
+		}
+
+		return c.writeUint64(value.Method(index).Call(nil)[0].Uint())
+	}
+}
@@ -0,0 +1,51 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type precomputed struct {
+	Payload []byte
+	sum     uint64
+}
+
+func (p precomputed) Hash64() uint64 { return p.sum }
+
+type wrongSignature struct{ Name string }
+
+func (wrongSignature) Hash64() int { return 1 }
+
+func TestHasher_HashMethod(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{HashMethod: "Hash64"})
+
+	if hasher.MustHash(precomputed{sum: 42}) != hasher.MustHash(uint64(42)) {
+		t.Error("expected types with the hash method to hash by its result")
+	}
+
+	if hasher.MustHash(precomputed{Payload: []byte("a"), sum: 42}) != hasher.MustHash(precomputed{Payload: []byte("b"), sum: 42}) {
+		t.Error("expected the content to be ignored in favor of the hash method")
+	}
+
+	if hasher.MustHash(&precomputed{sum: 1}) == hasher.MustHash(&precomputed{sum: 2}) {
+		t.Error("expected pointers to types with the hash method to hash by its result")
+	}
+
+	if hasher.MustHash(wrongSignature{Name: "a"}) == hasher.MustHash(wrongSignature{Name: "b"}) {
+		t.Error("expected methods with another signature to be ignored")
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	if plain.MustHash(precomputed{Payload: []byte("a"), sum: 42}) == plain.MustHash(precomputed{Payload: []byte("b"), sum: 42}) {
+		t.Error("expected the hash method to be ignored without HashMethod")
+	}
+
+	if explain := hasher.ExplainType(reflect.TypeFor[precomputed]()); !strings.Contains(explain, "Hash64() uint64") {
+		t.Errorf("expected the hash method to be explained, got %s", explain)
+	}
+}
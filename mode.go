@@ -0,0 +1,63 @@
+package datahash
+
+import "reflect"
+
+// Mode selects how values without hashable content, such as funcs and channels, are hashed.
+type Mode uint8
+
+// Supported modes.
+const (
+	// ModeDefault keeps the built-in handling: funcs fail to compile with an unsupported type error
+	// and channels are consumed like iter.Seq.
+	ModeDefault Mode = iota
+
+	// ModeIdentity hashes values by their pointer identity. Identities are only stable within a
+	// single process, so the resulting hashes must not be persisted.
+	ModeIdentity
+
+	// ModeSkip ignores values, like struct fields tagged `datahash:"-"`.
+	ModeSkip
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeDefault:
+		return "default"
+	case ModeIdentity:
+		return "identity"
+	case ModeSkip:
+		return "skip"
+	}
+
+	return "unknown"
+}
+
+// kindMode returns the Mode configured for t, or ModeDefault if t is not subject to a Mode.
+// Funcs implementing iter.Seq or iter.Seq2 are hashed as iterators instead.
+func (h *Hasher) kindMode(t reflect.Type) Mode {
+	switch t.Kind() {
+	case reflect.Func:
+		if !t.CanSeq() && !t.CanSeq2() {
+			return h.opts.Funcs
+		}
+	case reflect.Chan:
+		return h.opts.Chans
+	}
+
+	return ModeDefault
+}
+
+// hashByMode returns the hashFunc for a type whose kindMode is not ModeDefault.
+func (h *Hasher) hashByMode(mode Mode, info *TypeInfo) hashFunc {
+	if mode == ModeSkip {
+		info.Reason = "skipped"
+
+		return noop
+	}
+
+	info.Reason = "by identity"
+
+	return func(value reflect.Value, c *container) error {
+		return c.writeUint64(uint64(value.Pointer()))
+	}
+}
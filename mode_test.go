@@ -0,0 +1,102 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type withCallback struct {
+	Name     string
+	OnChange func(string)
+}
+
+func TestHasher_Funcs(t *testing.T) {
+	upper := func(string) {}
+	lower := func(string) {}
+
+	if _, err := datahash.New(fnv.New64a, datahash.Options{}).Hash(withCallback{}); err == nil {
+		t.Error("expected func fields to be rejected by default")
+	}
+
+	identity := datahash.New(fnv.New64a, datahash.Options{Funcs: datahash.ModeIdentity})
+
+	if identity.MustHash(withCallback{OnChange: upper}) != identity.MustHash(withCallback{OnChange: upper}) {
+		t.Error("expected the same func to hash equally")
+	}
+
+	if identity.MustHash(withCallback{OnChange: upper}) == identity.MustHash(withCallback{}) {
+		t.Error("expected a func and nil to differ")
+	}
+
+	if identity.MustHash(upper) == identity.MustHash(lower) {
+		t.Error("expected different funcs to differ")
+	}
+
+	skip := datahash.New(fnv.New64a, datahash.Options{Funcs: datahash.ModeSkip})
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	if skip.MustHash(withCallback{Name: "a", OnChange: upper}) != plain.MustHash(struct{ Name string }{Name: "a"}) {
+		t.Error("expected skipped func fields to be dropped")
+	}
+
+	if skip.MustHash([]func(string){upper}) != skip.MustHash([]func(string){lower}) {
+		t.Error("expected skipped funcs to hash alike")
+	}
+
+	if skip.MustHash(withCallback{Name: "a"}) == skip.MustHash(withCallback{Name: "b"}) {
+		t.Error("expected other fields to be hashed")
+	}
+
+	if got := skip.ExplainType(reflect.TypeFor[withCallback]()); !strings.Contains(got, "OnChange: skipped (func in ModeSkip)") {
+		t.Errorf("unexpected explanation:\n%s", got)
+	}
+
+	seq := func(yield func(int) bool) { yield(1) }
+
+	if identity.MustHash(seq) != plain.MustHash(seq) {
+		t.Error("expected iterators to be unaffected")
+	}
+}
+
+type worker struct {
+	ID   int
+	Jobs chan int
+	Done <-chan struct{}
+}
+
+func TestHasher_Chans(t *testing.T) {
+	jobs, other := make(chan int, 1), make(chan int, 1)
+
+	identity := datahash.New(fnv.New64a, datahash.Options{Chans: datahash.ModeIdentity})
+
+	jobs <- 1
+
+	if identity.MustHash(worker{Jobs: jobs}) != identity.MustHash(worker{Jobs: jobs}) {
+		t.Error("expected the same channel to hash equally")
+	}
+
+	if len(jobs) != 1 {
+		t.Error("expected the channel not to be drained")
+	}
+
+	if identity.MustHash(worker{Jobs: jobs}) == identity.MustHash(worker{Jobs: other}) {
+		t.Error("expected different channels to differ")
+	}
+
+	skip := datahash.New(fnv.New64a, datahash.Options{Chans: datahash.ModeSkip})
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	if skip.MustHash(worker{ID: 1, Jobs: jobs}) != plain.MustHash(struct{ ID int }{ID: 1}) {
+		t.Error("expected skipped chan fields to be dropped")
+	}
+
+	strict := datahash.New(fnv.New64a, datahash.Options{StrictKinds: true, Chans: datahash.ModeSkip})
+
+	if _, err := strict.Hash(worker{Jobs: jobs}); err != nil {
+		t.Errorf("expected an explicit Chans mode to satisfy StrictKinds: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package datahash
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"reflect"
+)
+
+var (
+	addrType     = reflect.TypeFor[netip.Addr]()
+	prefixType   = reflect.TypeFor[netip.Prefix]()
+	addrPortType = reflect.TypeFor[netip.AddrPort]()
+	netIPType    = reflect.TypeFor[net.IP]()
+)
+
+// hashNetIP returns a hashFunc for netip.Addr, netip.Prefix, netip.AddrPort and net.IP values
+// in FormatV2 and later, or nil. Marshalers forced by struct tags take precedence.
+//
+// Addresses are hashed as their 16-byte form, their bit length and their zone, prefixes as their
+// address and number of bits, and address ports as their address and port. net.IP values are
+// hashed like the netip.Addr of the unmapped address, so their 4-byte and 16-byte forms of the
+// same IPv4 address hash equally. FormatV1 hashes them by MarshalBinary and as raw bytes.
+func (h *Hasher) hashNetIP(t reflect.Type, cfg config) hashFunc {
+	if h.opts.Format < FormatV2 || cfg.prefer != "" {
+		return nil
+	}
+
+	switch t {
+	case addrType, prefixType, addrPortType:
+	case netIPType:
+		return h.distinguishNil(func(value reflect.Value, c *container) error {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) || value.Len() == 0 {
+				return nil
+			}
+
+			addr, ok := netip.AddrFromSlice(value.Bytes())
+			if !ok {
+				return c.write(value.Bytes())
+			}
+
+			return writeAddr(addr.Unmap(), c)
+		}, cfg)
+	default:
+		return nil
+	}
+
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+			return nil
+		}
+
+		if !value.CanInterface() {
+			return fmt.Errorf("datahash: cannot hash %s in unexported fields that are not accessible via reflection", value.Type())
+		}
+
+		switch v := value.Interface().(type) {
+		case netip.Prefix:
+			return twoErr(writeAddr(v.Addr(), c), c.writeUint64(uint64(v.Bits()))) //nolint:gosec
+		case netip.AddrPort:
+			return twoErr(writeAddr(v.Addr(), c), c.writeUint64(uint64(v.Port())))
+		}
+
+		return writeAddr(value.Interface().(netip.Addr), c)
+	}
+}
+
+// writeAddr writes the 16-byte form, the bit length and the length-prefixed zone of addr.
+func writeAddr(addr netip.Addr, c *container) error {
+	b := addr.As16()
+
+	if !addr.IsValid() {
+		b = [16]byte{}
+	}
+
+	zone := addr.Zone()
+
+	return twoErr(
+		threeErr(c.write(b[:]), c.write([]byte{byte(addr.BitLen())}), c.writeUint64(uint64(len(zone)))),
+		c.write(stringToBytes(zone)),
+	)
+}
@@ -0,0 +1,60 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_NetIP(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV2})
+
+	type network struct {
+		Addr   netip.Addr
+		Prefix netip.Prefix
+		Listen netip.AddrPort
+		IP     net.IP
+	}
+
+	base := network{
+		Addr:   netip.MustParseAddr("fe80::1%eth0"),
+		Prefix: netip.MustParsePrefix("10.0.0.0/8"),
+		Listen: netip.MustParseAddrPort("127.0.0.1:8080"),
+		IP:     net.IPv4(192, 168, 0, 1),
+	}
+
+	short := base
+	short.IP = net.IP{192, 168, 0, 1}
+
+	if hasher.MustHash(base) != hasher.MustHash(short) {
+		t.Error("expected the 4-byte and 16-byte forms of an IPv4 net.IP to hash equally")
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	if plain.MustHash(base) == plain.MustHash(short) {
+		t.Error("expected FormatV1 to keep hashing net.IP as raw bytes")
+	}
+
+	for _, changed := range []network{
+		{Addr: netip.MustParseAddr("fe80::1%eth1"), Prefix: base.Prefix, Listen: base.Listen, IP: base.IP},
+		{Addr: base.Addr, Prefix: netip.MustParsePrefix("10.0.0.0/16"), Listen: base.Listen, IP: base.IP},
+		{Addr: base.Addr, Prefix: base.Prefix, Listen: netip.MustParseAddrPort("127.0.0.1:8081"), IP: base.IP},
+		{Addr: base.Addr, Prefix: base.Prefix, Listen: base.Listen, IP: net.IPv4(192, 168, 0, 2)},
+	} {
+		if hasher.MustHash(changed) == hasher.MustHash(base) {
+			t.Errorf("expected %+v to hash differently", changed)
+		}
+	}
+
+	if hasher.MustHash(netip.MustParseAddr("1.2.3.4")) == hasher.MustHash(netip.MustParseAddr("::ffff:1.2.3.4")) {
+		t.Error("expected IPv4 and IPv4-mapped IPv6 addresses to stay distinct, like in net/netip")
+	}
+
+	if hasher.MustHash(netip.Addr{}) == hasher.MustHash(netip.IPv6Unspecified()) {
+		t.Error("expected the zero Addr to differ from ::")
+	}
+}
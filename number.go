@@ -0,0 +1,108 @@
+package datahash
+
+import (
+	"math"
+	"reflect"
+)
+
+// Classes of normalized numbers, written before their 8-byte representations.
+var (
+	numberInt     = [1]byte{'i'}
+	numberUint    = [1]byte{'u'}
+	numberFloat   = [1]byte{'f'}
+	numberComplex = [1]byte{'c'}
+)
+
+// hashNumber returns a hashFunc for numeric kinds in NormalizeNumbers mode, or nil.
+func (h *Hasher) hashNumber(t reflect.Type) hashFunc {
+	if !h.opts.NormalizeNumbers {
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(value reflect.Value, c *container) error {
+			return twoErr(
+				c.write(numberInt[:]),
+				c.writeUint64(uint64(value.Int())), //nolint:gosec
+			)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(value reflect.Value, c *container) error {
+			if u := value.Uint(); u > math.MaxInt64 {
+				return twoErr(c.write(numberUint[:]), c.writeUint64(u))
+			}
+
+			return twoErr(c.write(numberInt[:]), c.writeUint64(value.Uint()))
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(value reflect.Value, c *container) error {
+			return writeNumber(c, value.Float())
+		}
+	case reflect.Complex64, reflect.Complex128:
+		return func(value reflect.Value, c *container) error {
+			v := value.Complex()
+
+			if imag(v) == 0 {
+				return writeNumber(c, real(v))
+			}
+
+			return threeErr(
+				c.write(numberComplex[:]),
+				c.writeFloat64(normalizeFloat(real(v))),
+				c.writeFloat64(normalizeFloat(imag(v))),
+			)
+		}
+	}
+
+	return nil
+}
+
+// writeNumber writes the normalized representation of the real number v.
+func writeNumber(c *container, v float64) error {
+	switch {
+	case v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64:
+		return twoErr(c.write(numberInt[:]), c.writeUint64(uint64(int64(v)))) //nolint:gosec
+	case v == math.Trunc(v) && v >= math.MaxInt64 && v < math.MaxUint64:
+		return twoErr(c.write(numberUint[:]), c.writeUint64(uint64(v)))
+	}
+
+	return twoErr(c.write(numberFloat[:]), c.writeFloat64(normalizeFloat(v)))
+}
+
+// canonicalNumber returns the canonical form of the number v in NormalizeNumbers mode, of the
+// class hashNumber writes: int64, uint64, float64 or complex128. It reports false for other kinds.
+func canonicalNumber(v reflect.Value) (any, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if u := v.Uint(); u > math.MaxInt64 {
+			return u, true
+		}
+
+		return int64(v.Uint()), true //nolint:gosec
+	case reflect.Float32, reflect.Float64:
+		return realNumber(v.Float()), true
+	case reflect.Complex64, reflect.Complex128:
+		if c := v.Complex(); imag(c) != 0 {
+			return complex(normalizeFloat(real(c)), normalizeFloat(imag(c))), true
+		}
+
+		return realNumber(real(v.Complex())), true
+	}
+
+	return nil, false
+}
+
+// realNumber returns the canonical form of the real number v, as writeNumber writes it.
+func realNumber(v float64) any {
+	switch {
+	case v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64:
+		return int64(v)
+	case v == math.Trunc(v) && v >= math.MaxInt64 && v < math.MaxUint64:
+		return uint64(v)
+	}
+
+	return normalizeFloat(v)
+}
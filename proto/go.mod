@@ -0,0 +1,15 @@
+module github.com/go-sqlt/datahash/proto
+
+go 1.24.2
+
+require (
+	github.com/go-sqlt/datahash v0.0.0
+	google.golang.org/protobuf v1.36.12
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	golang.org/x/text v0.34.0 // indirect
+)
+
+replace github.com/go-sqlt/datahash => ../
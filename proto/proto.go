@@ -0,0 +1,41 @@
+// Package proto hashes protocol buffer messages with a datahash.Hasher by their deterministic
+// binary encoding instead of by reflection over the generated structs, whose unexported message
+// state, size caches and unknown field buffers make structural hashing fragile.
+//
+// Usage:
+//
+//	hasher := datahash.New(xxhash.New, datahash.Options{})
+//	proto.Register(hasher)
+//
+//	sum, err := hasher.Hash(&pb.User{Name: "Ada"})
+package proto
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/go-sqlt/datahash"
+	"google.golang.org/protobuf/proto"
+)
+
+// Register makes h hash all implementations of proto.Message, e.g. generated *pb.User types,
+// including messages nested in other Go values, by their deterministic binary encoding.
+//
+// Equal messages hash equally regardless of the iteration order of their map fields and the
+// internal state of the generated structs; unknown fields are hashed as well. The deterministic
+// encoding is stable for a given version of the protobuf module, but not across languages.
+// Nil messages hash like empty ones.
+func Register(h *datahash.Hasher) {
+	h.OverrideImplements(reflect.TypeFor[proto.Message](), func(value reflect.Value, w io.Writer) error {
+		msg, _ := value.Interface().(proto.Message)
+
+		b, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return err
+		}
+
+		_, err = w.Write(b)
+
+		return err
+	})
+}
@@ -0,0 +1,58 @@
+package proto_test
+
+import (
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+	"github.com/go-sqlt/datahash/proto"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestRegister(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+	proto.Register(hasher)
+
+	type event struct {
+		ID      int
+		At      *timestamppb.Timestamp
+		Payload *structpb.Struct
+	}
+
+	payload := func() *structpb.Struct {
+		s, err := structpb.NewStruct(map[string]any{"a": 1, "b": "x", "c": []any{true}, "d": nil})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return s
+	}
+
+	a := event{ID: 1, At: timestamppb.New(timestamppb.Now().AsTime()), Payload: payload()}
+	b := event{ID: 1, At: &timestamppb.Timestamp{Seconds: a.At.Seconds, Nanos: a.At.Nanos}, Payload: payload()}
+
+	// Sizing a message fills its size cache.
+	_ = protobuf.Size(a.Payload)
+
+	if hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected equal messages to hash equally")
+	}
+
+	b.At.Nanos++
+
+	if hasher.MustHash(a) == hasher.MustHash(b) {
+		t.Error("expected different messages to hash differently")
+	}
+
+	if hasher.MustHash(any(a.Payload)) != hasher.MustHash(payload()) {
+		t.Error("expected messages in interfaces to be hashed by their encoding")
+	}
+
+	if explain := hasher.ExplainType(reflect.TypeFor[*timestamppb.Timestamp]()); !strings.Contains(explain, "custom") {
+		t.Errorf("expected the custom strategy, got %s", explain)
+	}
+}
@@ -0,0 +1,34 @@
+package datahash
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// hashRaw returns a hashFunc writing values of t, a string, byte slice or byte array type,
+// as their bytes without framing, string normalization or marshalers, for fields tagged
+// with the raw directive.
+func (h *Hasher) hashRaw(t reflect.Type, cfg config) (hashFunc, error) {
+	switch {
+	case t.Kind() == reflect.String:
+		return func(value reflect.Value, c *container) error {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+				return nil
+			}
+
+			return c.write(stringToBytes(value.String()))
+		}, nil
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return func(value reflect.Value, c *container) error {
+			if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+				return nil
+			}
+
+			return c.write(value.Bytes())
+		}, nil
+	case t.Kind() == reflect.Array && t.Elem().Kind() == reflect.Uint8:
+		return h.hashByteArray(cfg), nil
+	}
+
+	return nil, fmt.Errorf("datahash: struct tag directive raw requires a string, byte slice or byte array, got %s", t)
+}
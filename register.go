@@ -0,0 +1,141 @@
+package datahash
+
+import (
+	"errors"
+	"io"
+	"reflect"
+)
+
+// RegisterHashFunc registers fn as the hash function of values of type T for h,
+// e.g. for third-party types like uuid.UUID that cannot implement HashWriter.
+//
+// fn takes precedence over all other handling of T, including HashWriter and the
+// marshaling interfaces. RegisterHashFunc resets the compiled types of h, so it should
+// be called while setting up the Hasher, not concurrently with Hash.
+func RegisterHashFunc[T any](h *Hasher, fn func(T, io.Writer) error) {
+	t := reflect.TypeFor[T]()
+
+	h.register(t, func(value reflect.Value, c *container) error {
+		if !value.CanInterface() {
+			return errors.New("datahash: cannot use a registered hash function on unexported fields that are not accessible via reflection")
+		}
+
+		v, _ := value.Interface().(T)
+
+		return marshalerErr(t, StrategyCustom, fn(v, c.hash))
+	})
+}
+
+// Override replaces the handling of values of type t with fn, e.g. to hash time.Time by its
+// Unix seconds or url.URL by its normalized string, instead of the built-in, marshaler or
+// kind-based handling. Like RegisterHashFunc, it resets the compiled types of h.
+func (h *Hasher) Override(t reflect.Type, fn func(value reflect.Value, w io.Writer) error) {
+	h.register(t, func(value reflect.Value, c *container) error {
+		return marshalerErr(t, StrategyCustom, fn(value, c.hash))
+	})
+}
+
+// OverrideImplements replaces the handling of all non-interface types implementing the interface
+// iface with fn, like Override, e.g. for generated types that share an interface such as
+// proto.Message. Types registered with Override or RegisterHashFunc take precedence, as do
+// interfaces registered earlier. It panics if iface is not an interface type.
+func (h *Hasher) OverrideImplements(iface reflect.Type, fn func(value reflect.Value, w io.Writer) error) {
+	if iface.Kind() != reflect.Interface {
+		panic("datahash: OverrideImplements of non-interface type " + iface.String())
+	}
+
+	hf := func(value reflect.Value, c *container) error {
+		return marshalerErr(value.Type(), StrategyCustom, fn(value, c.hash))
+	}
+
+	h.scalars = false
+
+	h.compileMu.Lock()
+	defer h.compileMu.Unlock()
+
+	h.implementers = append(h.implementers, implementer{iface: iface, hf: hf})
+
+	h.hashFuncMap.Clear()
+	h.typeInfoMap.Clear()
+}
+
+// Structural forces the given types, and pointers to them, to be hashed by their kind, e.g. structs
+// by their fields, even though they implement HashEncoder, HashWriter or a marshaling interface,
+// or have built-in handling. Struct tag directives forcing a marshaler and functions registered
+// with Override or RegisterHashFunc take precedence. Like Override, it resets the compiled types of h.
+func (h *Hasher) Structural(types ...reflect.Type) {
+	h.compileMu.Lock()
+	defer h.compileMu.Unlock()
+
+	if h.structural == nil {
+		h.structural = make(map[reflect.Type]struct{}, len(types))
+	}
+
+	for _, t := range types {
+		h.structural[t] = struct{}{}
+	}
+
+	h.hashFuncMap.Clear()
+	h.typeInfoMap.Clear()
+}
+
+// isStructural reports whether t or the element of the pointer type t was registered with Structural.
+func (h *Hasher) isStructural(t reflect.Type) bool {
+	if _, ok := h.structural[t]; ok {
+		return true
+	}
+
+	if t.Kind() == reflect.Pointer {
+		_, ok := h.structural[t.Elem()]
+
+		return ok
+	}
+
+	return false
+}
+
+// implementer is a hash function registered for the implementations of an interface.
+type implementer struct {
+	iface reflect.Type
+	hf    hashFunc
+}
+
+func (h *Hasher) register(t reflect.Type, hf hashFunc) {
+	h.custom.Store(t, hf)
+	h.scalars = false
+
+	h.compileMu.Lock()
+	defer h.compileMu.Unlock()
+
+	h.hashFuncMap.Clear()
+	h.typeInfoMap.Clear()
+}
+
+// customHashFunc returns the registered hash function of t, if any.
+func (h *Hasher) customHashFunc(t reflect.Type, cfg config) hashFunc {
+	var hf hashFunc
+
+	if v, ok := h.custom.Load(t); ok {
+		hf, _ = v.(hashFunc)
+	} else if t.Kind() != reflect.Interface {
+		for _, impl := range h.implementers {
+			if t.Implements(impl.iface) {
+				hf = impl.hf
+
+				break
+			}
+		}
+	}
+
+	if hf == nil {
+		return nil
+	}
+
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+			return nil
+		}
+
+		return hf(value, c)
+	}
+}
@@ -0,0 +1,186 @@
+package datahash_test
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type decimal struct {
+	Value string
+}
+
+func TestRegisterHashFunc(t *testing.T) {
+	type order struct {
+		ID    int
+		Price decimal
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	before, err := hasher.Hash(order{ID: 1, Price: decimal{"1.50"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Normalize trailing zeros so equal amounts hash equally.
+	datahash.RegisterHashFunc(hasher, func(d decimal, w io.Writer) error {
+		_, err := io.WriteString(w, strings.TrimRight(d.Value, "0"))
+
+		return err
+	})
+
+	a, err := hasher.Hash(order{ID: 1, Price: decimal{"1.50"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, _ := hasher.Hash(order{ID: 1, Price: decimal{"1.5"}})
+
+	if a != b {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", b, a)
+	}
+
+	if a == before {
+		t.Error("expected registration to apply to compiled types")
+	}
+
+	datahash.RegisterHashFunc(hasher, func(decimal, io.Writer) error {
+		return errors.New("boom")
+	})
+
+	if _, err := hasher.Hash(order{}); err == nil {
+		t.Error("expected error from registered hash function")
+	}
+}
+
+func TestHasher_Override(t *testing.T) {
+	type event struct {
+		At  time.Time
+		URL url.URL
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	hasher.Override(reflect.TypeFor[time.Time](), func(value reflect.Value, w io.Writer) error {
+		_, err := fmt.Fprint(w, value.Interface().(time.Time).Unix())
+
+		return err
+	})
+
+	hasher.Override(reflect.TypeFor[url.URL](), func(value reflect.Value, w io.Writer) error {
+		u := value.Interface().(url.URL)
+
+		_, err := io.WriteString(w, strings.ToLower(u.Scheme+"://"+u.Host)+u.EscapedPath())
+
+		return err
+	})
+
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	a, err := hasher.Hash(event{At: at, URL: url.URL{Scheme: "https", Host: "Example.com", Path: "/a"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := hasher.Hash(event{At: at.Add(time.Millisecond).In(time.FixedZone("X", 3600)), URL: url.URL{Scheme: "HTTPS", Host: "example.com", Path: "/a"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a != b {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", b, a)
+	}
+}
+
+type versioned interface {
+	Version() int
+}
+
+type revision struct {
+	Rev   int
+	Cache []byte
+}
+
+func (d *revision) Version() int { return d.Rev }
+
+func TestHasher_OverrideImplements(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	hasher.OverrideImplements(reflect.TypeFor[versioned](), func(value reflect.Value, w io.Writer) error {
+		_, err := fmt.Fprint(w, value.Interface().(versioned).Version())
+
+		return err
+	})
+
+	type holder struct {
+		Doc *revision
+		Any any
+	}
+
+	a := holder{Doc: &revision{Rev: 1, Cache: []byte("a")}, Any: &revision{Rev: 2}}
+	b := holder{Doc: &revision{Rev: 1, Cache: []byte("b")}, Any: &revision{Rev: 2, Cache: []byte("c")}}
+
+	if hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected implementations to be hashed by the override")
+	}
+
+	if hasher.MustHash(revision{Rev: 1, Cache: []byte("a")}) == hasher.MustHash(revision{Rev: 1}) {
+		t.Error("expected types not implementing the interface to be hashed as before")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for non-interface types")
+		}
+	}()
+
+	hasher.OverrideImplements(reflect.TypeFor[revision](), nil)
+}
+
+type badge struct {
+	Name  string
+	Color string
+}
+
+func (l badge) MarshalText() ([]byte, error) { return []byte(l.Name), nil }
+
+func TestHasher_Structural(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{Text: true})
+
+	red, blue := badge{Name: "a", Color: "red"}, badge{Name: "a", Color: "blue"}
+
+	if hasher.MustHash(red) != hasher.MustHash(blue) {
+		t.Fatal("expected labels to hash by MarshalText before Structural")
+	}
+
+	hasher.Structural(reflect.TypeFor[badge]())
+
+	if hasher.MustHash(red) == hasher.MustHash(blue) {
+		t.Error("expected structural types to hash by their fields")
+	}
+
+	if hasher.MustHash(&red) == hasher.MustHash(&blue) {
+		t.Error("expected pointers to structural types to hash by their fields")
+	}
+
+	if explain := hasher.ExplainType(reflect.TypeFor[badge]()); strings.Contains(explain, "TextMarshaler") {
+		t.Errorf("expected structural types not to be explained as marshaled, got %s", explain)
+	}
+
+	type tagged struct {
+		Label badge `datahash:"text"`
+	}
+
+	if hasher.MustHash(tagged{red}) != hasher.MustHash(tagged{blue}) {
+		t.Error("expected struct tags to force the marshaler of structural types")
+	}
+}
@@ -0,0 +1,99 @@
+// Package seen provides a Bloom filter over datahash hashes for deduplication in bounded memory.
+//
+// A Filter never reports a seen hash as new, but may report a new hash as seen
+// with the configured false-positive rate. It implements datahash.SeenSet:
+//
+//	filter := seen.New(1_000_000, 0.001)
+//
+//	for event, err := range datahash.Dedup(hasher, events, filter) {
+//		...
+//	}
+package seen
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// Filter is a Bloom filter of 64-bit hashes. It is safe for concurrent use.
+type Filter struct {
+	bits []atomic.Uint64
+	m    uint64
+	k    uint64
+}
+
+// New creates a Filter sized for n hashes with the false-positive rate p, e.g. 0.01.
+//
+// The k bit positions of a hash are derived with double hashing from the single 64-bit
+// hash, so values are hashed only once.
+func New(n int, p float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := math.Max(1, math.Round(m/float64(n)*math.Ln2))
+
+	words := (uint64(m) + 63) / 64
+
+	return &Filter{
+		bits: make([]atomic.Uint64, words),
+		m:    words * 64,
+		k:    uint64(k),
+	}
+}
+
+// Add records sum and reports whether it was not contained before.
+func (f *Filter) Add(sum uint64) bool {
+	added := false
+
+	h1, h2 := split(sum)
+
+	for i := range f.k {
+		pos := (h1 + i*h2) % f.m
+		mask := uint64(1) << (pos % 64)
+
+		if f.bits[pos/64].Or(mask)&mask == 0 {
+			added = true
+		}
+	}
+
+	return added
+}
+
+// Contains reports whether sum was probably added before.
+func (f *Filter) Contains(sum uint64) bool {
+	h1, h2 := split(sum)
+
+	for i := range f.k {
+		pos := (h1 + i*h2) % f.m
+
+		if f.bits[pos/64].Load()&(uint64(1)<<(pos%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Reset clears the filter.
+func (f *Filter) Reset() {
+	for i := range f.bits {
+		f.bits[i].Store(0)
+	}
+}
+
+// split derives two independent hashes from sum for double hashing.
+// The second hash is the splitmix64 finalizer of sum and always odd.
+func split(sum uint64) (uint64, uint64) {
+	z := sum + 0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	z ^= z >> 31
+
+	return sum, z | 1
+}
@@ -0,0 +1,70 @@
+package seen_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-sqlt/datahash"
+	"github.com/go-sqlt/datahash/seen"
+)
+
+var _ datahash.SeenSet = (*seen.Filter)(nil)
+
+func TestFilter(t *testing.T) {
+	filter := seen.New(10_000, 0.01)
+
+	collisions := 0
+
+	for i := range uint64(10_000) {
+		if !filter.Add(i * 0x9e3779b97f4a7c15) {
+			collisions++
+		}
+	}
+
+	if collisions > 300 {
+		t.Errorf("new hashes reported as seen: %d of 10000", collisions)
+	}
+
+	for i := range uint64(10_000) {
+		if !filter.Contains(i * 0x9e3779b97f4a7c15) {
+			t.Fatalf("hash %d not contained", i)
+		}
+	}
+
+	falsePositives := 0
+
+	for i := range uint64(10_000) {
+		if filter.Contains(^(i * 0x9e3779b97f4a7c15)) {
+			falsePositives++
+		}
+	}
+
+	if falsePositives > 300 {
+		t.Errorf("false positives: %d of 10000", falsePositives)
+	}
+
+	filter.Reset()
+
+	if filter.Contains(0) {
+		t.Error("expected empty filter after Reset")
+	}
+}
+
+func TestFilter_Dedup(t *testing.T) {
+	hasher := datahash.New(xxhash.New, datahash.Options{})
+
+	var got []string
+
+	for value, err := range datahash.Dedup(hasher, slices.Values([]string{"a", "b", "a", "c"}), seen.New(100, 0.001)) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		got = append(got, value)
+	}
+
+	if !slices.Equal(got, []string{"a", "b", "c"}) {
+		t.Errorf("unexpected values: %v", got)
+	}
+}
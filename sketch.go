@@ -0,0 +1,277 @@
+package datahash
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"reflect"
+	"strings"
+)
+
+// MinHash computes a MinHash signature of length k over the elements of value.
+//
+// The elements are the items of slices, arrays and iter.Seq values, the key:value
+// entries of maps and iter.Seq2 values, the name:value pairs of struct fields and the
+// whitespace-separated tokens of strings. Each element is hashed with the same canonical
+// traversal as Hash. Use Jaccard to estimate the similarity of two signatures.
+func (h *Hasher) MinHash(value any, k int) ([]uint64, error) {
+	if k <= 0 {
+		return nil, errors.New("datahash: MinHash signature length must be positive")
+	}
+
+	signature := make([]uint64, k)
+
+	for i := range signature {
+		signature[i] = math.MaxUint64
+	}
+
+	err := h.elements(value, func(sum uint64) {
+		for i := range signature {
+			if v := mix(sum + uint64(i)*0x9e3779b97f4a7c15); v < signature[i] { //nolint:gosec
+				signature[i] = v
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// Jaccard estimates the Jaccard similarity of the element sets of two MinHash signatures.
+// It returns 0 if the signatures differ in length.
+func Jaccard(a, b []uint64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	equal := 0
+
+	for i := range a {
+		if a[i] == b[i] {
+			equal++
+		}
+	}
+
+	return float64(equal) / float64(len(a))
+}
+
+// SimHash computes a 64-bit locality-sensitive fingerprint of value.
+//
+// Every element of value (see MinHash) votes on the bits of the fingerprint with its hash,
+// so values that differ in few elements, e.g. a struct with one changed field, produce
+// fingerprints with a small Hamming distance. Use Distance to compare fingerprints.
+func (h *Hasher) SimHash(value any) (uint64, error) {
+	var votes [64]int
+
+	err := h.elements(value, func(sum uint64) {
+		sum = mix(sum)
+
+		for i := range votes {
+			if sum&(1<<i) != 0 {
+				votes[i]++
+			} else {
+				votes[i]--
+			}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var fingerprint uint64
+
+	for i, v := range votes {
+		if v > 0 {
+			fingerprint |= 1 << i
+		}
+	}
+
+	return fingerprint, nil
+}
+
+// Distance returns the Hamming distance of two SimHash fingerprints.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// elements calls yield with the hash of every element of value, as described by MinHash.
+// Values other than collections, structs and strings, or types with custom hashing, are a single element.
+func (h *Hasher) elements(value any, yield func(sum uint64)) error {
+	v := reflect.ValueOf(value)
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+
+	if !v.IsValid() {
+		return nil
+	}
+
+	c := h.acquire()
+	defer h.containerPool.Put(c)
+
+	t := v.Type()
+
+	hf, err := h.makeHashFunc(t, h.cfg)
+	if err != nil {
+		return err
+	}
+
+	emit := func(write func(tmp *container) error) error {
+		tmp := h.sub(c)
+		defer h.containerPool.Put(tmp)
+
+		tmp.Reset()
+
+		if err := write(tmp); err != nil {
+			return err
+		}
+
+		yield(tmp.hash.Sum64())
+
+		return nil
+	}
+
+	if info := h.typeInfo(t, h.cfg); info == nil || info.Strategy != StrategyKind {
+		return emit(func(tmp *container) error { return hf(v, tmp) })
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		for _, token := range strings.Fields(v.String()) {
+			if err := emit(func(tmp *container) error { return tmp.write(stringToBytes(token)) }); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case reflect.Slice, reflect.Array:
+		vhf, err := h.makeHashFunc(t.Elem(), h.cfg)
+		if err != nil {
+			return err
+		}
+
+		for i := range v.Len() {
+			if err := emit(func(tmp *container) error { return vhf(v.Index(i), tmp) }); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case reflect.Map:
+		khf, err := h.makeHashFunc(t.Key(), h.cfg)
+		if err != nil {
+			return err
+		}
+
+		vhf, err := h.makeHashFunc(t.Elem(), h.cfg)
+		if err != nil {
+			return err
+		}
+
+		iter := v.MapRange()
+
+		for iter.Next() {
+			if err := emit(func(tmp *container) error {
+				return threeErr(
+					khf(iter.Key(), tmp),
+					tmp.write(colon[:]),
+					vhf(iter.Value(), tmp),
+				)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case reflect.Struct:
+		sfs := h.structFields(t, h.cfg)
+
+		for i := range sfs {
+			sf := &sfs[i]
+			fv := sf.value(v)
+
+			if sf.skips(fv, h.cfg) {
+				continue
+			}
+
+			if err := emit(func(tmp *container) error {
+				return threeErr(
+					tmp.write(sf.name),
+					tmp.write(colon[:]),
+					sf.hf(fv, tmp),
+				)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	switch {
+	case t.CanSeq2():
+		for k, v := range v.Seq2() {
+			if err := h.countSeqElement(c); err != nil {
+				return err
+			}
+
+			if err := emit(func(tmp *container) error {
+				khf, err := h.makeHashFunc(k.Type(), h.cfg)
+				if err != nil {
+					return err
+				}
+
+				vhf, err := h.makeHashFunc(v.Type(), h.cfg)
+				if err != nil {
+					return err
+				}
+
+				return threeErr(
+					khf(k, tmp),
+					tmp.write(colon[:]),
+					vhf(v, tmp),
+				)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case t.CanSeq():
+		for v := range v.Seq() {
+			if err := h.countSeqElement(c); err != nil {
+				return err
+			}
+
+			if err := emit(func(tmp *container) error {
+				vhf, err := h.makeHashFunc(v.Type(), h.cfg)
+				if err != nil {
+					return err
+				}
+
+				return vhf(v, tmp)
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return emit(func(tmp *container) error { return hf(v, tmp) })
+}
+
+// mix is the splitmix64 finalizer. It spreads the bits of element hashes from weak hash functions.
+func mix(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,100 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_MinHash(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	a, err := hasher.MinHash([]string{"a", "b", "c", "d", "e", "f", "g", "h"}, 256)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := hasher.MinHash([]string{"h", "g", "f", "e", "d", "c", "b", "x"}, 256)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	c, err := hasher.MinHash("the quick brown fox", 256)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The true Jaccard similarity of a and b is 7/9.
+	if sim := datahash.Jaccard(a, b); sim < 0.6 || sim > 0.95 {
+		t.Errorf("unexpected similarity of a and b: %f", sim)
+	}
+
+	if sim := datahash.Jaccard(a, a); sim != 1 {
+		t.Errorf("unexpected self similarity: %f", sim)
+	}
+
+	if sim := datahash.Jaccard(a, c); sim > 0.1 {
+		t.Errorf("unexpected similarity of a and c: %f", sim)
+	}
+
+	type record struct {
+		Name  string
+		City  string
+		Email string
+		Age   int
+	}
+
+	r1, _ := hasher.MinHash(record{"Alice", "Berlin", "alice@example.com", 30}, 128)
+	r2, _ := hasher.MinHash(record{"Alice", "Berlin", "alice@example.com", 31}, 128)
+
+	if sim := datahash.Jaccard(r1, r2); sim < 0.4 || sim == 1 {
+		t.Errorf("unexpected similarity of records: %f", sim)
+	}
+
+	if _, err := hasher.MinHash([]int{1}, 0); err == nil {
+		t.Error("expected error for zero signature length")
+	}
+}
+
+func TestHasher_SimHash(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	type record struct {
+		Name    string
+		City    string
+		Email   string
+		Phone   string
+		Country string
+		Zip     string
+		Street  string
+		Age     int
+	}
+
+	base := record{"Alice", "Berlin", "alice@example.com", "+49 30 1234", "DE", "10115", "Unter den Linden 1", 30}
+
+	changed := base
+	changed.Age = 31
+
+	other := record{"Bob", "Paris", "bob@example.com", "+33 1 5678", "FR", "75001", "Rue de Rivoli 2", 45}
+
+	a, err := hasher.SimHash(base)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, _ := hasher.SimHash(changed)
+	c, _ := hasher.SimHash(other)
+
+	if a == b {
+		t.Error("expected different fingerprints")
+	}
+
+	if near, far := datahash.Distance(a, b), datahash.Distance(a, c); near >= far {
+		t.Errorf("expected near-duplicate to be closer: near %d, far %d", near, far)
+	}
+
+	if d := datahash.Distance(a, a); d != 0 {
+		t.Errorf("unexpected self distance: %d", d)
+	}
+}
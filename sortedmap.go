@@ -0,0 +1,131 @@
+package datahash
+
+import (
+	"cmp"
+	"reflect"
+	"slices"
+)
+
+// mapEntry is an entry of a map hashed in SortedMaps mode.
+type mapEntry struct {
+	key, value reflect.Value
+	sum        uint64 // Digest of the key or value, or of the entry to order entries with equal digests.
+}
+
+// hashSortedMap returns a hashFunc writing the entries of a map as an ordered list of key:value
+// pairs, sorted by the digests of their keys. Entries with equal key digests, which only occur
+// for colliding keys, are ordered by the digests of their key:value pairs. Maps hashing only
+// their values are sorted by the digests of their values.
+func (h *Hasher) hashSortedMap(khf, vhf hashFunc, cfg config) hashFunc {
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() {
+			return nil
+		}
+
+		var (
+			entries   = make([]mapEntry, 0, value.Len())
+			iter      = value.MapRange()
+			truncated bool
+			err       error
+		)
+
+		for iter.Next() {
+			if truncated, err = h.countElement(c); truncated || err != nil {
+				break
+			}
+
+			v := iter.Value()
+			if !v.IsValid() || (cfg.ignoreZero && isZero(v)) {
+				continue
+			}
+
+			k := iter.Key()
+
+			c.enterKey(k)
+
+			sum, err := h.subHash(c, func(tmp *container) error {
+				if cfg.mapValues && !cfg.mapKeys {
+					return vhf(v, tmp)
+				}
+
+				return khf(k, tmp)
+			})
+			if err != nil {
+				return keyErr(err, k)
+			}
+
+			c.leave()
+
+			entries = append(entries, mapEntry{key: k, value: v, sum: sum})
+		}
+
+		if err != nil {
+			return err
+		}
+
+		slices.SortFunc(entries, func(a, b mapEntry) int { return cmp.Compare(a.sum, b.sum) })
+
+		if err := h.orderCollisions(entries, khf, vhf, cfg, c); err != nil {
+			return err
+		}
+
+		if err := c.write(startList[:]); err != nil {
+			return err
+		}
+
+		for i, e := range entries {
+			if i > 0 {
+				if err := c.write(comma[:]); err != nil {
+					return err
+				}
+			}
+
+			c.enterKey(e.key)
+
+			if err := writeEntry(e.key, e.value, khf, vhf, cfg, c); err != nil {
+				return keyErr(err, e.key)
+			}
+
+			c.leave()
+		}
+
+		if truncated {
+			return twoErr(c.write(byteTruncated[:]), c.write(endList[:]))
+		}
+
+		return c.write(endList[:])
+	}
+}
+
+// orderCollisions orders the runs of sorted entries with equal key digests by the digests of
+// their key:value pairs, so that their order does not depend on the iteration order of the map.
+func (h *Hasher) orderCollisions(entries []mapEntry, khf, vhf hashFunc, cfg config, c *container) error {
+	for i := 0; i < len(entries); {
+		j := i + 1
+
+		for j < len(entries) && entries[j].sum == entries[i].sum {
+			j++
+		}
+
+		if j-i > 1 {
+			for k := i; k < j; k++ {
+				e := &entries[k]
+
+				sum, err := h.subHash(c, func(tmp *container) error {
+					return writeEntry(e.key, e.value, khf, vhf, cfg, tmp)
+				})
+				if err != nil {
+					return keyErr(err, e.key)
+				}
+
+				e.sum = sum
+			}
+
+			slices.SortFunc(entries[i:j], func(a, b mapEntry) int { return cmp.Compare(a.sum, b.sum) })
+		}
+
+		i = j
+	}
+
+	return nil
+}
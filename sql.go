@@ -0,0 +1,45 @@
+package datahash
+
+import (
+	"reflect"
+	"strings"
+)
+
+// hashSQLNull returns a hashFunc for the nullable types of database/sql, such as sql.NullString
+// and sql.Null[T], in FormatV2 and later, or nil.
+//
+// Invalid values hash like nil pointers, valid values like their payload, so sql.NullString{}
+// hashes like a missing value and sql.NullString{String: "a", Valid: true} like "a".
+func (h *Hasher) hashSQLNull(t reflect.Type, cfg config) hashFunc {
+	if h.opts.Format < FormatV2 || !isSQLNull(t) {
+		return nil
+	}
+
+	vhf, err := h.compile(t.Field(0).Type, cfg)
+	if err != nil {
+		return nil
+	}
+
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+			return nil
+		}
+
+		if !value.Field(1).Bool() {
+			if h.opts.NilMarker {
+				return c.write(byteNil[:])
+			}
+
+			return nil
+		}
+
+		return vhf(value.Field(0), c)
+	}
+}
+
+// isSQLNull reports whether t is a nullable type of database/sql, a struct of a payload and a Valid flag.
+func isSQLNull(t reflect.Type) bool {
+	return t.PkgPath() == "database/sql" && strings.HasPrefix(t.Name(), "Null") &&
+		t.Kind() == reflect.Struct && t.NumField() == 2 &&
+		t.Field(1).Name == "Valid" && t.Field(1).Type.Kind() == reflect.Bool
+}
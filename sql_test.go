@@ -0,0 +1,58 @@
+package datahash_test
+
+import (
+	"database/sql"
+	"hash/fnv"
+	"testing"
+	"time"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_SQLNull(t *testing.T) {
+	v1 := datahash.New(fnv.New64a, datahash.Options{})
+	v2 := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV2})
+
+	type row struct {
+		Name  sql.NullString
+		Count sql.Null[int]
+		At    sql.NullTime `datahash:"trunc=1h"`
+	}
+
+	type missing struct {
+		Name  *string
+		Count *int
+		At    *time.Time
+	}
+
+	name, count := "a", 3
+	at := time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	if v2.MustHash(row{}) != v2.MustHash(missing{}) {
+		t.Error("expected invalid values to hash like missing ones")
+	}
+
+	valid := row{
+		Name:  sql.NullString{String: name, Valid: true},
+		Count: sql.Null[int]{V: count, Valid: true},
+		At:    sql.NullTime{Time: at.Add(time.Minute), Valid: true},
+	}
+
+	if v2.MustHash(valid) != v2.MustHash(missing{Name: &name, Count: &count, At: &at}) {
+		t.Error("expected valid values to hash like their payload")
+	}
+
+	if v2.MustHash(row{Name: sql.NullString{String: "stale"}}) != v2.MustHash(row{}) {
+		t.Error("expected the payload of invalid values to be ignored")
+	}
+
+	if v1.MustHash(sql.NullString{}) == v1.MustHash(nil) {
+		t.Error("expected FormatV1 to keep hashing nullable types as structs")
+	}
+
+	nilMarker := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV2, NilMarker: true})
+
+	if nilMarker.MustHash(sql.NullInt64{}) != nilMarker.MustHash((*int64)(nil)) {
+		t.Error("expected invalid values to write the nil marker like nil pointers")
+	}
+}
@@ -0,0 +1,74 @@
+package datahash
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalizeString applies the string normalizations of h and cfg to s.
+func (h *Hasher) normalizeString(s string, cfg config) string {
+	if h.opts.NormalizeUnicode {
+		s = h.opts.UnicodeForm.String(s)
+	}
+
+	if cfg.foldCase {
+		s = foldCase(s)
+	}
+
+	if h.opts.StringTransform != nil {
+		s = h.opts.StringTransform(s)
+	}
+
+	return s
+}
+
+// unicodeFormName returns the name of the normalization form f, panicking for unknown forms.
+func unicodeFormName(f norm.Form) string {
+	switch f {
+	case norm.NFC:
+		return "NFC"
+	case norm.NFD:
+		return "NFD"
+	case norm.NFKC:
+		return "NFKC"
+	case norm.NFKD:
+		return "NFKD"
+	}
+
+	panic(fmt.Sprintf("datahash: unknown unicode normalization form %d", f))
+}
+
+// foldCase maps s to a canonical case, so that strings equal under strings.EqualFold, i.e.
+// Unicode simple case folding, fold to the same string. It does not allocate for ASCII strings
+// without upper case letters.
+func foldCase(s string) string {
+	return strings.Map(foldRune, s)
+}
+
+// foldRune returns the canonical rune of the case folding orbit of r: lower case for ASCII letters
+// and the smallest rune of the orbit otherwise, e.g. 'k' for the Kelvin sign.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'A' <= r && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+
+		return r
+	}
+
+	canonical := r
+
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		canonical = min(canonical, f)
+	}
+
+	if canonical < utf8.RuneSelf {
+		return foldRune(canonical)
+	}
+
+	return canonical
+}
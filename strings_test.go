@@ -0,0 +1,158 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"strings"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+	"golang.org/x/text/unicode/norm"
+)
+
+func TestHasher_FoldCase(t *testing.T) {
+	pairs := [][2]any{
+		{"Content-Type", "content-type"},
+		{"EXAMPLE.com", "example.COM"},
+		{"K", "k"}, // Kelvin sign
+		{"Straße", "STRAßE"},
+		{"ΣΊΣΥΦΟΣ", "σίσυφος"},
+		{map[string]string{"Accept": "TEXT/HTML"}, map[string]string{"accept": "text/html"}},
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	folded := datahash.New(fnv.New64a, datahash.Options{FoldCase: true})
+
+	for _, p := range pairs {
+		if a, b := plain.MustHash(p[0]), plain.MustHash(p[1]); a == b {
+			t.Errorf("expected different hashes for %q and %q without FoldCase", p[0], p[1])
+		}
+
+		if a, b := folded.MustHash(p[0]), folded.MustHash(p[1]); a != b {
+			t.Errorf("expected equal hashes for %q and %q", p[0], p[1])
+		}
+	}
+
+	if folded.MustHash("host") == folded.MustHash("hosts") {
+		t.Error("expected different strings to differ")
+	}
+
+	if folded.MustHash("lower case") != plain.MustHash("lower case") {
+		t.Error("expected lower case strings to be unchanged")
+	}
+}
+
+func TestHasher_FoldCaseTag(t *testing.T) {
+	type request struct {
+		Host string `datahash:"fold"`
+		Path string
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	if hasher.MustHash(request{Host: "Example.com", Path: "/"}) != hasher.MustHash(request{Host: "example.com", Path: "/"}) {
+		t.Error("expected the tagged field to be case-insensitive")
+	}
+
+	if hasher.MustHash(request{Path: "/A"}) == hasher.MustHash(request{Path: "/a"}) {
+		t.Error("expected untagged fields to stay case-sensitive")
+	}
+
+	canonical, err := hasher.Canonicalize(request{Host: "Example.com", Path: "/A"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := canonical.(map[string]any)["Host"]; got != "example.com" {
+		t.Errorf("expected the canonical host to be folded, got %q", got)
+	}
+}
+
+func TestHasher_NormalizeUnicode(t *testing.T) {
+	composed, decomposed := "caf\u00e9", "cafe\u0301"
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	nfc := datahash.New(fnv.New64a, datahash.Options{NormalizeUnicode: true})
+	nfkc := datahash.New(fnv.New64a, datahash.Options{NormalizeUnicode: true, UnicodeForm: norm.NFKC})
+
+	if plain.MustHash(composed) == plain.MustHash(decomposed) {
+		t.Error("expected compositions to differ without NormalizeUnicode")
+	}
+
+	if nfc.MustHash(composed) != nfc.MustHash(decomposed) {
+		t.Error("expected compositions to hash equally")
+	}
+
+	if nfc.MustHash(map[string]int{decomposed: 1}) != nfc.MustHash(map[string]int{composed: 1}) {
+		t.Error("expected map keys to be normalized")
+	}
+
+	if nfc.MustHash(composed) != plain.MustHash(composed) {
+		t.Error("expected NFC strings to be unchanged")
+	}
+
+	if nfc.MustHash("\ufb01le") == nfc.MustHash("file") {
+		t.Error("expected NFC to keep ligatures")
+	}
+
+	if nfkc.MustHash("\ufb01le") != nfkc.MustHash("file") {
+		t.Error("expected NFKC to fold ligatures")
+	}
+
+	folded := datahash.New(fnv.New64a, datahash.Options{NormalizeUnicode: true, FoldCase: true})
+
+	if folded.MustHash("CAFE\u0301") != folded.MustHash(composed) {
+		t.Error("expected normalization and case folding to combine")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for an unknown normalization form")
+		}
+	}()
+
+	datahash.New(fnv.New64a, datahash.Options{NormalizeUnicode: true, UnicodeForm: 9})
+}
+
+func TestHasher_StringTransform(t *testing.T) {
+	type contact struct {
+		Name  string `datahash:"fold"`
+		Email string
+		Tags  map[string]bool
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{StringTransform: strings.TrimSpace})
+
+	a := contact{Name: " Alice ", Email: "alice@example.com\n", Tags: map[string]bool{" admin": true}}
+	b := contact{Name: "ALICE", Email: "alice@example.com", Tags: map[string]bool{"admin": true}}
+
+	if hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected transformed strings to hash equally")
+	}
+
+	canonical, err := hasher.Canonicalize(a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := canonical.(map[string]any)["Email"]; got != "alice@example.com" {
+		t.Errorf("expected the canonical email to be transformed, got %q", got)
+	}
+
+	scrubbed := datahash.New(fnv.New64a, datahash.Options{
+		StringTransform: func(s string) string {
+			if strings.Contains(s, "@") {
+				return "<email>"
+			}
+
+			return s
+		},
+	})
+
+	if scrubbed.MustHash(a.Email) != scrubbed.MustHash("bob@example.com") {
+		t.Error("expected scrubbed strings to hash equally")
+	}
+
+	if scrubbed.MustHash("Alice") == scrubbed.MustHash("Bob") {
+		t.Error("expected other strings to differ")
+	}
+}
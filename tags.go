@@ -0,0 +1,207 @@
+package datahash
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// fieldTag holds the directives of a `datahash` struct tag, e.g. `datahash:"set"` or `datahash:"text"`.
+//
+// Supported directives:
+//   - set: hash the field as an unordered set (slices, arrays, structs, iter.Seq and iter.Seq2).
+//   - text, json, xml, string, binary: force encoding.TextMarshaler, json.Marshaler, xml.Marshaler,
+//     fmt.Stringer or encoding.BinaryMarshaler for the field. Compiling fails if its type does not implement it.
+//   - name=<name>: hash the field under a stable logical name instead of its Go name,
+//     so renaming the Go field does not change the hash.
+//   - omitzero: skip the field if it is zero, even without Options.IgnoreZero.
+//   - omitempty: skip the field if it is zero or an empty slice or map.
+//   - trunc=<duration>: truncate a time.Time or *time.Time field before hashing, e.g. trunc=1s.
+//   - fold: hash the strings of the field case-insensitively, like Options.FoldCase.
+//   - include: hash the field in Options.ExplicitOnly mode, which skips all other fields.
+//   - raw: write a string, byte slice or byte array field as its bytes, without framing, string
+//     normalization, nil markers or marshalers, e.g. for pre-serialized canonical blobs.
+//   - keys, values: hash a map as the set of its keys or the multiset of its values.
+//   - flatten: hash the fields of an embedded struct as fields of the parent, like Options.FlattenEmbedded.
+type fieldTag struct {
+	opts      FieldOptions
+	prefer    Strategy
+	name      string
+	omitZero  bool
+	omitEmpty bool
+	jsonEmpty bool // The omitempty option of a json tag in JSONTags mode.
+	truncate  time.Duration
+	foldCase  bool
+	include   bool
+	flatten   bool
+	raw       bool
+}
+
+func parseTag(tag string) (fieldTag, error) {
+	var ft fieldTag
+
+	if tag == "" || tag == "-" {
+		return ft, nil
+	}
+
+	for directive := range strings.SplitSeq(tag, ",") {
+		switch directive = strings.TrimSpace(directive); directive {
+		case "":
+		case "set":
+			ft.opts.Unordered = true
+		case "text":
+			ft.opts.Text, ft.prefer = true, StrategyText
+		case "json":
+			ft.opts.JSON, ft.prefer = true, StrategyJSON
+		case "xml":
+			ft.opts.XML, ft.prefer = true, StrategyXML
+		case "string":
+			ft.opts.String, ft.prefer = true, StrategyStringer
+		case "binary":
+			ft.prefer = StrategyBinary
+		case "omitzero":
+			ft.omitZero = true
+		case "omitempty":
+			ft.omitEmpty = true
+		case "fold":
+			ft.foldCase = true
+		case "include":
+			ft.include = true
+		case "flatten":
+			ft.flatten = true
+		case "raw":
+			ft.raw = true
+		case "keys":
+			ft.opts.Keys = true
+		case "values":
+			ft.opts.Values = true
+		default:
+			if name, ok := strings.CutPrefix(directive, "name="); ok && name != "" {
+				ft.name = name
+
+				continue
+			}
+
+			if d, ok := strings.CutPrefix(directive, "trunc="); ok {
+				truncate, err := time.ParseDuration(d)
+				if err != nil || truncate <= 0 {
+					return ft, fmt.Errorf("datahash: invalid truncation in struct tag directive %q", directive)
+				}
+
+				ft.truncate = truncate
+
+				continue
+			}
+
+			return ft, fmt.Errorf("datahash: unknown struct tag directive %q", directive)
+		}
+	}
+
+	return ft, nil
+}
+
+// omits reports whether the field value v is skipped by an omitzero or omitempty directive,
+// or by the omitempty option of a json tag.
+func (ft fieldTag) omits(v reflect.Value) bool {
+	switch {
+	case ft.jsonEmpty && jsonEmpty(v):
+		return true
+	case !ft.omitZero && !ft.omitEmpty:
+		return false
+	case isZero(v):
+		return true
+	case ft.omitEmpty && (v.Kind() == reflect.Slice || v.Kind() == reflect.Map):
+		return v.Len() == 0
+	}
+
+	return false
+}
+
+// jsonEmpty reports whether v is empty by the rules of encoding/json for omitempty: false, 0,
+// nil pointers and interfaces, and arrays, slices, maps and strings of length zero. Structs
+// are never empty.
+func jsonEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// withTag applies the directives of a struct tag to the config of the field type.
+func (cfg config) withTag(ft fieldTag) config {
+	cfg = cfg.with(ft.opts)
+
+	if ft.prefer != "" {
+		cfg.prefer = ft.prefer
+	}
+
+	if ft.truncate != 0 {
+		cfg.truncate = ft.truncate
+	}
+
+	cfg.foldCase = cfg.foldCase || ft.foldCase
+	cfg.raw = cfg.raw || ft.raw
+
+	return cfg
+}
+
+// allows reports whether a struct tag does not force another strategy than s.
+func (cfg config) allows(s Strategy) bool {
+	return cfg.prefer == "" || cfg.prefer == s
+}
+
+// preferredTypes are the interfaces forced by struct tag directives.
+var preferredTypes = map[Strategy]reflect.Type{
+	StrategyText:     textMarshalerType,
+	StrategyJSON:     jsonMarshalerType,
+	StrategyXML:      xmlMarshalerType,
+	StrategyStringer: stringerType,
+	StrategyBinary:   binaryMarshalerType,
+}
+
+// fieldTag parses the `datahash` struct tag of a field. In JSONTags mode, the name and the
+// omitempty and omitzero options of its `json` tag apply unless the `datahash` tag sets them,
+// with omitempty following the emptiness rules of encoding/json.
+func (h *Hasher) fieldTag(sf reflect.StructField) (fieldTag, error) {
+	tag, err := parseTag(sf.Tag.Get("datahash"))
+	if err != nil || !h.opts.JSONTags {
+		return tag, err
+	}
+
+	name, opts, _ := strings.Cut(sf.Tag.Get("json"), ",")
+
+	if tag.name == "" {
+		tag.name = name
+	}
+
+	for opt := range strings.SplitSeq(opts, ",") {
+		switch opt {
+		case "omitempty":
+			tag.jsonEmpty = !tag.omitEmpty
+		case "omitzero":
+			tag.omitZero = true
+		}
+	}
+
+	return tag, nil
+}
+
+// fieldIncluded reports whether a struct field is tagged with the include directive.
+func fieldIncluded(sf reflect.StructField) bool {
+	tag, _ := parseTag(sf.Tag.Get("datahash"))
+
+	return tag.include
+}
@@ -0,0 +1,382 @@
+package datahash_test
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type label string
+
+func (l label) String() string { return strings.ToLower(string(l)) }
+
+func (l label) MarshalBinary() ([]byte, error) { return []byte(l), nil }
+
+func TestStructTags(t *testing.T) {
+	type item struct {
+		Ordered []string
+		Tags    []string   `datahash:"set"`
+		Price   *big.Float `datahash:"text"`
+		Label   label      `datahash:"string"`
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	hash := func(v any) uint64 {
+		t.Helper()
+
+		h, err := hasher.Hash(v)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return h
+	}
+
+	base := item{Ordered: []string{"a", "b"}, Tags: []string{"x", "y"}, Price: big.NewFloat(1.5), Label: "Red"}
+
+	if got, want := hash(item{Ordered: []string{"a", "b"}, Tags: []string{"y", "x"}, Price: big.NewFloat(1.5), Label: "RED"}), hash(base); got != want {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", got, want)
+	}
+
+	if hash(item{Ordered: []string{"b", "a"}, Tags: []string{"x", "y"}, Price: big.NewFloat(1.5), Label: "Red"}) == hash(base) {
+		t.Error("expected untagged slice to stay ordered")
+	}
+
+	// Without the tag, label is hashed via BinaryMarshaler and case matters.
+	if hash(label("Red")) == hash(label("RED")) {
+		t.Error("expected BinaryMarshaler without tag")
+	}
+
+	type invalid struct {
+		Count int `datahash:"text"`
+	}
+
+	if _, err := hasher.Hash(invalid{}); err == nil {
+		t.Error("expected error for forced marshaler not implemented")
+	}
+
+	type unknown struct {
+		Count int `datahash:"sorted"`
+	}
+
+	if _, err := hasher.Hash(unknown{}); err == nil || !strings.Contains(err.Error(), "sorted") {
+		t.Errorf("expected error for unknown directive, got %v", err)
+	}
+}
+
+func TestStructTags_Name(t *testing.T) {
+	type before struct {
+		UserID int `datahash:"name=user_id"`
+		Email  string
+	}
+
+	type after struct {
+		AccountID int `datahash:"name=user_id"`
+		Email     string
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	a, err := hasher.Hash(before{UserID: 7, Email: "a@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := hasher.Hash(after{AccountID: 7, Email: "a@example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a != b {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", b, a)
+	}
+
+	type duplicate struct {
+		A int `datahash:"name=B"`
+		B int
+	}
+
+	if _, err := hasher.Hash(duplicate{}); err == nil {
+		t.Error("expected error for duplicate field name")
+	}
+}
+
+func TestStructTags_Omit(t *testing.T) {
+	type v1 struct {
+		Name string
+	}
+
+	type v2 struct {
+		Name     string
+		Nickname string   `datahash:"omitzero"`
+		Aliases  []string `datahash:"omitempty"`
+		Count    int
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	a, _ := hasher.Hash(v1{Name: "x"})
+	b, _ := hasher.Hash(v2{Name: "x", Aliases: []string{}})
+	c, _ := hasher.Hash(v2{Name: "x", Nickname: "y"})
+
+	// Count has no directive, so it is hashed even when zero.
+	if a == b {
+		t.Error("expected untagged zero field to be hashed")
+	}
+
+	if b == c {
+		t.Error("expected non-zero field to be hashed")
+	}
+
+	type v3 struct {
+		Name     string
+		Nickname string   `datahash:"omitzero"`
+		Aliases  []string `datahash:"omitempty"`
+	}
+
+	d, _ := hasher.Hash(v3{Name: "x", Aliases: []string{}})
+
+	if d != a {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", d, a)
+	}
+}
+
+func TestStructTags_Include(t *testing.T) {
+	type identity struct {
+		ID   int
+		Name string
+	}
+
+	type account struct {
+		ID      int    `datahash:"include"`
+		Name    string `datahash:"include,name=Name"`
+		Balance int
+		Notes   string
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{ExplicitOnly: true})
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	a := account{ID: 1, Name: "x", Balance: 10}
+	b := account{ID: 1, Name: "x", Balance: 20, Notes: "new"}
+
+	if hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected untagged fields to be skipped in ExplicitOnly mode")
+	}
+
+	if hasher.MustHash(a) == hasher.MustHash(account{ID: 2, Name: "x"}) {
+		t.Error("expected included fields to be hashed")
+	}
+
+	if hasher.MustHash(a) != plain.MustHash(identity{ID: 1, Name: "x"}) {
+		t.Error("expected only the included fields to be hashed")
+	}
+
+	if plain.MustHash(a) == plain.MustHash(b) {
+		t.Error("expected the include directive to have no effect without ExplicitOnly")
+	}
+}
+
+func TestStructTags_JSON(t *testing.T) {
+	type v1 struct {
+		UserID int      `json:"user_id"`
+		Email  string   `json:"email,omitempty"`
+		Tags   []string `json:",omitempty"`
+		Secret string   `json:"-"`
+	}
+
+	type v2 struct {
+		ID       int      `json:"user_id"`
+		Mail     string   `json:"email,omitempty"`
+		Tags     []string `json:",omitempty"`
+		Password string   `json:"-"`
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{JSONTags: true})
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	a := v1{UserID: 1, Tags: []string{}, Secret: "a"}
+	b := v2{ID: 1, Password: "b"}
+
+	if hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected fields to hash by their json names and options")
+	}
+
+	if plain.MustHash(a) == plain.MustHash(b) {
+		t.Error("expected json tags to be ignored without JSONTags")
+	}
+
+	if hasher.MustHash(a) == hasher.MustHash(v1{UserID: 1, Email: "x"}) {
+		t.Error("expected non-empty json fields to be hashed")
+	}
+
+	type renamed struct {
+		UserID int `json:"user_id" datahash:"name=id"`
+	}
+
+	type named struct {
+		ID int `datahash:"name=id"`
+	}
+
+	if hasher.MustHash(renamed{1}) != hasher.MustHash(named{1}) {
+		t.Error("expected datahash names to take precedence over json names")
+	}
+
+	canonical, err := hasher.Canonicalize(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m := canonical.(map[string]any); len(m) != 1 || m["user_id"] != int64(1) {
+		t.Errorf("expected the canonical form to use json names, got %#v", m)
+	}
+}
+
+func TestStructTags_JSONOmitEmpty(t *testing.T) {
+	type inner struct {
+		A int
+	}
+
+	type fields struct {
+		Struct  inner          `json:"struct,omitempty"`
+		Array   [2]int         `json:"array,omitempty"`
+		Empty   [0]int         `json:"empty,omitempty"`
+		Bool    bool           `json:"bool,omitempty"`
+		Int     int            `json:"int,omitempty"`
+		Uint    uint           `json:"uint,omitempty"`
+		Float   float64        `json:"float,omitempty"`
+		String  string         `json:"string,omitempty"`
+		Pointer *int           `json:"pointer,omitempty"`
+		Any     any            `json:"any,omitempty"`
+		Slice   []int          `json:"slice,omitempty"`
+		Map     map[string]int `json:"map,omitempty"`
+	}
+
+	one := 1
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{JSONTags: true})
+
+	for _, value := range []fields{
+		{},
+		{Slice: []int{}, Map: map[string]int{}, Any: 0},
+		{Struct: inner{1}, Array: [2]int{1}, Bool: true, Int: 1, Uint: 1, Float: 1, String: "x", Pointer: &one, Slice: []int{1}, Map: map[string]int{"a": 1}},
+		{Pointer: new(int), Any: (*int)(nil)},
+	} {
+		b, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var want map[string]any
+
+		if err := json.Unmarshal(b, &want); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := hasher.Canonicalize(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := got.(map[string]any)
+
+		for name := range want {
+			if _, ok := m[name]; !ok {
+				t.Errorf("%+v: expected field %s as in encoding/json", value, name)
+			}
+		}
+
+		for name := range m {
+			if _, ok := want[name]; !ok {
+				t.Errorf("%+v: expected field %s to be omitted as in encoding/json", value, name)
+			}
+		}
+	}
+}
+
+func TestStructTags_KeysValues(t *testing.T) {
+	type members struct {
+		Roles  map[string]int `datahash:"keys"`
+		Scores map[string]int `datahash:"values"`
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	a := members{Roles: map[string]int{"admin": 1, "dev": 2}, Scores: map[string]int{"x": 1, "y": 2}}
+	b := members{Roles: map[string]int{"admin": 3, "dev": 4}, Scores: map[string]int{"u": 2, "v": 1}}
+
+	if hasher.MustHash(a) != hasher.MustHash(b) {
+		t.Error("expected maps to hash only their keys or values")
+	}
+
+	if hasher.MustHash(a) == hasher.MustHash(members{Roles: map[string]int{"admin": 1}, Scores: a.Scores}) {
+		t.Error("expected the key set to be hashed")
+	}
+
+	if hasher.MustHash(a) == hasher.MustHash(members{Roles: a.Roles, Scores: map[string]int{"x": 1, "y": 3}}) {
+		t.Error("expected the values to be hashed")
+	}
+
+	type set struct {
+		Roles []string `datahash:"set"`
+	}
+
+	if hasher.MustHash(struct {
+		Roles map[string]int `datahash:"keys"`
+	}{a.Roles}) != hasher.MustHash(set{[]string{"dev", "admin"}}) {
+		t.Error("expected the keys of a map to hash like an unordered set")
+	}
+
+	sorted := datahash.New(fnv.New64a, datahash.Options{SortedMaps: true})
+
+	if sorted.MustHash(a) != sorted.MustHash(b) {
+		t.Error("expected sorted maps to hash only their keys or values")
+	}
+
+	canonical, err := hasher.Canonicalize(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if roles, ok := canonical.(map[string]any)["Roles"].([]any); !ok || len(roles) != 2 {
+		t.Errorf("expected the keys in the canonical form, got %#v", canonical)
+	}
+}
+
+func TestStructTags_Raw(t *testing.T) {
+	type blob struct {
+		Body  string `datahash:"raw"`
+		Data  []byte `datahash:"raw"`
+		Label label  `datahash:"raw"`
+	}
+
+	type plain struct {
+		Body  string
+		Data  []byte
+		Label string
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{FoldCase: true, DistinguishNil: true, String: true})
+	base := datahash.New(fnv.New64a, datahash.Options{})
+
+	if hasher.MustHash(blob{"Abc", []byte{1}, "Red"}) != base.MustHash(plain{"Abc", []byte{1}, "Red"}) {
+		t.Error("expected raw fields to be written as is")
+	}
+
+	if hasher.MustHash(blob{"Abc", nil, "Red"}) != hasher.MustHash(blob{"Abc", []byte{}, "Red"}) {
+		t.Error("expected raw byte slices to hash without nil markers")
+	}
+
+	type invalid struct {
+		Count int `datahash:"raw"`
+	}
+
+	if _, err := hasher.Hash(invalid{}); err == nil || !strings.Contains(err.Error(), "raw") {
+		t.Errorf("expected an error for raw fields of other types, got %v", err)
+	}
+}
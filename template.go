@@ -0,0 +1,143 @@
+package datahash
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"reflect"
+	texttemplate "text/template"
+	"text/template/parse"
+)
+
+var (
+	textTemplateType = reflect.TypeFor[texttemplate.Template]()
+	htmlTemplateType = reflect.TypeFor[htmltemplate.Template]()
+	parseTreeType    = reflect.TypeFor[parse.Tree]()
+)
+
+// namedTree is a template definition: its name and parse tree.
+type namedTree struct {
+	name string
+	tree *parse.Tree
+}
+
+// hashTemplate returns a hashFunc for text/template, html/template and parse.Tree values, or nil.
+//
+// Templates are hashed by their name and the set of associated template definitions,
+// each reduced to its name and the canonical text of its parse tree. Note that
+// html/template rewrites its trees with escaping directives on first execution.
+func (h *Hasher) hashTemplate(t reflect.Type, cfg config) hashFunc {
+	switch t {
+	case textTemplateType:
+		return func(value reflect.Value, c *container) error {
+			tmpl, err := addrOf[texttemplate.Template](value)
+			if err != nil || tmpl == nil || (cfg.ignoreZero && isZero(value)) {
+				return err
+			}
+
+			var trees []namedTree
+
+			for _, t := range tmpl.Templates() {
+				trees = append(trees, namedTree{name: t.Name(), tree: t.Tree})
+			}
+
+			return h.writeTemplate(c, tmpl.Name(), trees)
+		}
+	case htmlTemplateType:
+		return func(value reflect.Value, c *container) error {
+			tmpl, err := addrOf[htmltemplate.Template](value)
+			if err != nil || tmpl == nil || (cfg.ignoreZero && isZero(value)) {
+				return err
+			}
+
+			var trees []namedTree
+
+			for _, t := range tmpl.Templates() {
+				trees = append(trees, namedTree{name: t.Name(), tree: t.Tree})
+			}
+
+			return h.writeTemplate(c, tmpl.Name(), trees)
+		}
+	case parseTreeType:
+		return func(value reflect.Value, c *container) error {
+			tree, err := addrOf[parse.Tree](value)
+			if err != nil || tree == nil || (cfg.ignoreZero && isZero(value)) {
+				return err
+			}
+
+			return h.writeTemplate(c, tree.Name, []namedTree{{name: tree.Name, tree: tree}})
+		}
+	}
+
+	return nil
+}
+
+// writeTemplate writes the name followed by the unordered set of template definitions.
+func (h *Hasher) writeTemplate(c *container, name string, trees []namedTree) error {
+	if err := threeErr(
+		c.write(startList[:]),
+		c.write(stringToBytes(name)),
+		c.write(comma[:]),
+	); err != nil {
+		return err
+	}
+
+	var (
+		fold = h.folder()
+		tmp  = h.sub(c)
+	)
+
+	for _, nt := range trees {
+		if nt.tree == nil || nt.tree.Root == nil {
+			continue
+		}
+
+		tmp.Reset()
+
+		if err := threeErr(
+			tmp.write(stringToBytes(nt.name)),
+			tmp.write(colon[:]),
+			tmp.write(stringToBytes(nt.tree.Root.String())),
+		); err != nil {
+			h.containerPool.Put(tmp)
+
+			return err
+		}
+
+		fold.addHash(tmp.hash)
+	}
+
+	h.containerPool.Put(tmp)
+
+	if err := c.write(startSet[:]); err != nil {
+		return err
+	}
+
+	if err := fold.write(c); err != nil {
+		return err
+	}
+
+	return twoErr(
+		c.write(endSet[:]),
+		c.write(endList[:]),
+	)
+}
+
+// addrOf returns a pointer to the struct held by value, copying it if value is not addressable.
+func addrOf[T any](value reflect.Value) (*T, error) {
+	if !value.IsValid() {
+		return nil, nil
+	}
+
+	if !value.CanInterface() {
+		return nil, fmt.Errorf("datahash: cannot hash %s in unexported fields that are not accessible via reflection", value.Type())
+	}
+
+	if value.CanAddr() {
+		return value.Addr().Interface().(*T), nil
+	}
+
+	ptr := reflect.New(value.Type())
+	ptr.Elem().Set(value)
+
+	return ptr.Interface().(*T), nil
+}
@@ -0,0 +1,54 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	htmltemplate "html/template"
+	"testing"
+	texttemplate "text/template"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_Template(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	type key struct {
+		Template *texttemplate.Template
+		Data     map[string]any
+	}
+
+	hash := func(src string) uint64 {
+		t.Helper()
+
+		tmpl := texttemplate.Must(texttemplate.New("query").Parse(src))
+
+		got, err := hasher.Hash(key{Template: tmpl, Data: map[string]any{"id": 1}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return got
+	}
+
+	if hash(`SELECT {{ .id }}`) != hash(`SELECT {{ .id }}`) {
+		t.Error("expected equal hashes for equal templates")
+	}
+
+	if hash(`SELECT {{ .id }}`) == hash(`DELETE {{ .id }}`) {
+		t.Error("expected different hashes for different templates")
+	}
+
+	a, err := hasher.Hash(htmltemplate.Must(htmltemplate.New("page").Parse(`<p>{{ . }}</p>`)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := hasher.Hash(htmltemplate.Must(htmltemplate.New("page").Parse(`<p>{{ . }}</p>`)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if a != b {
+		t.Error("expected equal hashes for equal html templates")
+	}
+}
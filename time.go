@@ -0,0 +1,89 @@
+package datahash
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	timeType    = reflect.TypeFor[time.Time]()
+	timePtrType = reflect.TypeFor[*time.Time]()
+)
+
+// hashTime returns a hashFunc for time.Time values in NormalizeTime mode or with truncation, or nil.
+//
+// Normalized instants are hashed as Unix seconds and nanoseconds in UTC, so monotonic clock readings
+// and locations do not affect the hash, followed by the location name if TimeLocation is set.
+// Otherwise truncated values are hashed by MarshalBinary. Marshalers forced by struct tags take precedence.
+func (h *Hasher) hashTime(t reflect.Type, cfg config) hashFunc {
+	if !h.opts.NormalizeTime && cfg.truncate == 0 || cfg.prefer != "" {
+		return nil
+	}
+
+	switch t {
+	case timeType:
+	case timePtrType:
+		// *time.Time implements encoding.BinaryMarshaler itself, so it is handled here
+		// instead of like other pointers.
+		ehf := h.hashTime(timeType, cfg)
+
+		return func(value reflect.Value, c *container) error {
+			switch {
+			case !value.IsValid():
+				return nil
+			case !value.IsNil():
+				return ehf(value.Elem(), c)
+			case cfg.zeroNil:
+				return ehf(reflect.Zero(timeType), c)
+			case h.opts.NilMarker:
+				return c.write(byteNil[:])
+			}
+
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+			return nil
+		}
+
+		if !value.CanInterface() {
+			return fmt.Errorf("datahash: cannot hash %s in unexported fields that are not accessible via reflection", value.Type())
+		}
+
+		tm := value.Interface().(time.Time)
+
+		if cfg.truncate > 0 {
+			tm = tm.Truncate(cfg.truncate)
+		}
+
+		if !h.opts.NormalizeTime {
+			b, err := c.keepScratch(tm.AppendBinary(c.scratch[:0]))
+			if err != nil {
+				return marshalerErr(timeType, StrategyBinary, err)
+			}
+
+			return c.write(b)
+		}
+
+		if err := twoErr(
+			c.writeUint64(uint64(tm.Unix())), //nolint:gosec
+			c.writeUint64(uint64(tm.Nanosecond())),
+		); err != nil {
+			return err
+		}
+
+		if !h.opts.TimeLocation {
+			return nil
+		}
+
+		return twoErr(
+			c.write(colon[:]),
+			c.write(stringToBytes(tm.Location().String())),
+		)
+	}
+}
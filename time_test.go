@@ -0,0 +1,103 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"testing"
+	"time"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_NormalizeTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+
+	utc := time.Date(2024, 5, 6, 7, 8, 9, 10, time.UTC)
+	local := utc.In(berlin)
+	monotonic := time.Now()
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	normalized := datahash.New(fnv.New64a, datahash.Options{NormalizeTime: true})
+	located := datahash.New(fnv.New64a, datahash.Options{NormalizeTime: true, TimeLocation: true})
+
+	if plain.MustHash(utc) == plain.MustHash(local) {
+		t.Error("expected locations to differ without NormalizeTime")
+	}
+
+	if normalized.MustHash(utc) != normalized.MustHash(local) {
+		t.Error("expected equal instants to hash equally")
+	}
+
+	if normalized.MustHash(monotonic) != normalized.MustHash(monotonic.Round(0)) {
+		t.Error("expected the monotonic clock reading to be ignored")
+	}
+
+	if normalized.MustHash(utc) == normalized.MustHash(utc.Add(time.Nanosecond)) {
+		t.Error("expected different instants to differ")
+	}
+
+	if located.MustHash(utc) == located.MustHash(local) {
+		t.Error("expected locations to differ with TimeLocation")
+	}
+
+	type event struct {
+		At *time.Time
+	}
+
+	if normalized.MustHash(event{At: &utc}) != normalized.MustHash(event{At: &local}) {
+		t.Error("expected normalization of nested times")
+	}
+
+	tagged := struct {
+		At time.Time `datahash:"text"`
+	}{utc}
+
+	if normalized.MustHash(tagged) != plain.MustHash(tagged) {
+		t.Error("expected struct tags to take precedence")
+	}
+}
+
+func TestHasher_TruncateTime(t *testing.T) {
+	base := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	noisy := base.Add(123 * time.Millisecond)
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+	truncated := datahash.New(fnv.New64a, datahash.Options{TruncateTime: time.Second})
+	normalized := datahash.New(fnv.New64a, datahash.Options{TruncateTime: time.Second, NormalizeTime: true})
+
+	if plain.MustHash(base) == plain.MustHash(noisy) {
+		t.Error("expected sub-second differences without truncation")
+	}
+
+	if truncated.MustHash(base) != truncated.MustHash(noisy) || truncated.MustHash(base) != plain.MustHash(base) {
+		t.Error("expected truncated times to hash like the truncated value")
+	}
+
+	if normalized.MustHash(&base) != normalized.MustHash(&noisy) {
+		t.Error("expected truncation with NormalizeTime")
+	}
+
+	type event struct {
+		At      time.Time  `datahash:"trunc=1m"`
+		Updated *time.Time `datahash:"trunc=1h"`
+		Exact   time.Time
+	}
+
+	later := base.Add(30 * time.Second)
+
+	if plain.MustHash(event{At: base, Updated: &base}) != plain.MustHash(event{At: later, Updated: &later}) {
+		t.Error("expected tagged fields to be truncated")
+	}
+
+	if plain.MustHash(event{Exact: base}) == plain.MustHash(event{Exact: later}) {
+		t.Error("expected untagged fields to be exact")
+	}
+
+	if _, err := plain.Hash(struct {
+		At time.Time `datahash:"trunc=soon"`
+	}{}); err == nil {
+		t.Error("expected error for invalid truncation")
+	}
+}
@@ -0,0 +1,99 @@
+package datahash
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Trace is the canonical byte stream of a value, split by the paths that contributed the bytes.
+type Trace []TraceEntry
+
+// TraceEntry holds consecutive bytes of the canonical stream written at Path.
+//
+// Unordered collections contribute their folded sub-hashes, not the streams of their elements.
+type TraceEntry struct {
+	Path  string
+	Bytes []byte
+}
+
+// Explain hashes value like Hash, but records which bytes each field, element and map entry
+// contributed to the hashed stream, in order. Use it to understand why two values hash
+// differently or identically, or as golden data for the canonical format.
+func (h *Hasher) Explain(value any) (Trace, error) {
+	if err := h.checkStream("Explain"); err != nil {
+		return nil, err
+	}
+
+	c := h.acquire()
+	c.own.reset(true)
+
+	rec := &traceHash{st: c.st}
+
+	hash := c.hash
+	c.hash = rec
+
+	if h.header != nil {
+		_, _ = rec.Write(h.header)
+	}
+
+	err := h.hash(value, c)
+
+	c.hash = hash
+
+	h.containerPool.Put(c)
+
+	return rec.trace, err
+}
+
+// Bytes returns the complete canonical stream.
+func (t Trace) Bytes() []byte {
+	var b []byte
+
+	for _, e := range t {
+		b = append(b, e.Bytes...)
+	}
+
+	return b
+}
+
+// String formats the trace with one line per entry: the path, or "." for the top-level value, and the bytes in hex.
+func (t Trace) String() string {
+	var b strings.Builder
+
+	for _, e := range t {
+		path := e.Path
+
+		if path == "" {
+			path = "."
+		}
+
+		fmt.Fprintf(&b, "%s: %x\n", path, e.Bytes)
+	}
+
+	return b.String()
+}
+
+// traceHash is a hash.Hash64 that records the written bytes together with the current path.
+type traceHash struct {
+	captureHash
+
+	st    *state
+	trace Trace
+}
+
+func (r *traceHash) Write(p []byte) (int, error) {
+	path := r.st.String()
+
+	if n := len(r.trace); n > 0 && r.trace[n-1].Path == path {
+		r.trace[n-1].Bytes = append(r.trace[n-1].Bytes, p...)
+	} else {
+		r.trace = append(r.trace, TraceEntry{Path: path, Bytes: append([]byte(nil), p...)})
+	}
+
+	return r.captureHash.Write(p)
+}
+
+// WriteString shadows the promoted bytes.Buffer method so that strings are recorded as well.
+func (r *traceHash) WriteString(s string) (int, error) {
+	return r.Write([]byte(s))
+}
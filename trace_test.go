@@ -0,0 +1,54 @@
+package datahash_test
+
+import (
+	"bytes"
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestHasher_Explain(t *testing.T) {
+	type item struct {
+		Name string
+		Tags []string
+	}
+
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	trace, err := hasher.Explain(item{Name: "a", Tags: []string{"x", "y"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := `.: 06
+Name: 4e616d650261
+.: 03
+Tags: 546167730206
+Tags[0]: 78
+Tags: 03
+Tags[1]: 79
+Tags: 07
+.: 07
+`
+
+	if got := trace.String(); got != want {
+		t.Errorf("trace mismatch:\n  got:\n%s\n  want:\n%s", got, want)
+	}
+
+	// The trace is the stream that is hashed.
+	h := fnv.New64a()
+	_, _ = h.Write(trace.Bytes())
+
+	sum, _ := hasher.Hash(item{Name: "a", Tags: []string{"x", "y"}})
+
+	if h.Sum64() != sum {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", h.Sum64(), sum)
+	}
+
+	other, _ := hasher.Explain(item{Name: "b", Tags: []string{"x", "y"}})
+
+	if bytes.Equal(other.Bytes(), trace.Bytes()) {
+		t.Error("expected different streams")
+	}
+}
@@ -0,0 +1,100 @@
+package datahash
+
+import (
+	"hash"
+	"reflect"
+	"sync"
+)
+
+// FuncFor returns the compiled hash function of h for the type T.
+//
+// The returned function produces the same results as h.Hash, but skips the
+// per-call type lookup, so frameworks can embed it in hot paths, e.g. as a map key deriver.
+// It returns an error if T is not supported.
+func FuncFor[T any](h *Hasher) (func(T) (uint64, error), error) {
+	if h.opts.Format >= formatCompat {
+		return func(value T) (uint64, error) { return h.Hash(value) }, nil
+	}
+
+	t := reflect.TypeFor[T]()
+
+	hf, err := h.makeHashFunc(t, h.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values are copied into pooled pointers, so they are not boxed in any. Only values that
+	// contain types hashed via their pointer type if addressable are boxed, since they must
+	// not be addressable, as in Hash.
+	var (
+		boxed = t.Kind() != reflect.Interface && !h.opts.AutoAddress && inlinesViaPointer(h.typeInfo(t, h.cfg))
+		pool  = sync.Pool{New: func() any { return new(T) }}
+	)
+
+	return func(value T) (uint64, error) {
+		c := h.acquire()
+
+		var (
+			p   = pool.Get().(*T)
+			v   = reflect.ValueOf(p).Elem()
+			err error
+		)
+
+		*p = value
+
+		if boxed {
+			v = reflect.ValueOf(value)
+		}
+
+		// Interface hash functions write the identity of the dynamic type themselves.
+		if t.Kind() != reflect.Interface {
+			err = h.writeType(t, c)
+		}
+
+		err = twoErr(err, hf(v, c))
+
+		result := c.hash.Sum64()
+
+		h.containerPool.Put(c)
+
+		var zero T
+
+		*p = zero
+		pool.Put(p)
+
+		return result, err
+	}, nil
+}
+
+// TypedHasher hashes values of a single type T with a precompiled hash function.
+type TypedHasher[T any] struct {
+	hasher *Hasher
+	hash   func(T) (uint64, error)
+	err    error
+}
+
+// For creates a TypedHasher for T, e.g. datahash.For[User](xxhash.New, opts).
+//
+// The hash function of T is compiled once, so Hash avoids boxing values in any and the
+// per-call type lookup. If T is not supported, Hash returns the compile error.
+func For[T any, H hash.Hash64](init func() H, opts Options) *TypedHasher[T] {
+	h := New(init, opts)
+
+	hash, err := FuncFor[T](h)
+
+	return &TypedHasher[T]{hasher: h, hash: hash, err: err}
+}
+
+// Hash computes the 64-bit hash of value, like Hasher.Hash.
+func (t *TypedHasher[T]) Hash(value T) (uint64, error) {
+	if t.err != nil {
+		return 0, t.err
+	}
+
+	return t.hash(value)
+}
+
+// Hasher returns the underlying Hasher.
+func (t *TypedHasher[T]) Hasher() *Hasher {
+	return t.hasher
+}
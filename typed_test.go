@@ -0,0 +1,128 @@
+package datahash_test
+
+import (
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+func TestFuncFor(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+
+	type key struct {
+		Name string
+		Tags []string
+	}
+
+	hash, err := datahash.FuncFor[key](hasher)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	value := key{Name: "a", Tags: []string{"x", "y"}}
+
+	got, err := hash(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, err := hasher.Hash(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != want {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", got, want)
+	}
+
+	hashAny, err := datahash.FuncFor[any](hasher)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got, _ := hashAny(value); got != want {
+		t.Errorf("hash mismatch for interface type:\n  got:  %d\n  want: %d", got, want)
+	}
+
+	if _, err := datahash.FuncFor[func()](hasher); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
+
+func TestFuncFor_Allocs(t *testing.T) {
+	type key struct {
+		Name  string
+		ID    int
+		Score float64
+	}
+
+	for _, opts := range []datahash.Options{{}, {Format: datahash.FormatV2}} {
+		hash, err := datahash.FuncFor[key](datahash.New(fnv.New64a, opts))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		value := key{Name: "a", ID: 1, Score: 2}
+
+		if allocs := testing.AllocsPerRun(100, func() { _, _ = hash(value) }); allocs != 0 {
+			t.Errorf("expected no allocations with %+v, got %v", opts, allocs)
+		}
+	}
+}
+
+type addrText struct {
+	Name string
+}
+
+func (a *addrText) MarshalText() ([]byte, error) {
+	return []byte("text:" + a.Name), nil
+}
+
+func TestFuncFor_Addressable(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV2, Text: true})
+
+	type holder struct {
+		A addrText
+	}
+
+	hash, err := datahash.FuncFor[holder](hasher)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, value := range []holder{{A: addrText{Name: "a"}}, {A: addrText{Name: "b"}}} {
+		got, err := hash(value)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if want := hasher.MustHash(value); got != want {
+			t.Errorf("hash mismatch for %v:\n  got:  %d\n  want: %d", value, got, want)
+		}
+	}
+}
+
+func TestFor(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+
+	typed := datahash.For[user](fnv.New64a, datahash.Options{})
+
+	got, err := typed.Hash(user{Name: "alice", Age: 30})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want, _ := typed.Hasher().Hash(user{Name: "alice", Age: 30})
+
+	if got != want {
+		t.Errorf("hash mismatch:\n  got:  %d\n  want: %d", got, want)
+	}
+
+	if _, err := datahash.For[func()](fnv.New64a, datahash.Options{}).Hash(nil); err == nil {
+		t.Error("expected error for unsupported type")
+	}
+}
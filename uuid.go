@@ -0,0 +1,64 @@
+package datahash
+
+import "reflect"
+
+// hashUUID returns a hashFunc for UUID types in FormatV2 and later, or nil.
+// Marshalers forced by struct tags take precedence.
+//
+// A UUID type is a [16]byte array type named UUID, like uuid.UUID of github.com/google/uuid and
+// github.com/gofrs/uuid. It is hashed as its 16 raw bytes instead of by its TextMarshaler,
+// so the hash matches other systems hashing the raw bytes of UUIDs.
+func (h *Hasher) hashUUID(t reflect.Type, cfg config) hashFunc {
+	if h.opts.Format < FormatV2 || cfg.prefer != "" || !isUUID(t) {
+		return nil
+	}
+
+	return h.hashByteArray(cfg)
+}
+
+// isUUID reports whether t is a [16]byte array type named UUID.
+func isUUID(t reflect.Type) bool {
+	return t.Name() == "UUID" && t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8
+}
+
+// rawArray reports whether the array type t of bytes is hashed as raw bytes, which FormatV2
+// and later do unless it is an unordered set or its elements are not hashed by their kind.
+func (h *Hasher) rawArray(t reflect.Type, cfg config) bool {
+	if h.opts.Format < FormatV2 || cfg.unorderedArray || t.Kind() != reflect.Array || t.Elem().Kind() != reflect.Uint8 {
+		return false
+	}
+
+	elem := h.typeInfo(t.Elem(), h.cfg)
+
+	return elem != nil && elem.Strategy == StrategyKind
+}
+
+// hashByteArray returns a hashFunc writing a byte array as raw bytes in a single write,
+// instead of as a comma-separated list of its elements.
+func (h *Hasher) hashByteArray(cfg config) hashFunc {
+	return func(value reflect.Value, c *container) error {
+		if !value.IsValid() || (cfg.ignoreZero && isZero(value)) {
+			return nil
+		}
+
+		if value.CanAddr() {
+			return c.write(value.Bytes())
+		}
+
+		var buf [64]byte
+
+		for off := 0; off < value.Len(); off += len(buf) {
+			n := min(len(buf), value.Len()-off)
+
+			for i := range n {
+				buf[i] = byte(value.Index(off + i).Uint())
+			}
+
+			if err := c.write(buf[:n]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package datahash_test
+
+import (
+	"encoding/hex"
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type UUID [16]byte
+
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(u[:])), nil
+}
+
+func TestHasher_UUID(t *testing.T) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV2})
+
+	id := UUID{0x6b, 0xa7, 0xb8, 0x10, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+	raw := fnv.New64a()
+	_, _ = raw.Write(id[:])
+
+	if hasher.MustHash(id) != raw.Sum64() {
+		t.Error("expected a UUID to hash as its raw bytes")
+	}
+
+	if hasher.MustHash(id) != hasher.MustHash([16]byte(id)) || hasher.MustHash(&id) != hasher.MustHash(id) {
+		t.Error("expected UUIDs and byte arrays to hash equally")
+	}
+
+	if hasher.MustHash(id) != hasher.MustHash(id[:]) {
+		t.Error("expected byte arrays to hash like byte slices")
+	}
+
+	other := id
+	other[15]++
+
+	if hasher.MustHash(id) == hasher.MustHash(other) {
+		t.Error("expected different UUIDs to hash differently")
+	}
+
+	plain := datahash.New(fnv.New64a, datahash.Options{})
+
+	if plain.MustHash(id) == raw.Sum64() || plain.MustHash([16]byte(id)) == raw.Sum64() {
+		t.Error("expected FormatV1 to keep hashing UUIDs by MarshalText and byte arrays as lists")
+	}
+
+	set := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV2, UnorderedArray: true})
+
+	if set.MustHash([2]byte{1, 2}) != set.MustHash([2]byte{2, 1}) {
+		t.Error("expected unordered byte arrays to keep hashing as sets")
+	}
+
+	canonical, err := hasher.Canonicalize(struct{ ID [4]byte }{[4]byte{1, 2, 3, 4}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if b, ok := canonical.(map[string]any)["ID"].([]byte); !ok || string(b) != "\x01\x02\x03\x04" {
+		t.Errorf("expected byte arrays to canonicalize to bytes, got %#v", canonical)
+	}
+}
+
+func BenchmarkHasher_UUID(b *testing.B) {
+	ids := make([][16]byte, 64)
+
+	for i := range ids {
+		ids[i][0] = byte(i)
+	}
+
+	for _, format := range []datahash.Format{datahash.FormatV1, datahash.FormatV2} {
+		hasher := datahash.New(fnv.New64a, datahash.Options{Format: format})
+
+		b.Run(format.String(), func(b *testing.B) {
+			for b.Loop() {
+				_ = hasher.MustHash(ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package datahash
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// visitIndexThreshold is the number of visited pointers from which a visitSet maintains a map
+// instead of scanning its list, so hashing large linked structures stays linear.
+const visitIndexThreshold = 32
+
+// visitSet records visited pointers, or their identities, in the order of their first visit.
+type visitSet[K comparable] struct {
+	list  []K
+	index map[K]int // Positions in list, built once list reaches visitIndexThreshold.
+}
+
+// lookup returns the position of the first visit of k.
+func (s *visitSet[K]) lookup(k K) (int, bool) {
+	if s.index != nil {
+		ref, ok := s.index[k]
+
+		return ref, ok
+	}
+
+	ref := slices.Index(s.list, k)
+
+	return ref, ref >= 0
+}
+
+// add records k, which must not be recorded yet.
+func (s *visitSet[K]) add(k K) {
+	s.list = append(s.list, k)
+
+	switch {
+	case s.index != nil:
+		s.index[k] = len(s.list) - 1
+	case len(s.list) >= visitIndexThreshold:
+		s.index = make(map[K]int, 2*len(s.list))
+
+		for i, v := range s.list {
+			s.index[v] = i
+		}
+	}
+}
+
+// copyFrom replaces the records of s by the records of other.
+func (s *visitSet[K]) copyFrom(other *visitSet[K]) {
+	s.reset()
+
+	for _, k := range other.list {
+		s.add(k)
+	}
+}
+
+func (s *visitSet[K]) reset() {
+	clear(s.list)
+	s.list = s.list[:0]
+	s.index = nil
+}
+
+// revisitable caches the results of mayRevisit by pointer type.
+var revisitable sync.Map
+
+// mayRevisit reports whether hashing a value of the pointer type ptr can reach a pointer of
+// the same type again, i.e. whether ptr is part of a recursive type. Interfaces and HashEncoder
+// types may hold anything, so they count as recursive.
+func mayRevisit(ptr reflect.Type) bool {
+	if v, ok := revisitable.Load(ptr); ok {
+		return v.(bool)
+	}
+
+	seen := map[reflect.Type]bool{}
+
+	var walk func(t reflect.Type) bool
+
+	walk = func(t reflect.Type) bool {
+		if t == ptr {
+			return true
+		}
+
+		if seen[t] {
+			return false
+		}
+
+		seen[t] = true
+
+		if t.Implements(hashEncoderType) || reflect.PointerTo(t).Implements(hashEncoderType) {
+			return true
+		}
+
+		switch t.Kind() {
+		case reflect.Interface:
+			return true
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Chan:
+			return walk(t.Elem())
+		case reflect.Map:
+			return walk(t.Key()) || walk(t.Elem())
+		case reflect.Struct:
+			for i := range t.NumField() {
+				if walk(t.Field(i).Type) {
+					return true
+				}
+			}
+		case reflect.Func:
+			if t.CanSeq2() {
+				return walk(t.In(0).In(0)) || walk(t.In(0).In(1))
+			}
+
+			if t.CanSeq() {
+				return walk(t.In(0).In(0))
+			}
+		}
+
+		return false
+	}
+
+	result := walk(ptr.Elem())
+
+	revisitable.Store(ptr, result)
+
+	return result
+}
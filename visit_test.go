@@ -0,0 +1,91 @@
+package datahash_test
+
+import (
+	"errors"
+	"hash/fnv"
+	"testing"
+
+	"github.com/go-sqlt/datahash"
+)
+
+type chainLink struct {
+	Value int
+	Next  any
+}
+
+// chain links n nodes and points the last one back to the node at index target.
+func chain(n, target int) *chainLink {
+	nodes := make([]*chainLink, n)
+
+	for i := range nodes {
+		nodes[i] = &chainLink{Value: i}
+	}
+
+	for i := range n - 1 {
+		nodes[i].Next = nodes[i+1]
+	}
+
+	nodes[n-1].Next = nodes[target]
+
+	return nodes[0]
+}
+
+func TestHasher_LargeGraphs(t *testing.T) {
+	v2 := datahash.New(fnv.New64a, datahash.Options{Format: datahash.FormatV2})
+
+	if v2.MustHash(chain(100, 50)) == v2.MustHash(chain(100, 60)) {
+		t.Error("expected back-references beyond the index threshold to differ")
+	}
+
+	if v2.MustHash(chain(100, 50)) != v2.MustHash(chain(100, 50)) {
+		t.Error("expected equal graphs to hash equally")
+	}
+
+	if v2.MustHash(chain(10, 5)) == v2.MustHash(chain(10, 6)) {
+		t.Error("expected back-references of small graphs to differ")
+	}
+
+	if _, err := v2.Hash(chain(100_000, 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestHasher_NoCycleDetection(t *testing.T) {
+	type pair struct {
+		A, B *int
+	}
+
+	x, y := 1, 1
+
+	tracking := datahash.New(fnv.New64a, datahash.Options{})
+	untracked := datahash.New(fnv.New64a, datahash.Options{NoCycleDetection: true})
+
+	if tracking.MustHash(pair{A: &x, B: &x}) == tracking.MustHash(pair{A: &x, B: &y}) {
+		t.Error("expected shared pointers to be skipped with cycle detection")
+	}
+
+	if untracked.MustHash(pair{A: &x, B: &x}) != untracked.MustHash(pair{A: &x, B: &y}) {
+		t.Error("expected shared pointers to hash by content without cycle detection")
+	}
+
+	limited := datahash.New(fnv.New64a, datahash.Options{NoCycleDetection: true, MaxDepth: 100})
+
+	if _, err := limited.Hash(chain(10, 0)); !errors.Is(err, datahash.ErrDepthLimit) {
+		t.Errorf("expected cycles to be stopped by MaxDepth, got %v", err)
+	}
+
+	if _, err := limited.Hash(&chainLink{Value: 1, Next: &chainLink{Value: 2}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func BenchmarkHasher_LinkedList(b *testing.B) {
+	hasher := datahash.New(fnv.New64a, datahash.Options{})
+	list := chain(10_000, 0)
+
+	for b.Loop() {
+		if _, err := hasher.Hash(list); err != nil {
+			b.Fatal(err)
+		}
+	}
+}